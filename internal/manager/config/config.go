@@ -67,6 +67,18 @@ const (
 	// for video files.
 	CalculateMD5 = "calculate_md5"
 
+	// CalculatePartialHash is the config key used to determine if a partial
+	// content hash should be calculated for all files.
+	CalculatePartialHash = "calculate_partial_hash"
+
+	// CalculateImagePhash is the config key used to determine if a perceptual
+	// hash should be calculated for image files while scanning.
+	CalculateImagePhash = "calculate_image_phash"
+
+	// PartialHashSampleSize is the config key for the number of megabytes
+	// hashed from each of the start and end of a file for the partial hash.
+	PartialHashSampleSize = "partial_hash_sample_size"
+
 	// VideoFileNamingAlgorithm is the config key used to determine what hash
 	// should be used when generating and using generated files for scenes.
 	VideoFileNamingAlgorithm = "video_file_naming_algorithm"
@@ -137,6 +149,10 @@ const (
 	ScraperCertCheck          = "scraper_cert_check"
 	ScraperCDPPath            = "scraper_cdp_path"
 	ScraperExcludeTagPatterns = "scraper_exclude_tag_patterns"
+	ScraperAllowLocalFiles    = "scraper_allow_local_files"
+
+	ScraperMaxIdleConnsPerHost = "scraper_max_idle_conns_per_host"
+	ScraperMaxRedirects        = "scraper_max_redirects"
 
 	// stash-box options
 	StashBoxes = "stash_boxes"
@@ -808,6 +824,24 @@ func (i *Config) IsCalculateMD5() bool {
 	return i.getBool(CalculateMD5)
 }
 
+// IsCalculatePartialHash returns true if a partial content hash should be
+// calculated for all files.
+func (i *Config) IsCalculatePartialHash() bool {
+	return i.getBool(CalculatePartialHash)
+}
+
+// IsCalculateImagePhash returns true if a perceptual hash should be
+// calculated for image files while scanning.
+func (i *Config) IsCalculateImagePhash() bool {
+	return i.getBool(CalculateImagePhash)
+}
+
+// GetPartialHashSampleSize returns the number of bytes hashed from each of the
+// start and end of a file for the partial hash. Returns 0 if not set.
+func (i *Config) GetPartialHashSampleSize() int64 {
+	return int64(i.getInt(PartialHashSampleSize)) * 1024 * 1024
+}
+
 // GetVideoFileNamingAlgorithm returns what hash algorithm should be used for
 // naming generated scene video files.
 func (i *Config) GetVideoFileNamingAlgorithm() models.HashAlgorithm {
@@ -861,6 +895,24 @@ func (i *Config) GetScraperExcludeTagPatterns() []string {
 	return i.getStringSlice(ScraperExcludeTagPatterns)
 }
 
+// GetScraperAllowLocalFiles returns true if scrapers may load file:// urls.
+// This is intended for scraper development and is disabled by default.
+func (i *Config) GetScraperAllowLocalFiles() bool {
+	return i.getBool(ScraperAllowLocalFiles)
+}
+
+// GetScraperMaxIdleConnsPerHost returns the maximum number of idle connections
+// the scrapers keep open to a single host. Returns 0 if not set.
+func (i *Config) GetScraperMaxIdleConnsPerHost() int {
+	return i.getInt(ScraperMaxIdleConnsPerHost)
+}
+
+// GetScraperMaxRedirects returns the maximum number of redirects followed by
+// scraper requests. Returns 0 if not set.
+func (i *Config) GetScraperMaxRedirects() int {
+	return i.getInt(ScraperMaxRedirects)
+}
+
 func (i *Config) GetStashBoxes() []*models.StashBox {
 	var boxes []*models.StashBox
 	if err := i.unmarshalKey(StashBoxes, &boxes); err != nil {
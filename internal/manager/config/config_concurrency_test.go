@@ -51,11 +51,17 @@ func TestConcurrentConfigAccess(t *testing.T) {
 				i.SetInterface(CreateGalleriesFromFolders, i.GetCreateGalleriesFromFolders())
 				i.SetInterface(Language, i.GetLanguage())
 				i.SetInterface(VideoFileNamingAlgorithm, i.GetVideoFileNamingAlgorithm())
+				i.SetInterface(CalculatePartialHash, i.IsCalculatePartialHash())
+				i.GetPartialHashSampleSize()
+				i.SetInterface(CalculateImagePhash, i.IsCalculateImagePhash())
 				i.SetInterface(ScrapersPath, i.GetScrapersPath())
 				i.SetInterface(ScraperUserAgent, i.GetScraperUserAgent())
 				i.SetInterface(ScraperCDPPath, i.GetScraperCDPPath())
 				i.SetInterface(ScraperCertCheck, i.GetScraperCertCheck())
 				i.SetInterface(ScraperExcludeTagPatterns, i.GetScraperExcludeTagPatterns())
+				i.SetInterface(ScraperAllowLocalFiles, i.GetScraperAllowLocalFiles())
+				i.SetInterface(ScraperMaxIdleConnsPerHost, i.GetScraperMaxIdleConnsPerHost())
+				i.SetInterface(ScraperMaxRedirects, i.GetScraperMaxRedirects())
 				i.SetInterface(StashBoxes, i.GetStashBoxes())
 				i.GetDefaultPluginsPath()
 				i.SetInterface(PluginsPath, i.GetPluginsPath())
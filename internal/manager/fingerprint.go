@@ -9,6 +9,7 @@ import (
 	"github.com/stashapp/stash/pkg/file"
 	"github.com/stashapp/stash/pkg/hash/md5"
 	"github.com/stashapp/stash/pkg/hash/oshash"
+	"github.com/stashapp/stash/pkg/hash/partialhash"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
 )
@@ -60,57 +61,193 @@ func (c *fingerprintCalculator) calculateMD5(o file.Opener) (*models.Fingerprint
 	}, nil
 }
 
-func (c *fingerprintCalculator) CalculateFingerprints(f *models.BaseFile, o file.Opener, useExisting bool) ([]models.Fingerprint, error) {
-	var ret []models.Fingerprint
-	calculateMD5 := true
+func (c *fingerprintCalculator) calculatePartialHash(f *models.BaseFile, o file.Opener, sampleSize int64) (*models.Fingerprint, error) {
+	r, err := o.Open()
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+
+	defer r.Close()
+
+	hash, err := partialhash.FromReader(r, f.Size, sampleSize)
+	if err != nil {
+		return nil, fmt.Errorf("calculating %s: %w", models.FingerprintTypePartialSHA256, err)
+	}
+
+	return &models.Fingerprint{
+		Type:        models.FingerprintTypePartialSHA256,
+		Fingerprint: hash,
+	}, nil
+}
 
-	if useAsVideo(f.Path) {
-		var (
-			fp  *models.Fingerprint
-			err error
-		)
+// singlePassFingerprints holds the fingerprints calculated by calculateSinglePass.
+// Fingerprints that were not requested are nil.
+type singlePassFingerprints struct {
+	oshash  *models.Fingerprint
+	md5     *models.Fingerprint
+	partial *models.Fingerprint
+}
+
+// calculateSinglePass calculates the MD5 of the file in a single read of its
+// contents. The oshash is calculated from the same read if withOshash is true,
+// and the partial hash if partialSampleSize is positive, rather than reading
+// the head and tail of the file separately for each.
+func (c *fingerprintCalculator) calculateSinglePass(f *models.BaseFile, o file.Opener, withOshash bool, partialSampleSize int64) (*singlePassFingerprints, error) {
+	md5Writer := md5.NewWriter()
+	writers := []io.Writer{md5Writer}
+
+	var oshashWriter *oshash.Writer
+	if withOshash {
+		oshashWriter = oshash.NewWriter(f.Size)
+		writers = append(writers, oshashWriter)
+	}
 
-		if useExisting {
-			fp = f.Fingerprints.For(models.FingerprintTypeOshash)
+	var partialWriter *partialhash.Writer
+	if partialSampleSize > 0 {
+		var err error
+		partialWriter, err = partialhash.NewWriter(f.Size, partialSampleSize)
+		if err != nil {
+			return nil, err
 		}
+		writers = append(writers, partialWriter)
+	}
 
-		if fp == nil {
-			// calculate oshash first
-			fp, err = c.calculateOshash(f, o)
-			if err != nil {
-				return nil, err
-			}
+	r, err := o.Open()
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+
+	defer r.Close()
+
+	if _, err := io.Copy(io.MultiWriter(writers...), r); err != nil {
+		return nil, fmt.Errorf("reading file: %w", err)
+	}
+
+	ret := &singlePassFingerprints{
+		md5: &models.Fingerprint{
+			Type:        models.FingerprintTypeMD5,
+			Fingerprint: md5Writer.Checksum(),
+		},
+	}
+
+	if oshashWriter != nil {
+		hash, err := oshashWriter.Sum()
+		if err != nil {
+			return nil, fmt.Errorf("calculating oshash: %w", err)
+		}
+
+		ret.oshash = &models.Fingerprint{
+			Type:        models.FingerprintTypeOshash,
+			Fingerprint: hash,
 		}
+	}
 
-		ret = append(ret, *fp)
+	if partialWriter != nil {
+		hash, err := partialWriter.Sum()
+		if err != nil {
+			return nil, fmt.Errorf("calculating %s: %w", models.FingerprintTypePartialSHA256, err)
+		}
 
-		// only calculate MD5 if enabled in config
-		calculateMD5 = c.Config.IsCalculateMD5()
+		ret.partial = &models.Fingerprint{
+			Type:        models.FingerprintTypePartialSHA256,
+			Fingerprint: hash,
+		}
 	}
 
-	if calculateMD5 {
-		var (
-			fp  *models.Fingerprint
-			err error
-		)
+	return ret, nil
+}
+
+// partialHashSampleSize returns the sample size of the partial hash, or 0 if
+// the partial hash is not calculated.
+func (c *fingerprintCalculator) partialHashSampleSize() int64 {
+	if !c.Config.IsCalculatePartialHash() {
+		return 0
+	}
+
+	if ret := c.Config.GetPartialHashSampleSize(); ret > 0 {
+		return ret
+	}
+
+	return partialhash.DefaultSampleSize
+}
+
+// CalculateFingerprints calculates the oshash of videos, the MD5 if enabled,
+// and the partial hash if enabled. If the MD5 is calculated, then the whole
+// file is read once, and the other fingerprints are calculated from the same
+// read. Otherwise the oshash and partial hash each read only the head and
+// tail of the file.
+func (c *fingerprintCalculator) CalculateFingerprints(f *models.BaseFile, o file.Opener, useExisting bool) ([]models.Fingerprint, error) {
+	var (
+		oshashFP  *models.Fingerprint
+		md5FP     *models.Fingerprint
+		partialFP *models.Fingerprint
+	)
+
+	isVideo := useAsVideo(f.Path)
+
+	// only calculate MD5 for videos if enabled in config
+	calculateMD5 := !isVideo || c.Config.IsCalculateMD5()
+	partialSampleSize := c.partialHashSampleSize()
+	calculatePartial := partialSampleSize > 0
+
+	if useExisting {
+		oshashFP = f.Fingerprints.For(models.FingerprintTypeOshash)
+		md5FP = f.Fingerprints.For(models.FingerprintTypeMD5)
+		partialFP = f.Fingerprints.For(models.FingerprintTypePartialSHA256)
+	}
+
+	needOshash := isVideo && oshashFP == nil
+	needMD5 := calculateMD5 && md5FP == nil
+	needPartial := calculatePartial && partialFP == nil
+
+	if needMD5 && useExisting {
+		// log to indicate missing fingerprint is being calculated
+		logger.Infof("Calculating checksum for %s ...", f.Path)
+	}
+
+	if needMD5 {
+		// the whole file is read for the MD5, so calculate the others from the same read
+		var singlePartialSampleSize int64
+		if needPartial {
+			singlePartialSampleSize = partialSampleSize
+		}
 
-		if useExisting {
-			fp = f.Fingerprints.For(models.FingerprintTypeMD5)
+		fp, err := c.calculateSinglePass(f, o, needOshash, singlePartialSampleSize)
+		if err != nil {
+			return nil, err
 		}
 
-		if fp == nil {
-			if useExisting {
-				// log to indicate missing fingerprint is being calculated
-				logger.Infof("Calculating checksum for %s ...", f.Path)
+		md5FP = fp.md5
+		if needOshash {
+			oshashFP = fp.oshash
+		}
+		if needPartial {
+			partialFP = fp.partial
+		}
+	} else {
+		var err error
+		if needOshash {
+			if oshashFP, err = c.calculateOshash(f, o); err != nil {
+				return nil, err
 			}
+		}
 
-			fp, err = c.calculateMD5(o)
-			if err != nil {
+		if needPartial {
+			if partialFP, err = c.calculatePartialHash(f, o, partialSampleSize); err != nil {
 				return nil, err
 			}
 		}
+	}
 
-		ret = append(ret, *fp)
+	var ret []models.Fingerprint
+	if isVideo {
+		ret = append(ret, *oshashFP)
+	}
+	if calculateMD5 {
+		ret = append(ret, *md5FP)
+	}
+	if calculatePartial {
+		ret = append(ret, *partialFP)
 	}
 
 	return ret, nil
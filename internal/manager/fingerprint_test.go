@@ -0,0 +1,95 @@
+package manager
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/hash/md5"
+	"github.com/stashapp/stash/pkg/hash/oshash"
+	"github.com/stashapp/stash/pkg/hash/partialhash"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// countingOpener opens a reader of data, counting the opens and bytes read.
+type countingOpener struct {
+	data  []byte
+	opens int
+	read  int64
+}
+
+func (o *countingOpener) Open() (io.ReadCloser, error) {
+	o.opens++
+	return &countingReader{r: bytes.NewReader(o.data), o: o}, nil
+}
+
+type countingReader struct {
+	r *bytes.Reader
+	o *countingOpener
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.o.read += int64(n)
+	return n, err
+}
+
+func (r *countingReader) Close() error {
+	return nil
+}
+
+func TestFingerprintCalculator_calculateSinglePass(t *testing.T) {
+	// larger than the oshash chunk size and twice the partial hash sample
+	// size, so that the head and tail differ
+	data := bytes.Repeat([]byte("fingerprint test data "), 10000)
+	const sampleSize = 1024
+
+	wantOshash, err := oshash.FromReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantMD5 := md5.FromBytes(data)
+	wantPartial, err := partialhash.FromReader(bytes.NewReader(data), int64(len(data)), sampleSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := &countingOpener{data: data}
+	c := &fingerprintCalculator{}
+
+	fp, err := c.calculateSinglePass(&models.BaseFile{Size: int64(len(data))}, o, true, sampleSize)
+	if err != nil {
+		t.Fatalf("calculateSinglePass() error = %v", err)
+	}
+
+	if fp.oshash.Type != models.FingerprintTypeOshash || fp.oshash.Fingerprint != wantOshash {
+		t.Errorf("calculateSinglePass() oshash = %v, want %v", fp.oshash.Fingerprint, wantOshash)
+	}
+	if fp.md5.Type != models.FingerprintTypeMD5 || fp.md5.Fingerprint != wantMD5 {
+		t.Errorf("calculateSinglePass() md5 = %v, want %v", fp.md5.Fingerprint, wantMD5)
+	}
+	if fp.partial.Type != models.FingerprintTypePartialSHA256 || fp.partial.Fingerprint != wantPartial {
+		t.Errorf("calculateSinglePass() partial = %v, want %v", fp.partial.Fingerprint, wantPartial)
+	}
+
+	// the file is read once
+	if o.opens != 1 {
+		t.Errorf("calculateSinglePass() opened file %d times, want 1", o.opens)
+	}
+	if o.read != int64(len(data)) {
+		t.Errorf("calculateSinglePass() read %d bytes, want %d", o.read, len(data))
+	}
+
+	// fingerprints that are not requested are not calculated
+	fp, err = c.calculateSinglePass(&models.BaseFile{Size: int64(len(data))}, o, false, 0)
+	if err != nil {
+		t.Fatalf("calculateSinglePass() error = %v", err)
+	}
+
+	if fp.oshash != nil || fp.partial != nil {
+		t.Errorf("calculateSinglePass() calculated unrequested fingerprints")
+	}
+	if fp.md5.Fingerprint != wantMD5 {
+		t.Errorf("calculateSinglePass() md5 = %v, want %v", fp.md5.Fingerprint, wantMD5)
+	}
+}
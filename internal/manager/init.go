@@ -15,6 +15,7 @@ import (
 	"github.com/stashapp/stash/internal/log"
 	"github.com/stashapp/stash/internal/manager/config"
 	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/file"
 	"github.com/stashapp/stash/pkg/fsutil"
 	"github.com/stashapp/stash/pkg/gallery"
 	"github.com/stashapp/stash/pkg/group"
@@ -106,7 +107,9 @@ func Initialize(cfg *config.Config, l *log.Logger) (*Manager, error) {
 		GalleryService: galleryService,
 		GroupService:   groupService,
 
-		scanSubs: &subscriptionManager{},
+		scanSubs:         &subscriptionManager{},
+		fingerprintCache: file.NewMemoryFingerprintCache(),
+		erroredFiles:     file.NewMemoryErroredFileTracker(),
 	}
 
 	if !cfg.IsNewSystem() {
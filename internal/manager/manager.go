@@ -17,6 +17,7 @@ import (
 	"github.com/stashapp/stash/internal/log"
 	"github.com/stashapp/stash/internal/manager/config"
 	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/file"
 	"github.com/stashapp/stash/pkg/fsutil"
 	"github.com/stashapp/stash/pkg/job"
 	"github.com/stashapp/stash/pkg/logger"
@@ -69,6 +70,14 @@ type Manager struct {
 	GroupService   GroupService
 
 	scanSubs *subscriptionManager
+
+	// fingerprintCache is shared between scans, so that fingerprints calculated by
+	// an interrupted scan are reused by the next scan.
+	fingerprintCache *file.MemoryFingerprintCache
+
+	// erroredFiles records files that failed to scan, so that later scans can
+	// skip them.
+	erroredFiles *file.MemoryErroredFileTracker
 }
 
 var instance *Manager
@@ -13,6 +13,7 @@ import (
 	file_image "github.com/stashapp/stash/pkg/file/image"
 	"github.com/stashapp/stash/pkg/file/video"
 	"github.com/stashapp/stash/pkg/fsutil"
+	"github.com/stashapp/stash/pkg/hash/imagephash"
 	"github.com/stashapp/stash/pkg/job"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
@@ -87,6 +88,83 @@ type ScanMetadataInput struct {
 
 	// Filter options for the scan
 	Filter *ScanMetaDataFilterInput `json:"filter"`
+
+	// If set, files and folders that fail to scan are recorded and skipped, and
+	// the failed paths are reported as the job error when the scan finishes.
+	// Otherwise, errors are logged as they occur, and an error while walking
+	// the scan paths stops the scan.
+	CollectErrors bool `json:"collectErrors"`
+
+	// If set, fingerprints calculated by previous scans are reused for files
+	// whose size and modification time have not changed.
+	UseFingerprintCache bool `json:"useFingerprintCache"`
+
+	// If set, paths that differ only in their Unicode normalization form are
+	// treated as the same path.
+	NormalizeUnicode bool `json:"normalizeUnicode"`
+
+	// If set, missing parent folders of a new file are created, rather than
+	// failing the scan of the file.
+	CreateMissingFolders bool `json:"createMissingFolders"`
+
+	// If set, the oshash of unchanged files is recalculated and compared against
+	// the stored value. Mismatches are logged.
+	VerifyOshash bool `json:"verifyOshash"`
+
+	// If set, the fingerprints of files with a mismatched oshash are replaced
+	// with the recalculated fingerprints. Only applies if VerifyOshash is set.
+	RepairOshash bool `json:"repairOshash"`
+
+	// If set, a new file is only treated as a moved file if its strongest
+	// fingerprint matches the missing file.
+	StrictRenameDetection bool `json:"strictRenameDetection"`
+
+	// If set, new and changed files are recorded with their fingerprints only.
+	// Metadata is not read and scenes, images and galleries are not created
+	// until the files are scanned again without this option.
+	SkipDecorators bool `json:"skipDecorators"`
+
+	// New files with any of these fingerprints, such as trailers or samples,
+	// are skipped.
+	FingerprintDenylist []*ScanFingerprintInput `json:"fingerprintDenylist"`
+
+	// If set, the fingerprints of unchanged files are recalculated, and files
+	// whose contents have changed are rescanned.
+	VerifyContents bool `json:"verifyContents"`
+
+	// If set, empty files are skipped, so that files that have been created but
+	// not yet written are scanned by a later scan.
+	SkipEmptyFiles bool `json:"skipEmptyFiles"`
+
+	// Files modified within this many seconds are skipped, since they may still
+	// be being written. Zero disables the check.
+	SettleTime int `json:"settleTime"`
+
+	// If set, files that failed to scan are skipped by later scans until
+	// ErrorRetryInterval has passed or the file is modified.
+	SkipErroredFiles bool `json:"skipErroredFiles"`
+
+	// The number of seconds after a file fails to scan before it is scanned
+	// again. Zero skips errored files until they are modified.
+	// Only applies if SkipErroredFiles is set.
+	ErrorRetryInterval int `json:"errorRetryInterval"`
+
+	// If set, files whose name has changed, but whose size and modification time
+	// have not, are renamed without recalculating their fingerprints.
+	RenameWithoutRehash bool `json:"renameWithoutRehash"`
+
+	// If set, directory junctions and volume mount points are skipped rather
+	// than walked. Only applies on Windows.
+	SkipJunctions bool `json:"skipJunctions"`
+
+	// If set, new files are read before they are created, and files that
+	// cannot be read are skipped.
+	VerifyReadable bool `json:"verifyReadable"`
+
+	// If set, new folders are matched to missing folders by the fingerprints of
+	// their files, if they cannot be matched by the names, sizes and modification
+	// times of their files.
+	DetectFolderMovesByContent bool `json:"detectFolderMovesByContent"`
 }
 
 // Filter options for meta data scannning
@@ -95,13 +173,28 @@ type ScanMetaDataFilterInput struct {
 	MinModTime *time.Time `json:"minModTime"`
 }
 
+type ScanFingerprintInput struct {
+	Type string `json:"type"`
+	// Phash values are hexadecimal strings
+	Value string `json:"value"`
+}
+
 func (s *Manager) Scan(ctx context.Context, input ScanMetadataInput) (int, error) {
 	if err := s.validateFFmpeg(); err != nil {
 		return 0, err
 	}
 
+	denylist, err := scanFingerprintDenylist(input.FingerprintDenylist)
+	if err != nil {
+		return 0, err
+	}
+
 	cfg := config.GetInstance()
 
+	allFiles := file.FilterFunc(func(ctx context.Context, f models.File) bool {
+		return true
+	})
+
 	scanner := &file.Scanner{
 		Repository: file.NewRepository(s.Repository),
 		FileDecorators: []file.Decorator{
@@ -123,7 +216,44 @@ func (s *Manager) Scan(ctx context.Context, input ScanMetadataInput) (int, error
 		ZipFileExtensions:     cfg.GetGalleryExtensions(),
 		// ScanFilters is set in ScanJob.Execute
 		// HandlerRequiredFilters is set in ScanJob.Execute
-		Rescan: input.Rescan,
+		Rescan:                     input.Rescan,
+		NormalizeUnicode:           input.NormalizeUnicode,
+		CreateMissingFolders:       input.CreateMissingFolders,
+		VerifyOshash:               input.VerifyOshash,
+		RepairOshash:               input.RepairOshash,
+		StrictRenameDetection:      input.StrictRenameDetection,
+		SkipDecorators:             input.SkipDecorators,
+		FingerprintDenylist:        denylist,
+		SkipEmptyFiles:             input.SkipEmptyFiles,
+		SettleTime:                 time.Duration(input.SettleTime) * time.Second,
+		RenameWithoutRehash:        input.RenameWithoutRehash,
+		SkipJunctions:              input.SkipJunctions,
+		VerifyReadable:             input.VerifyReadable,
+		DetectFolderMovesByContent: input.DetectFolderMovesByContent,
+	}
+
+	if input.VerifyContents {
+		scanner.VerifyContentFilters = []file.Filter{allFiles}
+	}
+
+	if input.SkipErroredFiles {
+		scanner.ErroredFileTracker = s.erroredFiles
+		scanner.ErrorRetryInterval = time.Duration(input.ErrorRetryInterval) * time.Second
+	}
+
+	if input.UseFingerprintCache {
+		scanner.FingerprintCache = s.fingerprintCache
+	}
+
+	// the partial hash is calculated by fingerprintCalculator, so that it can
+	// share the read of the file used for the MD5
+	if cfg.IsCalculateImagePhash() {
+		// calculators replace FingerprintCalculator, so it must be included
+		// the image phash decodes the image, so it reads the file separately
+		scanner.FingerprintCalculators = []file.FilteredFingerprintCalculator{
+			{FingerprintCalculator: scanner.FingerprintCalculator, Filter: allFiles},
+			{FingerprintCalculator: &imagephash.Calculator{}, Filter: file.FilterFunc(imageFileFilter)},
+		}
 	}
 
 	scanJob := ScanJob{
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime/debug"
+	"strconv"
 	"sync"
 	"time"
 
@@ -72,6 +73,9 @@ func (j *ScanJob) Execute(ctx context.Context, progress *job.Progress) error {
 	j.scanner.FileHandlers = getScanHandlers(j.input, taskQueue, progress)
 	j.scanner.ScanFilters = []file.PathFilter{newScanFilter(c, repo, minModTime)}
 	j.scanner.HandlerRequiredFilters = []file.Filter{newHandlerRequiredFilter(cfg, repo)}
+	if j.input.CollectErrors {
+		j.scanner.Stats = &file.ScanStats{}
+	}
 
 	j.runJob(ctx, paths, nTasks, progress)
 
@@ -86,9 +90,63 @@ func (j *ScanJob) Execute(ctx context.Context, progress *job.Progress) error {
 	logger.Info(fmt.Sprintf("Scan finished (%s)", elapsed))
 
 	j.subscriptions.notify()
+
+	if j.scanner.Stats != nil {
+		return scanErrorsToError(j.scanner.Stats.Errors())
+	}
+
 	return nil
 }
 
+// maxReportedScanErrors is the maximum number of per-path errors included in
+// the error returned by a scan job.
+const maxReportedScanErrors = 20
+
+// scanErrorsToError returns an error listing the path and error of each of the
+// provided scan errors, or nil if there are none. Only the first
+// maxReportedScanErrors are listed; all errors have already been logged.
+func scanErrorsToError(scanErrors []file.ScanError) error {
+	if len(scanErrors) == 0 {
+		return nil
+	}
+
+	var errs []error
+	for i, e := range scanErrors {
+		if i == maxReportedScanErrors {
+			errs = append(errs, fmt.Errorf("and %d more", len(scanErrors)-i))
+			break
+		}
+		errs = append(errs, e)
+	}
+
+	return fmt.Errorf("%d errors encountered during scan:\n%w", len(scanErrors), errors.Join(errs...))
+}
+
+// scanFingerprintDenylist converts the fingerprint denylist of the scan input to
+// fingerprints. Phash values are converted from hexadecimal strings.
+func scanFingerprintDenylist(input []*ScanFingerprintInput) (models.Fingerprints, error) {
+	var ret models.Fingerprints
+	for _, i := range input {
+		var v interface{} = i.Value
+
+		if i.Type == models.FingerprintTypePhash {
+			vInt, err := strconv.ParseUint(i.Value, 16, 64)
+			if err != nil {
+				return nil, fmt.Errorf("converting phash %s: %w", i.Value, err)
+			}
+
+			v = int64(vInt)
+		}
+
+		ret = append(ret, models.Fingerprint{
+			Type:        i.Type,
+			Fingerprint: v,
+		})
+	}
+
+	return ret, nil
+}
+
 func (j *ScanJob) runJob(ctx context.Context, paths []string, nTasks int, progress *job.Progress) {
 	var wg sync.WaitGroup
 	wg.Add(1)
@@ -178,6 +236,12 @@ func (j *ScanJob) queueFileFunc(ctx context.Context, f models.FS, zipFile *file.
 
 		size, err := file.GetFileSize(f, path, info)
 		if err != nil {
+			if j.scanner.Stats != nil {
+				// record the error and skip the file
+				logger.Errorf("error scanning %q: %v", path, err)
+				j.scanner.Stats.AddError(path, err)
+				return nil
+			}
 			return err
 		}
 
@@ -256,21 +320,56 @@ func (j *ScanJob) processQueue(ctx context.Context, parallelTasks int, progress
 		}()
 
 		for f := range j.fileQueue {
-			logger.Tracef("Processing queued file %s", f.Path)
-			if err := ctx.Err(); err != nil {
-				return
+			batch := j.takeQueueBatch(f)
+
+			// load the parent folders of the batch together, rather than
+			// querying for each folder as its files are scanned
+			paths := make([]string, len(batch))
+			for i, ff := range batch {
+				paths[i] = ff.Path
+			}
+			if err := j.scanner.PreloadFolders(ctx, paths); err != nil && !errors.Is(err, context.Canceled) {
+				logger.Warnf("error preloading folders: %v", err)
 			}
 
-			wg.Add()
-			ff := f
-			go func() {
-				defer wg.Done()
-				j.processQueueItem(ctx, ff, progress)
-			}()
+			for _, ff := range batch {
+				logger.Tracef("Processing queued file %s", ff.Path)
+				if err := ctx.Err(); err != nil {
+					return
+				}
+
+				wg.Add()
+				ff := ff
+				go func() {
+					defer wg.Done()
+					j.processQueueItem(ctx, ff, progress)
+				}()
+			}
 		}
 	}()
 }
 
+const scanPreloadBatchSize = 100
+
+// takeQueueBatch returns f along with any files already waiting in the queue,
+// up to scanPreloadBatchSize files.
+func (j *ScanJob) takeQueueBatch(f file.ScannedFile) []file.ScannedFile {
+	ret := []file.ScannedFile{f}
+	for len(ret) < scanPreloadBatchSize {
+		select {
+		case ff, ok := <-j.fileQueue:
+			if !ok {
+				return ret
+			}
+			ret = append(ret, ff)
+		default:
+			return ret
+		}
+	}
+
+	return ret
+}
+
 func (j *ScanJob) processQueueItem(ctx context.Context, f file.ScannedFile, progress *job.Progress) {
 	progress.ExecuteTask("Scanning "+f.Path, func() {
 		var err error
@@ -309,6 +408,11 @@ func (j *ScanJob) handleFile(ctx context.Context, f file.ScannedFile, progress *
 		return err
 	}
 
+	// the file was skipped, or the error was recorded in the scan stats
+	if r == nil {
+		return nil
+	}
+
 	// handle rename should have already handled the contents of the zip file
 	// so shouldn't need to scan it again
 
@@ -330,18 +434,16 @@ func (j *ScanJob) handleFile(ctx context.Context, f file.ScannedFile, progress *
 }
 
 func (j *ScanJob) scanZipFile(ctx context.Context, f file.ScannedFile, progress *job.Progress) error {
-	zipFS, err := f.FS.OpenZip(f.Path, f.Size)
+	zipFS, err := j.scanner.OpenZip(f)
 	if err != nil {
-		if errors.Is(err, file.ErrNotReaderAt) {
-			// can't walk the zip file
-			// just return
-			logger.Debugf("Skipping zip file %q as it cannot be opened for walking", f.Path)
-			return nil
-		}
-
 		return err
 	}
 
+	// zip file was skipped
+	if zipFS == nil {
+		return nil
+	}
+
 	defer zipFS.Close()
 
 	return file.SymWalk(zipFS, f.Path, j.queueFileFunc(ctx, zipFS, &f, progress))
@@ -0,0 +1,55 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanErrorsToError(t *testing.T) {
+	assert.NoError(t, scanErrorsToError(nil))
+
+	errTest := errors.New("test error")
+
+	var scanErrors []file.ScanError
+	for i := 0; i < maxReportedScanErrors+5; i++ {
+		scanErrors = append(scanErrors, file.ScanError{
+			Path: fmt.Sprintf("/path/%d.mp4", i),
+			Err:  errTest,
+		})
+	}
+
+	err := scanErrorsToError(scanErrors[:2])
+	assert.ErrorIs(t, err, errTest)
+	assert.Contains(t, err.Error(), "2 errors encountered")
+	assert.Contains(t, err.Error(), "/path/0.mp4: test error")
+	assert.Contains(t, err.Error(), "/path/1.mp4: test error")
+
+	err = scanErrorsToError(scanErrors)
+	assert.Contains(t, err.Error(), fmt.Sprintf("%d errors encountered", len(scanErrors)))
+	assert.Equal(t, maxReportedScanErrors, strings.Count(err.Error(), "test error"))
+	assert.Contains(t, err.Error(), "and 5 more")
+}
+
+func TestScanFingerprintDenylist(t *testing.T) {
+	got, err := scanFingerprintDenylist([]*ScanFingerprintInput{
+		{Type: models.FingerprintTypeOshash, Value: "abcdef0123456789"},
+		{Type: models.FingerprintTypePhash, Value: "ff"},
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, models.Fingerprints{
+			{Type: models.FingerprintTypeOshash, Fingerprint: "abcdef0123456789"},
+			{Type: models.FingerprintTypePhash, Fingerprint: int64(255)},
+		}, got)
+	}
+
+	_, err = scanFingerprintDenylist([]*ScanFingerprintInput{
+		{Type: models.FingerprintTypePhash, Value: "invalid"},
+	})
+	assert.Error(t, err)
+}
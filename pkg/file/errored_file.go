@@ -0,0 +1,72 @@
+package file
+
+import (
+	"sync"
+	"time"
+)
+
+// ErroredFileTracker records files that failed to scan, keyed by file path, so
+// that they can be skipped by subsequent scans. Entries are only valid if the
+// modification time of the file matches the value that was present when the
+// file failed to scan.
+type ErroredFileTracker interface {
+	// Get returns the time that the file at the provided path last failed to scan.
+	// Returns false if there is no entry, or if the mod time does not match the entry.
+	Get(path string, modTime time.Time) (time.Time, bool)
+	// Set records that the file at the provided path and mod time failed to scan at erroredAt.
+	Set(path string, modTime time.Time, erroredAt time.Time)
+	// Delete removes the entry for the provided path.
+	Delete(path string)
+}
+
+type erroredFileEntry struct {
+	modTime   time.Time
+	erroredAt time.Time
+}
+
+// MemoryErroredFileTracker is an ErroredFileTracker that stores entries in memory.
+type MemoryErroredFileTracker struct {
+	entries map[string]erroredFileEntry
+	mutex   sync.Mutex
+}
+
+func NewMemoryErroredFileTracker() *MemoryErroredFileTracker {
+	return &MemoryErroredFileTracker{
+		entries: make(map[string]erroredFileEntry),
+	}
+}
+
+func (t *MemoryErroredFileTracker) Get(path string, modTime time.Time) (time.Time, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	e, ok := t.entries[path]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	// invalidate the entry if the file has changed
+	if !e.modTime.Equal(modTime) {
+		delete(t.entries, path)
+		return time.Time{}, false
+	}
+
+	return e.erroredAt, true
+}
+
+func (t *MemoryErroredFileTracker) Set(path string, modTime time.Time, erroredAt time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.entries[path] = erroredFileEntry{
+		modTime:   modTime,
+		erroredAt: erroredAt,
+	}
+}
+
+func (t *MemoryErroredFileTracker) Delete(path string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.entries, path)
+}
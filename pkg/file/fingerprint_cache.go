@@ -0,0 +1,67 @@
+package file
+
+import (
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// FingerprintCache stores previously calculated fingerprints, keyed by file path.
+// Cached fingerprints are only valid if the size and modification time of the file
+// match the values that were present when the fingerprints were calculated.
+type FingerprintCache interface {
+	// Get returns the cached fingerprints for the provided path. Returns false
+	// if there is no entry, or if the size or mod time does not match the entry.
+	Get(path string, size int64, modTime time.Time) (models.Fingerprints, bool)
+	// Set stores the fingerprints for the provided path, size and mod time.
+	Set(path string, size int64, modTime time.Time, fp models.Fingerprints)
+}
+
+type fingerprintCacheEntry struct {
+	size         int64
+	modTime      time.Time
+	fingerprints models.Fingerprints
+}
+
+// MemoryFingerprintCache is a FingerprintCache that stores entries in memory.
+// Entries are lost when the process exits.
+type MemoryFingerprintCache struct {
+	entries map[string]fingerprintCacheEntry
+	mutex   sync.Mutex
+}
+
+func NewMemoryFingerprintCache() *MemoryFingerprintCache {
+	return &MemoryFingerprintCache{
+		entries: make(map[string]fingerprintCacheEntry),
+	}
+}
+
+func (c *MemoryFingerprintCache) Get(path string, size int64, modTime time.Time) (models.Fingerprints, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, ok := c.entries[path]
+	if !ok {
+		return nil, false
+	}
+
+	// invalidate the entry if the file has changed
+	if e.size != size || !e.modTime.Equal(modTime) {
+		delete(c.entries, path)
+		return nil, false
+	}
+
+	return e.fingerprints, true
+}
+
+func (c *MemoryFingerprintCache) Set(path string, size int64, modTime time.Time, fp models.Fingerprints) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.entries[path] = fingerprintCacheEntry{
+		size:         size,
+		modTime:      modTime,
+		fingerprints: fp,
+	}
+}
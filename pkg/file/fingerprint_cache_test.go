@@ -0,0 +1,113 @@
+package file
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingFingerprintCalculator struct {
+	calls int
+}
+
+func (c *countingFingerprintCalculator) CalculateFingerprints(f *models.BaseFile, o Opener, useExisting bool) ([]models.Fingerprint, error) {
+	c.calls++
+	return []models.Fingerprint{
+		{
+			Type:        models.FingerprintTypeOshash,
+			Fingerprint: "abc",
+		},
+	}, nil
+}
+
+func TestScanner_calculateFingerprintsCache(t *testing.T) {
+	const path = "/path/to/file.mp4"
+	modTime := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	calculator := &countingFingerprintCalculator{}
+	s := &Scanner{
+		FingerprintCalculator: calculator,
+		FingerprintCache:      NewMemoryFingerprintCache(),
+	}
+
+	f := &models.BaseFile{
+		Path:     path,
+		DirEntry: models.DirEntry{ModTime: modTime},
+		Size:     100,
+	}
+
+	const useExisting = false
+	ctx := context.Background()
+
+	// first call populates the cache
+	fp, err := s.calculateFingerprints(ctx, nil, f, path, useExisting)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calculator.calls)
+	assert.Equal(t, "abc", fp.GetString(models.FingerprintTypeOshash))
+
+	// unchanged file should use the cache
+	fp, err = s.calculateFingerprints(ctx, nil, f, path, useExisting)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calculator.calls)
+	assert.Equal(t, "abc", fp.GetString(models.FingerprintTypeOshash))
+
+	// size change forces recalculation
+	f.Size = 200
+	_, err = s.calculateFingerprints(ctx, nil, f, path, useExisting)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calculator.calls)
+
+	// mod time change forces recalculation
+	f.ModTime = modTime.Add(time.Second)
+	_, err = s.calculateFingerprints(ctx, nil, f, path, useExisting)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calculator.calls)
+
+	// rescan bypasses the cache
+	s.Rescan = true
+	_, err = s.calculateFingerprints(ctx, nil, f, path, useExisting)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, calculator.calls)
+}
+
+func TestMemoryFingerprintCache_Get(t *testing.T) {
+	const path = "file.mp4"
+	modTime := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	fp := models.Fingerprints{
+		{
+			Type:        models.FingerprintTypeMD5,
+			Fingerprint: "md5",
+		},
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		size    int64
+		modTime time.Time
+		wantOk  bool
+	}{
+		{"match", path, 100, modTime, true},
+		{"missing", "other.mp4", 100, modTime, false},
+		{"size changed", path, 101, modTime, false},
+		{"mod time changed", path, 100, modTime.Add(time.Second), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewMemoryFingerprintCache()
+			c.Set(path, 100, modTime, fp)
+
+			got, ok := c.Get(tt.path, tt.size, tt.modTime)
+			assert.Equal(t, tt.wantOk, ok)
+			if tt.wantOk {
+				assert.Equal(t, fp, got)
+			} else {
+				assert.Nil(t, got)
+			}
+		})
+	}
+}
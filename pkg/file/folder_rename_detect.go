@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"path/filepath"
 
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
@@ -79,7 +80,67 @@ func (s *Scanner) detectFolderMove(ctx context.Context, file ScannedFile) (*mode
 	// in order for a folder to be considered moved, the existing folder must be
 	// missing, and the majority of the old folder's files must be present, unchanged,
 	// in the new folder.
+	r := s.Repository
+
+	// check if the files exist in the database based on basename, size and mod time
+	ret, err := s.findMovedFolder(ctx, file, func(path string, info fs.FileInfo, size int64) ([]models.File, error) {
+		return r.File.FindByFileInfo(ctx, info, size)
+	})
+	if err != nil || ret != nil || !s.DetectFolderMovesByContent {
+		return ret, err
+	}
+
+	// fall back to matching the files by their fingerprints
+	return s.findMovedFolder(ctx, file, func(path string, info fs.FileInfo, size int64) ([]models.File, error) {
+		return s.findFilesByContent(ctx, file.FS, path, info, size)
+	})
+}
+
+// findFilesByContent returns the existing files that have any of the
+// fingerprints of the file at path. Returns nil if the fingerprints cannot be
+// calculated.
+func (s *Scanner) findFilesByContent(ctx context.Context, fs models.FS, path string, info fs.FileInfo, size int64) ([]models.File, error) {
+	f := &models.BaseFile{
+		DirEntry: models.DirEntry{
+			ModTime: ModTime(info),
+		},
+		Path:     path,
+		Basename: filepath.Base(path),
+		Size:     size,
+	}
+
+	// the fingerprints are cached if FingerprintCache is set, so that they are
+	// not calculated again when the file is scanned
+	const useExisting = false
+	fp, err := s.calculateFingerprints(ctx, fs, f, path, useExisting)
+	if err != nil {
+		logger.Errorf("calculating fingerprints for %q: %v", path, err)
+		return nil, nil
+	}
+
+	var ret []models.File
+	seen := make(map[models.FileID]bool)
+	for _, v := range fp {
+		existing, err := s.Repository.File.FindByFingerprint(ctx, v)
+		if err != nil {
+			return nil, fmt.Errorf("finding files by fingerprint %v: %w", v.Fingerprint, err)
+		}
+
+		for _, e := range existing {
+			if !seen[e.Base().ID] {
+				seen[e.Base().ID] = true
+				ret = append(ret, e)
+			}
+		}
+	}
+
+	return ret, nil
+}
 
+// findMovedFolder walks the files in the folder, and returns the missing folder
+// that the most files were moved from, based on the existing files returned
+// by match for each file.
+func (s *Scanner) findMovedFolder(ctx context.Context, file ScannedFile, match func(path string, info fs.FileInfo, size int64) ([]models.File, error)) (*models.Folder, error) {
 	detector := folderRenameDetector{
 		candidates: make(map[models.FolderID]folderRenameCandidate),
 		rejects:    make(map[models.FolderID]struct{}),
@@ -111,7 +172,7 @@ func (s *Scanner) detectFolderMove(ctx context.Context, file ScannedFile) (*mode
 			return nil
 		}
 
-		if !s.AcceptEntry(ctx, path, info) {
+		if !s.acceptEntry(ctx, path, info) {
 			return nil
 		}
 
@@ -120,8 +181,7 @@ func (s *Scanner) detectFolderMove(ctx context.Context, file ScannedFile) (*mode
 			return fmt.Errorf("getting file size for %q: %w", path, err)
 		}
 
-		// check if the file exists in the database based on basename, size and mod time
-		existing, err := r.File.FindByFileInfo(ctx, info, size)
+		existing, err := match(path, info, size)
 		if err != nil {
 			return fmt.Errorf("checking for existing file %q: %w", path, err)
 		}
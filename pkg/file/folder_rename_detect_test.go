@@ -0,0 +1,143 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestScanner_detectFolderMoveByContent(t *testing.T) {
+	const oldFolderID = models.FolderID(5)
+
+	dir := t.TempDir()
+
+	// the folder was moved from a root that no longer exists, and the
+	// modification times of its files were not preserved
+	oldFolder := &models.Folder{
+		ID:   oldFolderID,
+		Path: filepath.Join(dir, "old root", "folder"),
+	}
+
+	newPath := filepath.Join(dir, "new root", "folder")
+	if err := os.MkdirAll(newPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	calculator := &PartialHashCalculator{}
+	fs := &OsFS{}
+
+	var fingerprints []models.Fingerprint
+	for _, name := range []string{"a.mp4", "b.mp4", "c.mp4"} {
+		p := filepath.Join(newPath, name)
+		data := []byte("contents of " + name)
+		if err := os.WriteFile(p, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		f := &models.BaseFile{Path: p, Size: int64(len(data))}
+		fp, err := calculator.CalculateFingerprints(f, &fsOpener{fs: fs, name: p}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fingerprints = append(fingerprints, fp...)
+	}
+
+	newDB := func() *mocks.Database {
+		db := mocks.NewDatabase()
+		db.File.On("FindByFileInfo", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+
+		// two of the three files are found in the old folder
+		for i, fp := range fingerprints[:2] {
+			existing := &models.BaseFile{
+				ID:             models.FileID(i + 1),
+				ParentFolderID: oldFolderID,
+			}
+			db.File.On("FindByFingerprint", mock.Anything, fp).Return([]models.File{existing}, nil)
+		}
+		db.File.On("FindByFingerprint", mock.Anything, mock.Anything).Return(nil, nil)
+
+		db.Folder.On("Find", mock.Anything, oldFolderID).Return(oldFolder, nil)
+		db.File.On("CountByFolderID", mock.Anything, oldFolderID).Return(3, nil)
+		return db
+	}
+
+	folder := ScannedFile{
+		BaseFile: &models.BaseFile{Path: newPath},
+		FS:       fs,
+	}
+
+	// not detected using basename, size and mod time
+	db := newDB()
+	s := &Scanner{
+		Repository:            newTestRepository(db),
+		FingerprintCalculator: calculator,
+	}
+
+	got, err := s.detectFolderMove(context.Background(), folder)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+	db.File.AssertNotCalled(t, "FindByFingerprint", mock.Anything, mock.Anything)
+
+	// detected using the fingerprints of the files
+	db = newDB()
+	s = &Scanner{
+		Repository:                 newTestRepository(db),
+		FingerprintCalculator:      calculator,
+		DetectFolderMovesByContent: true,
+	}
+
+	got, err = s.detectFolderMove(context.Background(), folder)
+	assert.NoError(t, err)
+	assert.Equal(t, oldFolder, got)
+	db.File.AssertNumberOfCalls(t, "FindByFingerprint", 3)
+}
+
+func TestScanner_detectFolderMoveByContentExistingFolder(t *testing.T) {
+	const oldFolderID = models.FolderID(5)
+
+	dir := t.TempDir()
+
+	// the old folder still exists, so the files are copies
+	oldFolder := &models.Folder{
+		ID:   oldFolderID,
+		Path: filepath.Join(dir, "old"),
+	}
+
+	newPath := filepath.Join(dir, "new")
+	for _, p := range []string{oldFolder.Path, newPath} {
+		if err := os.Mkdir(p, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(newPath, "a.mp4"), []byte("contents"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	db := mocks.NewDatabase()
+	db.File.On("FindByFileInfo", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+	db.File.On("FindByFingerprint", mock.Anything, mock.Anything).Return([]models.File{
+		&models.BaseFile{ID: 1, ParentFolderID: oldFolderID},
+	}, nil)
+	db.Folder.On("Find", mock.Anything, oldFolderID).Return(oldFolder, nil)
+
+	s := &Scanner{
+		Repository:                 newTestRepository(db),
+		FingerprintCalculator:      &PartialHashCalculator{},
+		DetectFolderMovesByContent: true,
+	}
+
+	got, err := s.detectFolderMove(context.Background(), ScannedFile{
+		BaseFile: &models.BaseFile{Path: newPath},
+		FS:       &OsFS{},
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+	db.File.AssertNotCalled(t, "CountByFolderID", mock.Anything, mock.Anything)
+}
@@ -65,3 +65,7 @@ func (f *OsFS) OpenZip(name string, size int64) (models.ZipFS, error) {
 func (f *OsFS) IsPathCaseSensitive(path string) (bool, error) {
 	return fsutil.IsFsPathCaseSensitive(path)
 }
+
+func (f *OsFS) IsJunction(info fs.FileInfo) bool {
+	return fsutil.IsJunction(info)
+}
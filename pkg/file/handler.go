@@ -3,6 +3,7 @@ package file
 import (
 	"context"
 	"io/fs"
+	"time"
 
 	"github.com/stashapp/stash/pkg/models"
 )
@@ -29,6 +30,79 @@ func (ff FilterFunc) Accept(ctx context.Context, f models.File) bool {
 	return ff(ctx, f)
 }
 
+// SkipReason describes why an entry was not scanned.
+type SkipReason string
+
+const (
+	// SkipReasonFiltered indicates that the entry was not accepted by the scan filters.
+	SkipReasonFiltered SkipReason = "filtered"
+	// SkipReasonZipNotWalkable indicates that the contents of a zip file could not be walked.
+	SkipReasonZipNotWalkable SkipReason = "zip not walkable"
+	// SkipReasonDeniedFingerprint indicates that a new file has a fingerprint in the fingerprint denylist.
+	SkipReasonDeniedFingerprint SkipReason = "denied fingerprint"
+	// SkipReasonEmpty indicates that the file is empty, and may still be being written.
+	SkipReasonEmpty SkipReason = "empty"
+	// SkipReasonUnsettled indicates that the file was modified within the settle time,
+	// and may still be being written.
+	SkipReasonUnsettled SkipReason = "recently modified"
+	// SkipReasonErrored indicates that the file failed to scan recently, and has not
+	// been modified since.
+	SkipReasonErrored SkipReason = "previously errored"
+	// SkipReasonJunction indicates that the entry is a directory junction, and
+	// junctions are not followed.
+	SkipReasonJunction SkipReason = "junction"
+	// SkipReasonUnreadable indicates that a new file exists, but could not be read.
+	SkipReasonUnreadable SkipReason = "unreadable"
+)
+
+// SkipHandler is notified when an entry is skipped during scanning.
+type SkipHandler interface {
+	HandleSkip(path string, reason SkipReason)
+}
+
+type SkipHandlerFunc func(path string, reason SkipReason)
+
+func (shf SkipHandlerFunc) HandleSkip(path string, reason SkipReason) {
+	shf(path, reason)
+}
+
+// OshashMismatchHandler is notified when the oshash calculated for an existing file
+// does not match the oshash stored for it.
+type OshashMismatchHandler interface {
+	HandleOshashMismatch(f models.File, stored string, calculated string)
+}
+
+type OshashMismatchHandlerFunc func(f models.File, stored string, calculated string)
+
+func (h OshashMismatchHandlerFunc) HandleOshashMismatch(f models.File, stored string, calculated string) {
+	h(f, stored, calculated)
+}
+
+// FingerprintCollisionHandler is notified when a new file shares a fingerprint with
+// existing files that are still present, so that it cannot be treated as a move.
+// fp is the strongest fingerprint shared with the existing files.
+type FingerprintCollisionHandler interface {
+	HandleFingerprintCollision(f models.File, fp models.Fingerprint, existing []models.File)
+}
+
+type FingerprintCollisionHandlerFunc func(f models.File, fp models.Fingerprint, existing []models.File)
+
+func (h FingerprintCollisionHandlerFunc) HandleFingerprintCollision(f models.File, fp models.Fingerprint, existing []models.File) {
+	h(f, fp, existing)
+}
+
+// FingerprintTimingHandler is notified of the time taken to calculate the
+// fingerprints of a file. size is the size of the file in bytes.
+type FingerprintTimingHandler interface {
+	HandleFingerprintTiming(path string, size int64, duration time.Duration)
+}
+
+type FingerprintTimingHandlerFunc func(path string, size int64, duration time.Duration)
+
+func (h FingerprintTimingHandlerFunc) HandleFingerprintTiming(path string, size int64, duration time.Duration) {
+	h(path, size, duration)
+}
+
 // Handler provides a handler for Files.
 type Handler interface {
 	Handle(ctx context.Context, f models.File, oldFile models.File) error
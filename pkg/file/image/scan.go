@@ -22,12 +22,27 @@ import (
 
 var ErrUnsupportedAVIFInZip = errors.New("AVIF images in zip files is unsupported")
 
+// metadataVersion is the version of the metadata set by Decorator on image
+// files. It is recorded on decorated files, and should be incremented when the
+// metadata set by Decorator changes, so that existing files can be decorated
+// again. Video clips are versioned by the video decorator.
+const metadataVersion = 1
+
 // Decorator adds image specific fields to a File.
 type Decorator struct {
 	FFProbe *ffmpeg.FFProbe
 }
 
 func (d *Decorator) Decorate(ctx context.Context, fs models.FS, f models.File) (models.File, error) {
+	ret, err := d.decorate(ctx, fs, f)
+	if imf, ok := ret.(*models.ImageFile); ok && err == nil {
+		imf.MetadataVersion = metadataVersion
+	}
+
+	return ret, err
+}
+
+func (d *Decorator) decorate(ctx context.Context, fs models.FS, f models.File) (models.File, error) {
 	base := f.Base()
 
 	// ignore clips in non-OsFS filesystems as ffprobe cannot read them
@@ -156,3 +171,17 @@ func (d *Decorator) IsMissingMetadata(ctx context.Context, fs models.FS, f model
 		return true
 	}
 }
+
+// MetadataVersion returns the metadata version recorded on f. Returns false if
+// f is neither an image nor a video file.
+func (d *Decorator) MetadataVersion(ctx context.Context, fs models.FS, f models.File) (int, bool) {
+	switch v := f.(type) {
+	case *models.ImageFile:
+		return v.MetadataVersion, true
+	case *models.VideoFile:
+		videoFileDecorator := video.Decorator{FFProbe: d.FFProbe}
+		return videoFileDecorator.MetadataVersion(ctx, fs, v)
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,59 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// mimeSniffLength is the number of bytes read from the start of a file to
+// detect its MIME type. http.DetectContentType considers at most this many.
+const mimeSniffLength = 512
+
+// MimeTypeDecorator is a Decorator that detects the MIME type of a file from
+// its contents, and stores it in the MimeType field. It may be used with any
+// file type.
+//
+// Detection uses http.DetectContentType, so files with unrecognised contents
+// are given the application/octet-stream type. Parameters such as the charset
+// are not stored.
+type MimeTypeDecorator struct{}
+
+func (d *MimeTypeDecorator) detect(o Opener) (string, error) {
+	r, err := o.Open()
+	if err != nil {
+		return "", fmt.Errorf("opening file: %w", err)
+	}
+	defer r.Close()
+
+	buf := make([]byte, mimeSniffLength)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", fmt.Errorf("reading file: %w", err)
+	}
+
+	t := http.DetectContentType(buf[:n])
+	t, _, _ = strings.Cut(t, ";")
+	return strings.TrimSpace(t), nil
+}
+
+func (d *MimeTypeDecorator) Decorate(ctx context.Context, fs models.FS, f models.File) (models.File, error) {
+	base := f.Base()
+	t, err := d.detect(&fsOpener{fs: fs, name: base.Path})
+	if err != nil {
+		return f, fmt.Errorf("detecting MIME type of %q: %w", base.Path, err)
+	}
+
+	base.MimeType = t
+	return f, nil
+}
+
+// IsMissingMetadata returns true if the MIME type of the file has not been
+// detected.
+func (d *MimeTypeDecorator) IsMissingMetadata(ctx context.Context, fs models.FS, f models.File) bool {
+	return f.Base().MimeType == ""
+}
@@ -0,0 +1,84 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMimeTypeDecorator(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFile := func(name string, contents []byte) string {
+		t.Helper()
+
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, contents, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	tests := []struct {
+		name     string
+		contents []byte
+		want     string
+	}{
+		{"png", []byte("\x89PNG\x0D\x0A\x1A\x0A\x00\x00\x00\x0DIHDR"), "image/png"},
+		{"jpg", []byte("\xFF\xD8\xFF\xE0\x00\x10JFIF\x00"), "image/jpeg"},
+		{"gif", []byte("GIF89a\x01\x00\x01\x00"), "image/gif"},
+		{"mp4", []byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom"), "video/mp4"},
+		{"zip", []byte("PK\x03\x04\x14\x00\x00\x00"), "application/zip"},
+		// parameters such as the charset are not stored
+		{"txt", []byte("some text"), "text/plain"},
+		{"empty", []byte{}, "text/plain"},
+		{"bin", []byte{0x00, 0x01, 0x02, 0x03}, "application/octet-stream"},
+	}
+
+	d := &MimeTypeDecorator{}
+	fs := &OsFS{}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := writeFile(tt.name, tt.contents)
+			f := &models.BaseFile{Path: p}
+
+			assert.True(t, d.IsMissingMetadata(context.Background(), fs, f))
+
+			got, err := d.Decorate(context.Background(), fs, f)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, tt.want, got.Base().MimeType)
+			assert.False(t, d.IsMissingMetadata(context.Background(), fs, got))
+		})
+	}
+
+	t.Run("video file", func(t *testing.T) {
+		p := writeFile("video", tests[3].contents)
+		f := &models.VideoFile{
+			BaseFile: &models.BaseFile{Path: p},
+			Format:   "mp4",
+		}
+
+		got, err := d.Decorate(context.Background(), fs, f)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Same(t, f, got)
+		assert.Equal(t, "video/mp4", f.MimeType)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		f := &models.BaseFile{Path: filepath.Join(dir, "missing")}
+		_, err := d.Decorate(context.Background(), fs, f)
+		assert.Error(t, err)
+		assert.Empty(t, f.MimeType)
+	})
+}
@@ -0,0 +1,51 @@
+package file
+
+import (
+	"fmt"
+
+	"github.com/stashapp/stash/pkg/hash/partialhash"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// PartialHashCalculator is a FingerprintCalculator that calculates a hash of the
+// start and end of the file. It is much faster than a full content hash for large
+// files, and may be registered alongside a full content hash calculator in
+// Scanner.FingerprintCalculators.
+type PartialHashCalculator struct {
+	// SampleSize is the number of bytes hashed from each of the start and end of the file.
+	// Defaults to partialhash.DefaultSampleSize if not set.
+	// Changing the sample size changes the fingerprints of existing files.
+	SampleSize int64
+}
+
+func (c *PartialHashCalculator) CalculateFingerprints(f *models.BaseFile, o Opener, useExisting bool) ([]models.Fingerprint, error) {
+	if useExisting {
+		if fp := f.Fingerprints.For(models.FingerprintTypePartialSHA256); fp != nil {
+			return []models.Fingerprint{*fp}, nil
+		}
+	}
+
+	sampleSize := c.SampleSize
+	if sampleSize <= 0 {
+		sampleSize = partialhash.DefaultSampleSize
+	}
+
+	r, err := o.Open()
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+
+	defer r.Close()
+
+	hash, err := partialhash.FromReader(r, f.Size, sampleSize)
+	if err != nil {
+		return nil, fmt.Errorf("calculating %s: %w", models.FingerprintTypePartialSHA256, err)
+	}
+
+	return []models.Fingerprint{
+		{
+			Type:        models.FingerprintTypePartialSHA256,
+			Fingerprint: hash,
+		},
+	}, nil
+}
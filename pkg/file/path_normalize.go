@@ -0,0 +1,67 @@
+package file
+
+import (
+	"context"
+
+	"github.com/stashapp/stash/pkg/models"
+	"golang.org/x/text/unicode/norm"
+)
+
+// unicodePathVariants returns the Unicode NFC and NFD forms of path that differ from it.
+// Returns nil if NormalizeUnicode is not set.
+func (s *Scanner) unicodePathVariants(path string) []string {
+	if !s.NormalizeUnicode {
+		return nil
+	}
+
+	var ret []string
+	for _, v := range []string{norm.NFC.String(path), norm.NFD.String(path)} {
+		if v != path && (len(ret) == 0 || ret[0] != v) {
+			ret = append(ret, v)
+		}
+	}
+
+	return ret
+}
+
+// unicodePathsEqual returns true if the paths are equal after Unicode NFC normalization.
+// Always returns false if NormalizeUnicode is not set.
+func (s *Scanner) unicodePathsEqual(a, b string) bool {
+	return s.NormalizeUnicode && norm.NFC.String(a) == norm.NFC.String(b)
+}
+
+// findFileByPath finds a file by its path. If the file is not found and NormalizeUnicode
+// is set, then the alternate Unicode normalization forms of the path are tried.
+func (s *Scanner) findFileByPath(ctx context.Context, path string, caseSensitive bool) (models.File, error) {
+	ret, err := s.Repository.File.FindByPath(ctx, path, caseSensitive)
+	if err != nil || ret != nil {
+		return ret, err
+	}
+
+	for _, v := range s.unicodePathVariants(path) {
+		ret, err = s.Repository.File.FindByPath(ctx, v, caseSensitive)
+		if err != nil || ret != nil {
+			return ret, err
+		}
+	}
+
+	return nil, nil
+}
+
+// findFolderByPath finds a folder by its path. If the folder is not found and NormalizeUnicode
+// is set, then the alternate Unicode normalization forms of the path are tried.
+func (s *Scanner) findFolderByPath(ctx context.Context, path string, caseSensitive bool) (*models.Folder, error) {
+	ret, err := s.Repository.Folder.FindByPath(ctx, path, caseSensitive)
+	if err != nil || ret != nil {
+		return ret, err
+	}
+
+	for _, v := range s.unicodePathVariants(path) {
+		ret, err = s.Repository.Folder.FindByPath(ctx, v, caseSensitive)
+		if err != nil || ret != nil {
+			return ret, err
+		}
+	}
+
+	return nil, nil
+}
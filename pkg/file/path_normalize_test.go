@@ -0,0 +1,102 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	// café.mp4 in Unicode NFC and NFD forms
+	nfcName = "caf\u00e9.mp4"
+	nfdName = "cafe\u0301.mp4"
+)
+
+func TestScanner_findFileByPathUnicode(t *testing.T) {
+	existing := &models.BaseFile{ID: models.FileID(10), Path: nfdName}
+
+	tests := []struct {
+		name             string
+		normalizeUnicode bool
+		want             models.File
+	}{
+		{"normalized", true, existing},
+		{"not normalized", false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := mocks.NewDatabase()
+			db.File.On("FindByPath", mock.Anything, nfdName, true).Return(existing, nil)
+			db.File.On("FindByPath", mock.Anything, mock.Anything, true).Return(nil, nil)
+
+			s := &Scanner{
+				Repository:       newTestRepository(db),
+				NormalizeUnicode: tt.normalizeUnicode,
+			}
+
+			got, err := s.findFileByPath(context.Background(), nfcName, true)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestScanner_ScanFileUnicodeRename(t *testing.T) {
+	dir := t.TempDir()
+	nfcPath := filepath.Join(dir, nfcName)
+	nfdPath := filepath.Join(dir, nfdName)
+
+	// both forms exist, as they would if the filesystem normalizes names
+	for _, p := range []string{nfcPath, nfdPath} {
+		if err := os.WriteFile(p, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	existing := &models.BaseFile{
+		ID:           models.FileID(10),
+		Path:         nfdPath,
+		Basename:     nfdName,
+		Fingerprints: models.Fingerprints{defaultHash},
+	}
+
+	tests := []struct {
+		name             string
+		normalizeUnicode bool
+		wantRenamed      bool
+	}{
+		{"normalized", true, true},
+		{"not normalized", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := mocks.NewDatabase()
+			db.File.On("FindByFingerprint", mock.Anything, defaultHash).Return([]models.File{existing}, nil)
+			mockNewFiles(db)
+			db.File.On("Update", mock.Anything, mock.Anything).Return(nil)
+			db.File.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+			s := &Scanner{
+				FS:                    &OsFS{},
+				Repository:            newTestRepository(db),
+				FingerprintCalculator: fallbackCalc,
+				NormalizeUnicode:      tt.normalizeUnicode,
+			}
+
+			r, err := s.ScanFile(context.Background(), makeScannedFile(nfcPath))
+			assert.NoError(t, err)
+			if assert.NotNil(t, r) {
+				assert.Equal(t, tt.wantRenamed, r.Renamed)
+				assert.Equal(t, nfcPath, r.File.Base().Path)
+			}
+		})
+	}
+}
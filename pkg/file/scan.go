@@ -2,9 +2,12 @@ package file
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -44,10 +47,26 @@ type Scanner struct {
 	Repository            Repository
 	FingerprintCalculator FingerprintCalculator
 
+	// FingerprintCalculators are used to calculate fingerprints for files accepted by their filters.
+	// All calculators that accept a file contribute to its fingerprints. Calculators are listed in
+	// priority order: if more than one calculator returns a fingerprint of the same type, the
+	// fingerprint from the earlier calculator is used.
+	// FingerprintCalculator is used if no calculator accepts the file.
+	FingerprintCalculators []FilteredFingerprintCalculator
+
 	// ZipFileExtensions is a list of file extensions that are considered zip files.
 	// Extension does not include the . character.
 	ZipFileExtensions []string
 
+	// PathFingerprintExtensions is a list of file extensions, such as subtitle or
+	// nfo sidecar files, for which fingerprints are not calculated from the file
+	// contents. These files are given a single path_size fingerprint instead, which
+	// is cheap to calculate. Since the fingerprint changes when the file is moved,
+	// moved files with these extensions are treated as new files, and the file at
+	// the previous path is left missing.
+	// Extension does not include the . character.
+	PathFingerprintExtensions []string
+
 	// ScanFilters are used to determine if a file should be scanned.
 	ScanFilters []PathFilter
 
@@ -60,9 +79,145 @@ type Scanner struct {
 	// handlers are called after a file has been scanned.
 	FileHandlers []Handler
 
+	// SkipHandler, if set, is notified when an entry is skipped during scanning.
+	SkipHandler SkipHandler
+
+	// FingerprintDenylist contains fingerprints of unwanted files, such as trailers
+	// or samples. New files with any of these fingerprints are not created, and are
+	// reported to SkipHandler.
+	FingerprintDenylist models.Fingerprints
+
+	// SkipEmptyFiles indicates whether zero-byte files should be skipped, so that
+	// files that have been created but not yet written are scanned by a later scan.
+	// Does not apply to files within zip files.
+	SkipEmptyFiles bool
+
+	// SettleTime is the minimum time since a file was last modified for it to be
+	// scanned. Files modified more recently may still be being written, and are
+	// skipped so that they are scanned by a later scan. Zero disables the check.
+	// Does not apply to files within zip files.
+	SettleTime time.Duration
+
+	// VerifyReadable indicates whether new files should be probed by reading from
+	// them before they are created. Files that exist but cannot be read, such as
+	// files without read permission or offline cloud storage placeholders, are
+	// skipped and reported to SkipHandler rather than being created.
+	VerifyReadable bool
+
+	// NormalizeUnicode indicates whether paths that differ only in their Unicode
+	// normalization form (NFC or NFD) should be treated as the same path when
+	// looking up existing files and folders, and when detecting moved files.
+	NormalizeUnicode bool
+
+	// CreateMissingFolders indicates whether missing ancestor folders of a new file
+	// should be created when scanning the file, rather than failing the scan.
+	// Ancestor folders are created up to the first folder not accepted by ScanFilters.
+	// Does not apply to files within zip files.
+	CreateMissingFolders bool
+
+	// StrictRenameDetection indicates whether a new file should only be treated as a
+	// moved file if the strongest fingerprint type calculated for the new file matches.
+	// Otherwise, a match on any fingerprint type is sufficient, which may misclassify
+	// a new file as a move if it shares a weaker fingerprint with a missing file.
+	StrictRenameDetection bool
+
+	// RenameWithoutRehash indicates whether an existing file whose basename has changed,
+	// but whose mod time and size have not, should be treated as renamed in place rather
+	// than updated. Renamed files have their basename updated and handlers fired, but
+	// their fingerprints are not recalculated and decorators are not fired. This speeds
+	// up scanning after mass renames, such as changes to the case of filenames.
+	// Does not apply if Rescan is true.
+	RenameWithoutRehash bool
+
+	// DetectFolderMovesByContent indicates whether a new folder should be matched to a
+	// missing folder by the fingerprints of its files, if no missing folder is found by
+	// the basename, size and modification time of its files. This detects folders that
+	// were moved between file systems that do not preserve modification times. The
+	// fingerprints of the files in each new folder must be calculated, which may be slow.
+	DetectFolderMovesByContent bool
+
+	// SkipJunctions indicates whether directory junctions and volume mount points should
+	// be skipped rather than walked. Junctions can cause the same files to be scanned more
+	// than once, or the walk to loop, if they point within the scanned tree. Symbolic links
+	// are not affected. Junctions are only detected if FS implements models.JunctionFS,
+	// and OsFS only detects them on Windows. Scan paths that are junctions are also skipped.
+	SkipJunctions bool
+
 	// Rescan indicates whether files should be rescanned even if they haven't changed.
 	Rescan bool
 
+	// SkipDecorators indicates whether decorators and handlers should be skipped for
+	// new and updated files, so that files are recorded with their fingerprints only.
+	// New files are created without decoration, and so are reported as missing metadata
+	// on a subsequent scan. Updated files retain their previous mod time, so that they
+	// are treated as updated on a subsequent scan. Unchanged files that are missing
+	// metadata are left undecorated and are not handled.
+	SkipDecorators bool
+
+	// MinMetadataVersion is the minimum version of the metadata set by decorators.
+	// Unchanged files with a lower metadata version, as reported by decorators that
+	// implement MetadataVersioner, are treated as missing metadata and are decorated
+	// again. This allows metadata to be recalculated when a decorator is changed.
+	// The video and image decorators record their version in the MetadataVersion
+	// of the file. Zero disables the check.
+	MinMetadataVersion int
+
+	// VerifyOshash indicates whether the oshash of unchanged files should be recalculated
+	// and compared against the stored oshash. Mismatches indicate that the file was
+	// corrupted or that the stored oshash was wrong, and are reported to OshashMismatchHandler.
+	VerifyOshash bool
+
+	// RepairOshash indicates whether the stored fingerprints of files with a mismatched
+	// oshash should be replaced with the recalculated fingerprints.
+	// Only applies if VerifyOshash is true.
+	RepairOshash bool
+
+	// OshashMismatchHandler, if set, is notified when VerifyOshash finds a mismatched oshash.
+	OshashMismatchHandler OshashMismatchHandler
+
+	// VerifyContentFilters select unchanged files whose fingerprints are recalculated
+	// and compared against the stored values. If any differ, then the file is rescanned
+	// as if it was updated. This detects files whose contents were replaced without
+	// changing the modification time, such as files restored from a backup.
+	VerifyContentFilters []Filter
+
+	// FingerprintCollisionHandler, if set, is notified when a new file shares a fingerprint
+	// with existing files that are all still present on disk. Such files are intentional
+	// duplicates or fingerprint collisions, and the new file is created as a separate file.
+	// It is also notified when a new file shares a perceptual fingerprint, such as an image
+	// phash, with existing files, whether or not they are present.
+	FingerprintCollisionHandler FingerprintCollisionHandler
+
+	// FingerprintTimingHandler, if set, is notified of the time taken to calculate
+	// the fingerprints of each file. It is not notified when fingerprints are
+	// taken from the FingerprintCache or reused from the existing file.
+	FingerprintTimingHandler FingerprintTimingHandler
+
+	// FingerprintCache, if set, is used to skip calculating fingerprints for files
+	// that have not changed since their fingerprints were last calculated. It is
+	// not consulted if Rescan is true, but is still populated.
+	//
+	// The cache is only as durable as its implementation: MemoryFingerprintCache
+	// does not survive a restart, so it only avoids re-hashing within a process.
+	FingerprintCache FingerprintCache
+
+	// ErroredFileTracker, if set, records files that failed to scan. Such files are
+	// skipped by subsequent scans until ErrorRetryInterval has passed or the file
+	// is modified, so that time is not wasted repeatedly scanning corrupt or locked
+	// files. Does not apply to files within zip files.
+	ErroredFileTracker ErroredFileTracker
+
+	// ErrorRetryInterval is the minimum time after a file fails to scan before it
+	// is scanned again. Zero skips errored files until they are modified.
+	// Only applies if ErroredFileTracker is set.
+	ErrorRetryInterval time.Duration
+
+	// Stats, if set, collects errors encountered by ScanFile and ScanFolder.
+	// When set, these errors are recorded against the scanned path instead of being
+	// returned, so that the caller may continue with the next entry.
+	// ScanFile and ScanFolder return a nil result when an error is recorded.
+	Stats *ScanStats
+
 	folderPathToID sync.Map
 }
 
@@ -71,6 +226,13 @@ type FingerprintCalculator interface {
 	CalculateFingerprints(f *models.BaseFile, o Opener, useExisting bool) ([]models.Fingerprint, error)
 }
 
+// FilteredFingerprintCalculator is a FingerprintCalculator that is only used
+// for files accepted by the filter.
+type FilteredFingerprintCalculator struct {
+	FingerprintCalculator
+	Filter
+}
+
 // Decorator wraps the Decorate method to add additional functionality while scanning files.
 type Decorator interface {
 	Decorate(ctx context.Context, fs models.FS, f models.File) (models.File, error)
@@ -98,6 +260,24 @@ func (d *FilteredDecorator) IsMissingMetadata(ctx context.Context, fs models.FS,
 	return false
 }
 
+// MetadataVersion returns the metadata version reported by the decorator if the
+// filter accepts the file and the decorator is a MetadataVersioner.
+func (d *FilteredDecorator) MetadataVersion(ctx context.Context, fs models.FS, f models.File) (int, bool) {
+	if v, ok := d.Decorator.(MetadataVersioner); ok && d.Accept(ctx, f) {
+		return v.MetadataVersion(ctx, fs, f)
+	}
+
+	return 0, false
+}
+
+// MetadataVersioner may be implemented by a Decorator to report the version of
+// the metadata it has set on a file.
+type MetadataVersioner interface {
+	// MetadataVersion returns the version of the metadata set on f by the decorator.
+	// Returns false if the decorator does not apply to f.
+	MetadataVersion(ctx context.Context, fs models.FS, f models.File) (int, bool)
+}
+
 // ScannedFile represents a file being scanned.
 type ScannedFile struct {
 	*models.BaseFile
@@ -105,8 +285,31 @@ type ScannedFile struct {
 	Info fs.FileInfo
 }
 
-// AcceptEntry determines if the file entry should be accepted for scanning
+// AcceptEntry determines if the file entry should be accepted for scanning.
+// SkipHandler is notified if the entry is not accepted.
 func (s *Scanner) AcceptEntry(ctx context.Context, path string, info fs.FileInfo) bool {
+	if !s.acceptEntry(ctx, path, info) {
+		s.handleSkip(path, SkipReasonFiltered)
+		return false
+	}
+
+	if s.SkipJunctions && s.isJunction(info) {
+		s.handleSkip(path, SkipReasonJunction)
+		return false
+	}
+
+	return true
+}
+
+// isJunction returns true if info describes a directory junction, as detected by FS.
+func (s *Scanner) isJunction(info fs.FileInfo) bool {
+	jfs, ok := s.FS.(models.JunctionFS)
+	return ok && info != nil && jfs.IsJunction(info)
+}
+
+// acceptEntry determines if the file entry should be accepted for scanning,
+// without notifying SkipHandler.
+func (s *Scanner) acceptEntry(ctx context.Context, path string, info fs.FileInfo) bool {
 	// always accept if there's no filters
 	accept := len(s.ScanFilters) == 0
 	for _, filter := range s.ScanFilters {
@@ -130,7 +333,7 @@ func (s *Scanner) getFolderID(ctx context.Context, path string) (*models.FolderI
 	// assume case sensitive when searching for the folder
 	const caseSensitive = true
 
-	ret, err := s.Repository.Folder.FindByPath(ctx, path, caseSensitive)
+	ret, err := s.findFolderByPath(ctx, path, caseSensitive)
 	if err != nil {
 		return nil, err
 	}
@@ -143,6 +346,49 @@ func (s *Scanner) getFolderID(ctx context.Context, path string) (*models.FolderI
 	return &ret.ID, nil
 }
 
+// PreloadFolders loads the IDs of the parent folders of the provided file paths,
+// along with their ancestor folders, into the folder cache. Folders that are not
+// already cached are found with a single query, rather than a query per folder
+// when each file is scanned. Folders that do not exist are not cached.
+func (s *Scanner) PreloadFolders(ctx context.Context, paths []string) error {
+	var toLoad []string
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		dir := filepath.Dir(p)
+		for !seen[dir] {
+			seen[dir] = true
+			if _, ok := s.folderPathToID.Load(dir); !ok {
+				toLoad = append(toLoad, dir)
+			}
+
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+	}
+
+	if len(toLoad) == 0 {
+		return nil
+	}
+
+	var folders []*models.Folder
+	if err := s.Repository.WithReadTxn(ctx, func(ctx context.Context) error {
+		var err error
+		folders, err = s.Repository.Folder.FindByPaths(ctx, toLoad)
+		return err
+	}); err != nil {
+		return fmt.Errorf("preloading folders: %w", err)
+	}
+
+	for _, f := range folders {
+		s.folderPathToID.Store(f.Path, f.ID)
+	}
+
+	return nil
+}
+
 // ScanFolder scans the provided folder into the database, returning the folder entry.
 // If the folder already exists, it is updated if necessary.
 func (s *Scanner) ScanFolder(ctx context.Context, file ScannedFile) (*models.Folder, error) {
@@ -153,7 +399,7 @@ func (s *Scanner) ScanFolder(ctx context.Context, file ScannedFile) (*models.Fol
 	err = s.Repository.WithTxn(ctx, func(ctx context.Context) error {
 		// determine if folder already exists in data store (by path)
 		// assume case sensitive by default
-		f, err = s.Repository.Folder.FindByPath(ctx, path, true)
+		f, err = s.findFolderByPath(ctx, path, true)
 		if err != nil {
 			return fmt.Errorf("checking for existing folder %q: %w", path, err)
 		}
@@ -165,7 +411,7 @@ func (s *Scanner) ScanFolder(ctx context.Context, file ScannedFile) (*models.Fol
 			caseSensitive, _ := file.FS.IsPathCaseSensitive(file.Path)
 
 			if !caseSensitive {
-				f, err = s.Repository.Folder.FindByPath(ctx, path, false)
+				f, err = s.findFolderByPath(ctx, path, false)
 				if err != nil {
 					return fmt.Errorf("checking for existing folder %q: %w", path, err)
 				}
@@ -190,7 +436,50 @@ func (s *Scanner) ScanFolder(ctx context.Context, file ScannedFile) (*models.Fol
 		return nil
 	})
 
-	return f, err
+	if err != nil {
+		return nil, s.handleScanError(path, err)
+	}
+
+	return f, nil
+}
+
+// OpenZip opens the provided zip file so that its contents may be scanned.
+// Returns a nil ZipFS and nil error if the zip file cannot be walked, or if the zip
+// file could not be opened and the error was recorded in Stats.
+// Returns an error if the zip file could not be opened and Stats is not set.
+func (s *Scanner) OpenZip(f ScannedFile) (models.ZipFS, error) {
+	zipFS, err := f.FS.OpenZip(f.Path, f.Size)
+	if err != nil {
+		if errors.Is(err, ErrNotReaderAt) {
+			// can't walk the zip file
+			logger.Debugf("Skipping zip file %q as it cannot be opened for walking", f.Path)
+			s.handleSkip(f.Path, SkipReasonZipNotWalkable)
+			return nil, nil
+		}
+
+		return nil, s.handleScanError(f.Path, fmt.Errorf("opening zip file: %w", err))
+	}
+
+	return zipFS, nil
+}
+
+func (s *Scanner) handleSkip(path string, reason SkipReason) {
+	if s.SkipHandler != nil {
+		s.SkipHandler.HandleSkip(path, reason)
+	}
+}
+
+// handleScanError records the error against the provided path if Stats is set,
+// and returns nil. Otherwise it returns the error unchanged.
+// Context cancellation errors are always returned.
+func (s *Scanner) handleScanError(path string, err error) error {
+	if s.Stats == nil || errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	logger.Errorf("error scanning %q: %v", path, err)
+	s.Stats.AddError(path, err)
+	return nil
 }
 
 func (s *Scanner) onNewFolder(ctx context.Context, file ScannedFile) (*models.Folder, error) {
@@ -240,6 +529,55 @@ func (s *Scanner) onNewFolder(ctx context.Context, file ScannedFile) (*models.Fo
 	return toCreate, nil
 }
 
+// createMissingFolder creates the folder at the provided path, along with any
+// missing ancestor folders accepted by the scan filters. Returns the ID of the
+// folder, or nil if the folder is not accepted by the scan filters.
+func (s *Scanner) createMissingFolder(ctx context.Context, fs models.FS, path string) (*models.FolderID, error) {
+	id, err := s.getFolderID(ctx, path)
+	if err != nil || id != nil {
+		return id, err
+	}
+
+	info, err := fs.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.acceptEntry(ctx, path, info) {
+		return nil, nil
+	}
+
+	// ensure the parent exists first so that the new folder is linked to it
+	if dir := filepath.Dir(path); dir != path && dir != "." {
+		if _, err := s.createMissingFolder(ctx, fs, dir); err != nil {
+			return nil, err
+		}
+	}
+
+	file := ScannedFile{
+		BaseFile: &models.BaseFile{
+			DirEntry: models.DirEntry{
+				ModTime: ModTime(info),
+			},
+			Path: path,
+		},
+		FS:   fs,
+		Info: info,
+	}
+
+	var f *models.Folder
+	if err := s.Repository.WithTxn(ctx, func(ctx context.Context) error {
+		var err error
+		f, err = s.onNewFolder(ctx, file)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	s.folderPathToID.Store(f.Path, f.ID)
+	return &f.ID, nil
+}
+
 func (s *Scanner) handleFolderRename(ctx context.Context, file ScannedFile) (*models.Folder, error) {
 	// ignore folders in zip files
 	if file.ZipFileID != nil {
@@ -330,14 +668,29 @@ type ScanFileResult struct {
 }
 
 // ScanFile scans the provided file into the database, returning the scan result.
+// Returns a nil result if the file is skipped because it may still be being written.
 func (s *Scanner) ScanFile(ctx context.Context, f ScannedFile) (*ScanFileResult, error) {
+	if reason := s.unsettledReason(f); reason != "" {
+		logger.Infof("Skipping %s: %s", f.Path, reason)
+		s.handleSkip(f.Path, reason)
+		return nil, nil
+	}
+
+	if s.isRecentlyErrored(f) {
+		logger.Infof("Skipping %s: %s", f.Path, SkipReasonErrored)
+		s.handleSkip(f.Path, SkipReasonErrored)
+		return nil, nil
+	}
+
 	var r *ScanFileResult
 
+	ctx = withFiredHandlers(ctx)
+
 	// don't use a transaction to check if new or existing
 	if err := s.Repository.WithDB(ctx, func(ctx context.Context) error {
 		// determine if file already exists in data store
 		// assume case sensitive when searching for the file to begin with
-		ff, err := s.Repository.File.FindByPath(ctx, f.Path, true)
+		ff, err := s.findFileByPath(ctx, f.Path, true)
 		if err != nil {
 			return fmt.Errorf("checking for existing file %q: %w", f.Path, err)
 		}
@@ -349,7 +702,7 @@ func (s *Scanner) ScanFile(ctx context.Context, f ScannedFile) (*ScanFileResult,
 			caseSensitive, _ := f.FS.IsPathCaseSensitive(f.Path)
 
 			if !caseSensitive {
-				ff, err = s.Repository.File.FindByPath(ctx, f.Path, false)
+				ff, err = s.findFileByPath(ctx, f.Path, false)
 				if err != nil {
 					return fmt.Errorf("checking for existing file %q: %w", f.Path, err)
 				}
@@ -365,12 +718,90 @@ func (s *Scanner) ScanFile(ctx context.Context, f ScannedFile) (*ScanFileResult,
 		r, err = s.onExistingFile(ctx, f, ff)
 		return err
 	}); err != nil {
-		return nil, err
+		s.recordErroredFile(f, err)
+		return nil, s.handleScanError(f.Path, err)
+	}
+
+	if s.ErroredFileTracker != nil && f.ZipFileID == nil {
+		s.ErroredFileTracker.Delete(f.Path)
 	}
 
 	return r, nil
 }
 
+// isRecentlyErrored returns true if the file failed to scan within ErrorRetryInterval,
+// and has not been modified since.
+func (s *Scanner) isRecentlyErrored(f ScannedFile) bool {
+	if s.ErroredFileTracker == nil || f.ZipFileID != nil {
+		return false
+	}
+
+	erroredAt, ok := s.ErroredFileTracker.Get(f.Path, f.ModTime)
+	if !ok {
+		return false
+	}
+
+	return s.ErrorRetryInterval == 0 || time.Since(erroredAt) < s.ErrorRetryInterval
+}
+
+// recordErroredFile records the file in ErroredFileTracker, if set.
+// Context cancellation errors are not recorded.
+func (s *Scanner) recordErroredFile(f ScannedFile, err error) {
+	if s.ErroredFileTracker == nil || f.ZipFileID != nil || errors.Is(err, context.Canceled) {
+		return
+	}
+
+	s.ErroredFileTracker.Set(f.Path, f.ModTime, time.Now())
+}
+
+// probeReadable returns an error if the first byte of the file cannot be read.
+func probeReadable(fs models.FS, path string) error {
+	r, err := fs.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening file: %w", err)
+	}
+	defer r.Close()
+
+	var buf [1]byte
+	if _, err := r.Read(buf[:]); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("reading file: %w", err)
+	}
+
+	return nil
+}
+
+// unsettledReason returns the reason that the file may still be being written,
+// based on SkipEmptyFiles and SettleTime. Returns an empty string if the file
+// should be scanned.
+func (s *Scanner) unsettledReason(f ScannedFile) SkipReason {
+	if f.ZipFileID != nil {
+		return ""
+	}
+
+	if s.SkipEmptyFiles && f.Size == 0 {
+		return SkipReasonEmpty
+	}
+
+	if s.SettleTime > 0 && time.Since(f.ModTime) < s.SettleTime {
+		return SkipReasonUnsettled
+	}
+
+	return ""
+}
+
+// isPathFingerprintFile determines if the provided path has one of the
+// PathFingerprintExtensions.
+func (s *Scanner) isPathFingerprintFile(path string) bool {
+	fExt := filepath.Ext(path)
+	for _, ext := range s.PathFingerprintExtensions {
+		if strings.EqualFold(fExt, "."+ext) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // IsZipFile determines if the provided path is a zip file based on its extension.
 func (s *Scanner) IsZipFile(path string) bool {
 	fExt := filepath.Ext(path)
@@ -389,6 +820,14 @@ func (s *Scanner) onNewFile(ctx context.Context, f ScannedFile) (*ScanFileResult
 	baseFile := f.BaseFile
 	path := baseFile.Path
 
+	if s.VerifyReadable {
+		if err := probeReadable(f.FS, path); err != nil {
+			logger.Warnf("Skipping %s: %v", path, err)
+			s.handleSkip(path, SkipReasonUnreadable)
+			return nil, nil
+		}
+	}
+
 	baseFile.CreatedAt = now
 	baseFile.UpdatedAt = now
 
@@ -398,6 +837,13 @@ func (s *Scanner) onNewFile(ctx context.Context, f ScannedFile) (*ScanFileResult
 		return nil, fmt.Errorf("getting parent folder for %q: %w", path, err)
 	}
 
+	if parentFolderID == nil && s.CreateMissingFolders && f.ZipFileID == nil {
+		parentFolderID, err = s.createMissingFolder(ctx, f.FS, filepath.Dir(path))
+		if err != nil {
+			return nil, fmt.Errorf("creating parent folder for %q: %w", path, err)
+		}
+	}
+
 	if parentFolderID == nil {
 		return nil, fmt.Errorf("parent folder for %q doesn't exist", path)
 	}
@@ -405,23 +851,36 @@ func (s *Scanner) onNewFile(ctx context.Context, f ScannedFile) (*ScanFileResult
 	baseFile.ParentFolderID = *parentFolderID
 
 	const useExisting = false
-	fp, err := s.calculateFingerprints(f.FS, baseFile, path, useExisting)
+	fp, err := s.calculateFingerprints(ctx, f.FS, baseFile, path, useExisting)
 	if err != nil {
 		return nil, err
 	}
 
+	if denied := s.deniedFingerprint(fp); denied != nil {
+		logger.Infof("Skipping %s: %s fingerprint %v is denied", path, denied.Type, denied.Value())
+		s.handleSkip(path, SkipReasonDeniedFingerprint)
+		return nil, nil
+	}
+
 	baseFile.SetFingerprints(fp)
 
-	file, err := s.fireDecorators(ctx, f.FS, baseFile)
-	if err != nil {
-		return nil, err
+	var file models.File = baseFile
+	if !s.SkipDecorators {
+		file, err = s.fireDecorators(ctx, f.FS, baseFile)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// determine if the file is renamed from an existing file in the store
 	// do this after decoration so that missing fields can be populated
-	renamed, err := s.handleRename(ctx, file, fp)
-	if err != nil {
-		return nil, err
+	// path fingerprints cannot match a file at a different path, so don't look for them
+	var renamed models.File
+	if !s.isPathFingerprintFile(path) {
+		renamed, err = s.handleRename(ctx, file, fp)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if renamed != nil {
@@ -440,7 +899,12 @@ func (s *Scanner) onNewFile(ctx context.Context, f ScannedFile) (*ScanFileResult
 			return fmt.Errorf("creating file %q: %w", path, err)
 		}
 
-		if err := s.fireHandlers(ctx, file, nil); err != nil {
+		// handlers are fired once the file is decorated
+		if s.SkipDecorators {
+			return nil
+		}
+
+		if err := s.fireHandlers(ctx, file, nil, handlerOperationCreate); err != nil {
 			return err
 		}
 
@@ -455,6 +919,20 @@ func (s *Scanner) onNewFile(ctx context.Context, f ScannedFile) (*ScanFileResult
 	}, nil
 }
 
+// deniedFingerprint returns the first of the provided fingerprints that is in
+// FingerprintDenylist, or nil if none are.
+func (s *Scanner) deniedFingerprint(fp models.Fingerprints) *models.Fingerprint {
+	for _, f := range fp {
+		for _, d := range s.FingerprintDenylist {
+			if d.Type == f.Type && d.Fingerprint == f.Fingerprint {
+				return &f
+			}
+		}
+	}
+
+	return nil
+}
+
 func (s *Scanner) fireDecorators(ctx context.Context, fs models.FS, f models.File) (models.File, error) {
 	for _, h := range s.FileDecorators {
 		var err error
@@ -467,29 +945,157 @@ func (s *Scanner) fireDecorators(ctx context.Context, fs models.FS, f models.Fil
 	return f, nil
 }
 
-func (s *Scanner) fireHandlers(ctx context.Context, f models.File, oldFile models.File) error {
-	for _, h := range s.FileHandlers {
+// handlerOperation is the change to a file that handlers are fired for.
+type handlerOperation string
+
+const (
+	handlerOperationCreate handlerOperation = "create"
+	handlerOperationRename handlerOperation = "rename"
+	handlerOperationUpdate handlerOperation = "update"
+	// handlerOperationRefresh is used for unchanged files that are missing metadata.
+	handlerOperationRefresh handlerOperation = "refresh"
+)
+
+type firedHandler struct {
+	handler int
+	fileID  models.FileID
+	op      handlerOperation
+}
+
+// firedHandlers records the handlers fired during a single ScanFile call, so
+// that a handler is not fired twice for the same file and operation.
+type firedHandlers map[firedHandler]struct{}
+
+type firedHandlersKey struct{}
+
+func withFiredHandlers(ctx context.Context) context.Context {
+	return context.WithValue(ctx, firedHandlersKey{}, firedHandlers{})
+}
+
+// firedHandlersFromContext returns the fired handlers set by withFiredHandlers,
+// or nil if not set.
+func firedHandlersFromContext(ctx context.Context) firedHandlers {
+	fired, _ := ctx.Value(firedHandlersKey{}).(firedHandlers)
+	return fired
+}
+
+// fireHandlers fires the file handlers for the provided file. Handlers that
+// have already been fired for the same file and operation during the current
+// ScanFile call are skipped. A handler is only considered fired if its
+// transaction is not rolled back, so that it is fired again if the
+// transaction is retried.
+func (s *Scanner) fireHandlers(ctx context.Context, f models.File, oldFile models.File, op handlerOperation) error {
+	fired := firedHandlersFromContext(ctx)
+
+	for i, h := range s.FileHandlers {
+		key := firedHandler{
+			handler: i,
+			fileID:  f.Base().ID,
+			op:      op,
+		}
+
+		if _, found := fired[key]; found {
+			logger.Debugf("Skipping handler %d for %s: already fired for %s", i, f.Base().Path, op)
+			continue
+		}
+
 		if err := h.Handle(ctx, f, oldFile); err != nil {
 			return err
 		}
+
+		if fired != nil {
+			fired[key] = struct{}{}
+			txn.AddPostRollbackHook(ctx, func(ctx context.Context) {
+				delete(fired, key)
+			})
+		}
 	}
 
 	return nil
 }
 
-func (s *Scanner) calculateFingerprints(fs models.FS, f *models.BaseFile, path string, useExisting bool) (models.Fingerprints, error) {
+// fingerprintCalculators returns the calculators to use for the provided file, in priority order.
+func (s *Scanner) fingerprintCalculators(ctx context.Context, f *models.BaseFile) []FingerprintCalculator {
+	var ret []FingerprintCalculator
+	for _, c := range s.FingerprintCalculators {
+		if c.Accept(ctx, f) {
+			ret = append(ret, c.FingerprintCalculator)
+		}
+	}
+
+	if len(ret) == 0 && s.FingerprintCalculator != nil {
+		ret = append(ret, s.FingerprintCalculator)
+	}
+
+	return ret
+}
+
+func (s *Scanner) calculateFingerprints(ctx context.Context, fs models.FS, f *models.BaseFile, path string, useExisting bool) (models.Fingerprints, error) {
+	if s.isPathFingerprintFile(path) {
+		return models.Fingerprints{
+			{
+				Type:        models.FingerprintTypePathSize,
+				Fingerprint: fmt.Sprintf("%s:%d", path, f.Size),
+			},
+		}, nil
+	}
+
+	// use cached fingerprints if the file has not changed. Rescan forces the
+	// fingerprints to be recalculated, so the cache is not consulted.
+	if !useExisting && !s.Rescan && s.FingerprintCache != nil {
+		if fp, ok := s.FingerprintCache.Get(path, f.Size, f.ModTime); ok {
+			logger.Debugf("Using cached fingerprints for %s", path)
+			return fp, nil
+		}
+	}
+
+	fp, err := s.computeFingerprints(ctx, fs, f, path, useExisting)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.FingerprintCache != nil {
+		s.FingerprintCache.Set(path, f.Size, f.ModTime, fp)
+	}
+
+	return fp, nil
+}
+
+// computeFingerprints calculates the fingerprints for the file without consulting the FingerprintCache.
+func (s *Scanner) computeFingerprints(ctx context.Context, fs models.FS, f *models.BaseFile, path string, useExisting bool) (models.Fingerprints, error) {
 	// only log if we're (re)calculating fingerprints
 	if !useExisting {
 		logger.Infof("Calculating fingerprints for %s ...", path)
 	}
 
-	// calculate primary fingerprint for the file
-	fp, err := s.FingerprintCalculator.CalculateFingerprints(f, &fsOpener{
+	opener := &fsOpener{
 		fs:   fs,
 		name: path,
-	}, useExisting)
-	if err != nil {
-		return nil, fmt.Errorf("calculating fingerprint for file %q: %w", path, err)
+	}
+
+	timed := !useExisting && s.FingerprintTimingHandler != nil
+	var start time.Time
+	if timed {
+		start = time.Now()
+	}
+
+	var fp models.Fingerprints
+	for _, c := range s.fingerprintCalculators(ctx, f) {
+		cfp, err := c.CalculateFingerprints(f, opener, useExisting)
+		if err != nil {
+			return nil, fmt.Errorf("calculating fingerprint for file %q: %w", path, err)
+		}
+
+		// fingerprints from earlier calculators take precedence
+		for _, v := range cfp {
+			if fp.For(v.Type) == nil {
+				fp = append(fp, v)
+			}
+		}
+	}
+
+	if timed {
+		s.FingerprintTimingHandler.HandleFingerprintTiming(path, f.Size, time.Since(start))
 	}
 
 	return fp, nil
@@ -526,11 +1132,17 @@ func (s *Scanner) getFileFS(f *models.BaseFile) (models.FS, error) {
 
 	zipPath := f.ZipFile.Base().Path
 	zipSize := f.ZipFile.Base().Size
-	return fs.OpenZip(zipPath, zipSize)
+	zipFS, err := fs.OpenZip(zipPath, zipSize)
+	if err != nil {
+		return nil, fmt.Errorf("opening zip file %q: %w", zipPath, err)
+	}
+
+	return zipFS, nil
 }
 
 func (s *Scanner) handleRename(ctx context.Context, f models.File, fp []models.Fingerprint) (models.File, error) {
 	var others []models.File
+	var similar []models.File
 
 	for _, tfp := range fp {
 		thisOthers, err := s.Repository.File.FindByFingerprint(ctx, tfp)
@@ -538,10 +1150,21 @@ func (s *Scanner) handleRename(ctx context.Context, f models.File, fp []models.F
 			return nil, fmt.Errorf("getting files by fingerprint %v: %w", tfp, err)
 		}
 
+		// perceptual fingerprints match similar files, which are not moves
+		if isPerceptualFingerprint(tfp.Type) {
+			similar = appendFileUnique(similar, thisOthers)
+			continue
+		}
+
 		others = appendFileUnique(others, thisOthers)
 	}
 
+	if s.StrictRenameDetection {
+		others = filterStrongestFingerprintMatches(fp, others)
+	}
+
 	var missing []models.File
+	var present []models.File
 
 	fZipID := f.Base().ZipFileID
 	for _, other := range others {
@@ -562,6 +1185,10 @@ func (s *Scanner) handleRename(ctx context.Context, f models.File, fp []models.F
 		switch {
 		case err != nil:
 			missing = append(missing, other)
+		case s.unicodePathsEqual(f.Base().Path, other.Base().Path):
+			// file exists but differs only in its unicode normalization form
+			// (ie NFD on macOS vs NFC on Linux) - treat as a move
+			missing = append(missing, other)
 		case strings.EqualFold(f.Base().Path, other.Base().Path):
 			// #1426 - if file exists but is a case-insensitive match for the
 			// original filename, and the filesystem is case-insensitive
@@ -571,16 +1198,23 @@ func (s *Scanner) handleRename(ctx context.Context, f models.File, fp []models.F
 				// treat as a move
 				missing = append(missing, other)
 			}
-		case !s.AcceptEntry(ctx, other.Base().Path, info):
+		case !s.acceptEntry(ctx, other.Base().Path, info):
 			// #4393 - if the file is no longer in the configured library paths, treat it as a move
 			logger.Debugf("File %q no longer in library paths. Treating as a move.", other.Base().Path)
 			missing = append(missing, other)
+		default:
+			present = append(present, other)
 		}
 	}
 
 	n := len(missing)
 	if n == 0 {
 		// no missing files, not a rename
+		// similar files are reported whether or not they are present
+		present = appendFileUnique(present, similar)
+		if len(present) > 0 {
+			s.reportFingerprintCollision(f, fp, present)
+		}
 		return nil, nil
 	}
 
@@ -615,7 +1249,7 @@ func (s *Scanner) handleRename(ctx context.Context, f models.File, fp []models.F
 			}
 		}
 
-		if err := s.fireHandlers(ctx, updated, other); err != nil {
+		if err := s.fireHandlers(ctx, updated, other, handlerOperationRename); err != nil {
 			return err
 		}
 
@@ -627,6 +1261,88 @@ func (s *Scanner) handleRename(ctx context.Context, f models.File, fp []models.F
 	return updated, nil
 }
 
+// reportFingerprintCollision notifies FingerprintCollisionHandler that f shares a
+// fingerprint with the existing files, which are still present on disk, or share
+// a perceptual fingerprint.
+// The strongest shared fingerprint is reported, along with the existing files that share it.
+func (s *Scanner) reportFingerprintCollision(f models.File, fp models.Fingerprints, existing []models.File) {
+	for _, t := range slices.Concat(renameFingerprintTypes, perceptualFingerprintTypes) {
+		shared := fp.For(t)
+		if shared == nil {
+			continue
+		}
+
+		var colliding []models.File
+		var paths []string
+		for _, other := range existing {
+			otherFP := other.Base().Fingerprints.For(t)
+			if otherFP != nil && *otherFP == *shared {
+				colliding = append(colliding, other)
+				paths = append(paths, other.Base().Path)
+			}
+		}
+
+		if len(colliding) == 0 {
+			continue
+		}
+
+		logger.Infof("%s has the same %s fingerprint %s as existing files: %s", f.Base().Path, shared.Type, shared.Value(), strings.Join(paths, ", "))
+
+		if s.FingerprintCollisionHandler != nil {
+			s.FingerprintCollisionHandler.HandleFingerprintCollision(f, *shared, colliding)
+		}
+		return
+	}
+}
+
+// renameFingerprintTypes are the fingerprint types used by strict rename detection,
+// in order of strength.
+var renameFingerprintTypes = []string{
+	models.FingerprintTypeMD5,
+	models.FingerprintTypePartialSHA256,
+	models.FingerprintTypeOshash,
+}
+
+// perceptualFingerprintTypes are fingerprint types that are shared by visually
+// similar files, rather than only by files with the same contents. They are not
+// used to detect moved files, but are reported as fingerprint collisions, after
+// renameFingerprintTypes.
+var perceptualFingerprintTypes = []string{
+	models.FingerprintTypePhash,
+}
+
+func isPerceptualFingerprint(t string) bool {
+	return slices.Contains(perceptualFingerprintTypes, t)
+}
+
+// filterStrongestFingerprintMatches returns the files that match the strongest
+// fingerprint type present in fp. If fp has none of renameFingerprintTypes, then
+// no files are returned.
+func filterStrongestFingerprintMatches(fp models.Fingerprints, files []models.File) []models.File {
+	var strongest *models.Fingerprint
+	for _, t := range renameFingerprintTypes {
+		if strongest = fp.For(t); strongest != nil {
+			break
+		}
+	}
+
+	if strongest == nil {
+		return nil
+	}
+
+	var ret []models.File
+	for _, f := range files {
+		other := f.Base().Fingerprints.For(strongest.Type)
+		if other != nil && *other == *strongest {
+			ret = append(ret, f)
+		} else {
+			logger.Debugf("%s fingerprint of %q does not match. Not treating as a move.", strongest.Type, f.Base().Path)
+		}
+	}
+
+	return ret
+}
+
 func (s *Scanner) isHandlerRequired(ctx context.Context, f models.File) bool {
 	accept := len(s.HandlerRequiredFilters) == 0
 	for _, filter := range s.HandlerRequiredFilters {
@@ -648,16 +1364,43 @@ func (s *Scanner) isHandlerRequired(ctx context.Context, f models.File) bool {
 // - file size
 // - image format, width or height
 // - video codec, audio codec, format, width, height, framerate or bitrate
+// Files with a metadata version lower than MinMetadataVersion are also treated
+// as missing metadata.
 func (s *Scanner) isMissingMetadata(ctx context.Context, f ScannedFile, existing models.File) bool {
 	for _, h := range s.FileDecorators {
 		if h.IsMissingMetadata(ctx, f.FS, existing) {
 			return true
 		}
+
+		if s.isMetadataOutdated(ctx, h, f, existing) {
+			return true
+		}
 	}
 
 	return false
 }
 
+// isMetadataOutdated returns true if the decorator reports a metadata version for
+// the file that is lower than MinMetadataVersion.
+func (s *Scanner) isMetadataOutdated(ctx context.Context, d Decorator, f ScannedFile, existing models.File) bool {
+	if s.MinMetadataVersion == 0 {
+		return false
+	}
+
+	v, ok := d.(MetadataVersioner)
+	if !ok {
+		return false
+	}
+
+	version, applies := v.MetadataVersion(ctx, f.FS, existing)
+	if !applies || version >= s.MinMetadataVersion {
+		return false
+	}
+
+	logger.Debugf("%s has metadata version %d, less than %d", existing.Base().Path, version, s.MinMetadataVersion)
+	return true
+}
+
 func (s *Scanner) setMissingMetadata(ctx context.Context, f ScannedFile, existing models.File) (models.File, error) {
 	path := existing.Base().Path
 	logger.Infof("Updating metadata for %s", path)
@@ -686,7 +1429,7 @@ func (s *Scanner) setMissingMetadata(ctx context.Context, f ScannedFile, existin
 
 func (s *Scanner) setMissingFingerprints(ctx context.Context, f ScannedFile, existing models.File) (models.File, error) {
 	const useExisting = true
-	fp, err := s.calculateFingerprints(f.FS, existing.Base(), f.Path, useExisting)
+	fp, err := s.calculateFingerprints(ctx, f.FS, existing.Base(), f.Path, useExisting)
 	if err != nil {
 		return nil, err
 	}
@@ -718,27 +1461,48 @@ func (s *Scanner) onExistingFile(ctx context.Context, f ScannedFile, existing mo
 	updated := !fileModTime.Equal(base.ModTime) || base.Basename != f.Basename
 	forceRescan := s.Rescan
 
+	if s.RenameWithoutRehash && !forceRescan && base.Basename != f.Basename &&
+		fileModTime.Equal(base.ModTime) && base.Size == f.Size {
+		return s.onRenamedFile(ctx, f, existing)
+	}
+
+	contentsChanged := false
 	if !updated && !forceRescan {
-		return s.onUnchangedFile(ctx, f, existing)
+		var err error
+		contentsChanged, err = s.verifyContents(ctx, f, existing)
+		if err != nil {
+			return nil, err
+		}
+
+		if !contentsChanged {
+			return s.onUnchangedFile(ctx, f, existing)
+		}
 	}
 
 	oldBase := *base
 
-	if !updated && forceRescan {
+	switch {
+	case contentsChanged:
+		logger.Infof("%s contents have changed: rescanning", path)
+	case !updated && forceRescan:
 		logger.Infof("rescanning %s", path)
-	} else {
+	default:
 		logger.Infof("%s has been updated: rescanning", path)
 	}
 
 	// #6326 - update basename in case it changed
 	base.Basename = f.Basename
-	base.ModTime = fileModTime
 	base.Size = f.Size
+	// retain the old mod time if not decorating, so that the file is treated
+	// as updated on the next scan
+	if !s.SkipDecorators {
+		base.ModTime = fileModTime
+	}
 	base.UpdatedAt = time.Now()
 
 	// calculate and update fingerprints for the file
 	const useExisting = false
-	fp, err := s.calculateFingerprints(f.FS, base, path, useExisting)
+	fp, err := s.calculateFingerprints(ctx, f.FS, base, path, useExisting)
 	if err != nil {
 		return nil, err
 	}
@@ -746,9 +1510,11 @@ func (s *Scanner) onExistingFile(ctx context.Context, f ScannedFile, existing mo
 	s.removeOutdatedFingerprints(existing, fp)
 	existing.SetFingerprints(fp)
 
-	existing, err = s.fireDecorators(ctx, f.FS, existing)
-	if err != nil {
-		return nil, err
+	if !s.SkipDecorators {
+		existing, err = s.fireDecorators(ctx, f.FS, existing)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// queue file for update
@@ -757,7 +1523,11 @@ func (s *Scanner) onExistingFile(ctx context.Context, f ScannedFile, existing mo
 			return fmt.Errorf("updating file %q: %w", path, err)
 		}
 
-		if err := s.fireHandlers(ctx, existing, &oldBase); err != nil {
+		if s.SkipDecorators {
+			return nil
+		}
+
+		if err := s.fireHandlers(ctx, existing, &oldBase, handlerOperationUpdate); err != nil {
 			return err
 		}
 
@@ -771,6 +1541,38 @@ func (s *Scanner) onExistingFile(ctx context.Context, f ScannedFile, existing mo
 	}, nil
 }
 
+// onRenamedFile updates the basename of an existing file that has been renamed in place,
+// retaining its fingerprints and metadata.
+func (s *Scanner) onRenamedFile(ctx context.Context, f ScannedFile, existing models.File) (*ScanFileResult, error) {
+	base := existing.Base()
+	path := base.Path
+	oldBase := *base
+
+	logger.Infof("%s has been renamed to %s: updating", path, f.Basename)
+
+	base.Basename = f.Basename
+	base.UpdatedAt = time.Now()
+
+	if err := s.Repository.WithTxn(ctx, func(ctx context.Context) error {
+		if err := s.Repository.File.Update(ctx, existing); err != nil {
+			return fmt.Errorf("updating file %q: %w", path, err)
+		}
+
+		if s.SkipDecorators {
+			return nil
+		}
+
+		return s.fireHandlers(ctx, existing, &oldBase, handlerOperationUpdate)
+	}); err != nil {
+		return nil, err
+	}
+
+	return &ScanFileResult{
+		File:    existing,
+		Updated: true,
+	}, nil
+}
+
 func (s *Scanner) removeOutdatedFingerprints(existing models.File, fp models.Fingerprints) {
 	// HACK - if no MD5 fingerprint was returned, and the oshash is changed
 	// then remove the MD5 fingerprint
@@ -798,13 +1600,107 @@ func (s *Scanner) removeOutdatedFingerprints(existing models.File, fp models.Fin
 	b.Fingerprints = b.Fingerprints.Remove(models.FingerprintTypeMD5)
 }
 
+// verifyContents returns true if the existing file is accepted by any of the
+// VerifyContentFilters and its recalculated fingerprints differ from the stored
+// values. Fingerprint types that are not stored are not compared.
+func (s *Scanner) verifyContents(ctx context.Context, f ScannedFile, existing models.File) (bool, error) {
+	accept := false
+	for _, filter := range s.VerifyContentFilters {
+		if filter.Accept(ctx, existing) {
+			accept = true
+			break
+		}
+	}
+
+	if !accept {
+		return false, nil
+	}
+
+	// bypass the fingerprint cache, since it is keyed on the unchanged mod time
+	const useExisting = false
+	fp, err := s.computeFingerprints(ctx, f.FS, existing.Base(), f.Path, useExisting)
+	if err != nil {
+		return false, err
+	}
+
+	stored := existing.Base().Fingerprints
+	changed := false
+	for _, calculated := range fp {
+		if sf := stored.For(calculated.Type); sf != nil && sf.Fingerprint != calculated.Fingerprint {
+			logger.Debugf("%s fingerprint changed for %s: stored %v, calculated %v", calculated.Type, f.Path, sf.Value(), calculated.Value())
+			changed = true
+		}
+	}
+
+	// cache the new fingerprints so that they are not calculated again when rescanning
+	if changed && s.FingerprintCache != nil {
+		s.FingerprintCache.Set(f.Path, f.Size, f.ModTime, fp)
+	}
+
+	return changed, nil
+}
+
+// verifyOshash recalculates the fingerprints of the existing file and compares the
+// oshash against the stored value. If RepairOshash is set, then mismatched fingerprints
+// are replaced with the recalculated fingerprints.
+func (s *Scanner) verifyOshash(ctx context.Context, f ScannedFile, existing models.File) (models.File, error) {
+	stored := existing.Base().Fingerprints.For(models.FingerprintTypeOshash)
+	if stored == nil {
+		// nothing to verify against
+		return existing, nil
+	}
+
+	// bypass the fingerprint cache, since it may hold the stored value
+	const useExisting = false
+	fp, err := s.computeFingerprints(ctx, f.FS, existing.Base(), f.Path, useExisting)
+	if err != nil {
+		return nil, err
+	}
+
+	calculated := fp.For(models.FingerprintTypeOshash)
+	if calculated == nil || *calculated == *stored {
+		return existing, nil
+	}
+
+	logger.Warnf("oshash mismatch for %s: stored %s, calculated %s", f.Path, stored.Value(), calculated.Value())
+
+	if s.OshashMismatchHandler != nil {
+		s.OshashMismatchHandler.HandleOshashMismatch(existing, stored.Value(), calculated.Value())
+	}
+
+	if !s.RepairOshash {
+		return existing, nil
+	}
+
+	logger.Infof("Repairing fingerprints for %s", f.Path)
+
+	s.removeOutdatedFingerprints(existing, fp)
+	existing.SetFingerprints(fp)
+
+	if err := s.Repository.WithTxn(ctx, func(ctx context.Context) error {
+		if err := s.Repository.File.Update(ctx, existing); err != nil {
+			return fmt.Errorf("updating file %q: %w", f.Path, err)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if s.FingerprintCache != nil {
+		s.FingerprintCache.Set(f.Path, existing.Base().Size, existing.Base().ModTime, fp)
+	}
+
+	return existing, nil
+}
+
 // returns a file only if it was updated
 func (s *Scanner) onUnchangedFile(ctx context.Context, f ScannedFile, existing models.File) (*ScanFileResult, error) {
 	var err error
 
 	isMissingMetdata := s.isMissingMetadata(ctx, f, existing)
 	// set missing information
-	if isMissingMetdata {
+	if isMissingMetdata && !s.SkipDecorators {
 		existing, err = s.setMissingMetadata(ctx, f, existing)
 		if err != nil {
 			return nil, err
@@ -817,6 +1713,20 @@ func (s *Scanner) onUnchangedFile(ctx context.Context, f ScannedFile, existing m
 		return nil, err
 	}
 
+	if isMissingMetdata && s.SkipDecorators {
+		// handlers are fired once the file is decorated
+		return &ScanFileResult{
+			File: existing,
+		}, nil
+	}
+
+	if s.VerifyOshash {
+		existing, err = s.verifyOshash(ctx, f, existing)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	handlerRequired := false
 	if err := s.Repository.WithDB(ctx, func(ctx context.Context) error {
 		// check if the handler needs to be run
@@ -842,7 +1752,7 @@ func (s *Scanner) onUnchangedFile(ctx context.Context, f ScannedFile, existing m
 	}
 
 	if err := s.Repository.WithTxn(ctx, func(ctx context.Context) error {
-		if err := s.fireHandlers(ctx, existing, nil); err != nil {
+		if err := s.fireHandlers(ctx, existing, nil, handlerOperationRefresh); err != nil {
 			return err
 		}
 
@@ -0,0 +1,1736 @@
+package file
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testFolderID = models.FolderID(1)
+
+func newTestRepository(db *mocks.Database) Repository {
+	return Repository{
+		TxnManager: db,
+		File:       db.File,
+		Folder:     db.Folder,
+	}
+}
+
+// testFingerprintCalculator returns a fingerprint based on the file path,
+// or the configured error for the path.
+type testFingerprintCalculator struct {
+	errors map[string]error
+}
+
+func (c *testFingerprintCalculator) CalculateFingerprints(f *models.BaseFile, o Opener, useExisting bool) ([]models.Fingerprint, error) {
+	if err := c.errors[f.Path]; err != nil {
+		return nil, err
+	}
+
+	return []models.Fingerprint{
+		{
+			Type:        models.FingerprintTypeOshash,
+			Fingerprint: f.Path,
+		},
+	}, nil
+}
+
+func makeScannedFile(path string) ScannedFile {
+	return ScannedFile{
+		BaseFile: &models.BaseFile{
+			Path:     path,
+			Basename: path,
+		},
+	}
+}
+
+// mockNewFiles sets up the mocks such that all files are treated as new files.
+func mockNewFiles(db *mocks.Database) {
+	db.File.On("FindByPath", mock.Anything, mock.Anything, true).Return(nil, nil)
+	db.File.On("FindByFingerprint", mock.Anything, mock.Anything).Return(nil, nil)
+	db.Folder.On("FindByPath", mock.Anything, mock.Anything, true).Return(&models.Folder{ID: testFolderID}, nil)
+}
+
+func TestScanner_ScanFileErrors(t *testing.T) {
+	const (
+		first      = "/a.mp4"
+		unreadable = "/b.mp4"
+		last       = "/c.mp4"
+	)
+
+	paths := []string{first, unreadable, last}
+
+	db := mocks.NewDatabase()
+	mockNewFiles(db)
+	db.File.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	s := &Scanner{
+		Repository: newTestRepository(db),
+		FingerprintCalculator: &testFingerprintCalculator{
+			errors: map[string]error{
+				unreadable: fs.ErrPermission,
+			},
+		},
+		Stats: &ScanStats{},
+	}
+
+	ctx := context.Background()
+
+	var scanned []string
+	for _, p := range paths {
+		r, err := s.ScanFile(ctx, makeScannedFile(p))
+		assert.NoError(t, err)
+		if r != nil {
+			scanned = append(scanned, r.File.Base().Path)
+		}
+	}
+
+	assert.Equal(t, []string{first, last}, scanned)
+
+	scanErrors := s.Stats.Errors()
+	if assert.Len(t, scanErrors, 1) {
+		assert.Equal(t, unreadable, scanErrors[0].Path)
+		assert.True(t, errors.Is(scanErrors[0], fs.ErrPermission))
+	}
+
+	db.File.AssertNumberOfCalls(t, "Create", 2)
+}
+
+func TestScanner_ScanFileErrorsFailFast(t *testing.T) {
+	const unreadable = "/b.mp4"
+
+	db := mocks.NewDatabase()
+	mockNewFiles(db)
+
+	s := &Scanner{
+		Repository: newTestRepository(db),
+		FingerprintCalculator: &testFingerprintCalculator{
+			errors: map[string]error{
+				unreadable: fs.ErrPermission,
+			},
+		},
+	}
+
+	r, err := s.ScanFile(context.Background(), makeScannedFile(unreadable))
+	assert.Nil(t, r)
+	assert.ErrorIs(t, err, fs.ErrPermission)
+}
+
+// fixedFingerprintCalculator returns the same fingerprints for every file.
+type fixedFingerprintCalculator struct {
+	fingerprints []models.Fingerprint
+}
+
+func (c *fixedFingerprintCalculator) CalculateFingerprints(f *models.BaseFile, o Opener, useExisting bool) ([]models.Fingerprint, error) {
+	return c.fingerprints, nil
+}
+
+func extensionFilter(ext string) Filter {
+	return FilterFunc(func(ctx context.Context, f models.File) bool {
+		return filepath.Ext(f.Base().Path) == ext
+	})
+}
+
+var (
+	videoOshash  = models.Fingerprint{Type: models.FingerprintTypeOshash, Fingerprint: "video"}
+	imageMD5     = models.Fingerprint{Type: models.FingerprintTypeMD5, Fingerprint: "image"}
+	defaultHash  = models.Fingerprint{Type: models.FingerprintTypeOshash, Fingerprint: "default"}
+	fallbackCalc = &fixedFingerprintCalculator{fingerprints: []models.Fingerprint{defaultHash}}
+)
+
+func newMultiCalculatorScanner(db *mocks.Database) *Scanner {
+	return &Scanner{
+		FS:                    &OsFS{},
+		Repository:            newTestRepository(db),
+		FingerprintCalculator: fallbackCalc,
+		FingerprintCalculators: []FilteredFingerprintCalculator{
+			{
+				FingerprintCalculator: &fixedFingerprintCalculator{fingerprints: []models.Fingerprint{videoOshash}},
+				Filter:                extensionFilter(".mp4"),
+			},
+			{
+				FingerprintCalculator: &fixedFingerprintCalculator{fingerprints: []models.Fingerprint{imageMD5}},
+				Filter:                extensionFilter(".jpg"),
+			},
+		},
+	}
+}
+
+func TestScanner_calculateFingerprintsMultiple(t *testing.T) {
+	s := newMultiCalculatorScanner(mocks.NewDatabase())
+
+	tests := []struct {
+		path string
+		want models.Fingerprints
+	}{
+		{"/a.mp4", models.Fingerprints{videoOshash}},
+		{"/b.jpg", models.Fingerprints{imageMD5}},
+		{"/c.txt", models.Fingerprints{defaultHash}},
+	}
+
+	const useExisting = false
+	ctx := context.Background()
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			f := &models.BaseFile{Path: tt.path}
+			got, err := s.calculateFingerprints(ctx, nil, f, tt.path, useExisting)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestScanner_calculateFingerprintsPriority(t *testing.T) {
+	lowPriorityOshash := models.Fingerprint{Type: models.FingerprintTypeOshash, Fingerprint: "low"}
+
+	s := &Scanner{
+		FingerprintCalculators: []FilteredFingerprintCalculator{
+			{
+				FingerprintCalculator: &fixedFingerprintCalculator{fingerprints: []models.Fingerprint{videoOshash}},
+				Filter:                extensionFilter(".mp4"),
+			},
+			{
+				FingerprintCalculator: &fixedFingerprintCalculator{fingerprints: []models.Fingerprint{lowPriorityOshash, imageMD5}},
+				Filter:                extensionFilter(".mp4"),
+			},
+		},
+	}
+
+	const (
+		path        = "/a.mp4"
+		useExisting = false
+	)
+
+	got, err := s.calculateFingerprints(context.Background(), nil, &models.BaseFile{Path: path}, path, useExisting)
+	assert.NoError(t, err)
+	assert.Equal(t, models.Fingerprints{videoOshash, imageMD5}, got)
+}
+
+func TestScanner_ScanFileMultipleCalculatorsRename(t *testing.T) {
+	const (
+		oldPath = "/nonexistent/old.jpg"
+		newPath = "/nonexistent/new.jpg"
+	)
+
+	existing := &models.BaseFile{
+		ID:           models.FileID(10),
+		Path:         oldPath,
+		Basename:     filepath.Base(oldPath),
+		Fingerprints: models.Fingerprints{imageMD5},
+	}
+
+	db := mocks.NewDatabase()
+	// the image fingerprint matches the missing file
+	db.File.On("FindByFingerprint", mock.Anything, imageMD5).Return([]models.File{existing}, nil)
+	mockNewFiles(db)
+	db.File.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+	s := newMultiCalculatorScanner(db)
+
+	r, err := s.ScanFile(context.Background(), makeScannedFile(newPath))
+	assert.NoError(t, err)
+	if assert.NotNil(t, r) {
+		assert.True(t, r.Renamed)
+		assert.Equal(t, existing.ID, r.File.Base().ID)
+		assert.Equal(t, newPath, r.File.Base().Path)
+	}
+
+	db.File.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// writeTruncatedZip writes a zip file that has been truncated, such that it
+// cannot be opened.
+func writeTruncatedZip(t *testing.T, path string) int64 {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	fw, err := w.Create("image.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fw.Write(bytes.Repeat([]byte("data"), 100)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// drop the central directory
+	data := buf.Bytes()[:buf.Len()/2]
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return int64(len(data))
+}
+
+func TestScanner_OpenZipCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	zipPath := filepath.Join(dir, "corrupt.zip")
+	size := writeTruncatedZip(t, zipPath)
+
+	zipFile := makeScannedFile(zipPath)
+	zipFile.Size = size
+	zipFile.FS = &OsFS{}
+
+	t.Run("record error", func(t *testing.T) {
+		db := mocks.NewDatabase()
+		mockNewFiles(db)
+		db.File.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+		s := &Scanner{
+			Repository:            newTestRepository(db),
+			FingerprintCalculator: &testFingerprintCalculator{},
+			Stats:                 &ScanStats{},
+		}
+
+		ctx := context.Background()
+
+		var scanned []string
+		scan := func(path string) {
+			r, err := s.ScanFile(ctx, makeScannedFile(path))
+			assert.NoError(t, err)
+			if r != nil {
+				scanned = append(scanned, r.File.Base().Path)
+			}
+		}
+
+		scan("/a.mp4")
+
+		zipFS, err := s.OpenZip(zipFile)
+		assert.NoError(t, err)
+		assert.Nil(t, zipFS)
+
+		// sibling files are still scanned
+		scan("/c.mp4")
+
+		assert.Equal(t, []string{"/a.mp4", "/c.mp4"}, scanned)
+
+		scanErrors := s.Stats.Errors()
+		if assert.Len(t, scanErrors, 1) {
+			assert.Equal(t, zipPath, scanErrors[0].Path)
+			assert.True(t, errors.Is(scanErrors[0], zip.ErrFormat))
+		}
+	})
+
+	t.Run("fail fast", func(t *testing.T) {
+		s := &Scanner{}
+
+		zipFS, err := s.OpenZip(zipFile)
+		assert.Nil(t, zipFS)
+		assert.ErrorIs(t, err, zip.ErrFormat)
+	})
+}
+
+type skippedEntry struct {
+	path   string
+	reason SkipReason
+}
+
+func recordSkips(skipped *[]skippedEntry) SkipHandler {
+	return SkipHandlerFunc(func(path string, reason SkipReason) {
+		*skipped = append(*skipped, skippedEntry{path: path, reason: reason})
+	})
+}
+
+type extensionPathFilter string
+
+func (f extensionPathFilter) Accept(ctx context.Context, path string, info fs.FileInfo) bool {
+	return filepath.Ext(path) == string(f)
+}
+
+func TestScanner_AcceptEntrySkipHandler(t *testing.T) {
+	var skipped []skippedEntry
+
+	s := &Scanner{
+		ScanFilters: []PathFilter{extensionPathFilter(".mp4")},
+		SkipHandler: recordSkips(&skipped),
+	}
+
+	ctx := context.Background()
+
+	assert.True(t, s.AcceptEntry(ctx, "/a.mp4", nil))
+	assert.False(t, s.AcceptEntry(ctx, "/b.txt", nil))
+
+	assert.Equal(t, []skippedEntry{{path: "/b.txt", reason: SkipReasonFiltered}}, skipped)
+
+	// nil handler is ignored
+	s.SkipHandler = nil
+	assert.False(t, s.AcceptEntry(ctx, "/c.txt", nil))
+}
+
+// junctionFS is a filesystem that treats the directories with the provided
+// names as junctions.
+type junctionFS struct {
+	OsFS
+	junctions []string
+}
+
+func (f *junctionFS) IsJunction(info fs.FileInfo) bool {
+	return info.IsDir() && slices.Contains(f.junctions, info.Name())
+}
+
+func TestScanner_AcceptEntrySkipJunctions(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"junction", "dir"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lstat := func(name string) fs.FileInfo {
+		t.Helper()
+		info, err := os.Lstat(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info
+	}
+
+	var skipped []skippedEntry
+
+	s := &Scanner{
+		FS:          &junctionFS{junctions: []string{"junction"}},
+		SkipHandler: recordSkips(&skipped),
+	}
+
+	ctx := context.Background()
+	junctionPath := filepath.Join(root, "junction")
+
+	// junctions are followed by default
+	assert.True(t, s.AcceptEntry(ctx, junctionPath, lstat("junction")))
+
+	s.SkipJunctions = true
+	assert.False(t, s.AcceptEntry(ctx, junctionPath, lstat("junction")))
+	assert.True(t, s.AcceptEntry(ctx, filepath.Join(root, "dir"), lstat("dir")))
+
+	assert.Equal(t, []skippedEntry{{path: junctionPath, reason: SkipReasonJunction}}, skipped)
+}
+
+// notReaderAtFS is a filesystem that cannot open zip files for walking.
+type notReaderAtFS struct {
+	OsFS
+}
+
+func (f *notReaderAtFS) OpenZip(name string, size int64) (models.ZipFS, error) {
+	return nil, ErrNotReaderAt
+}
+
+func TestScanner_OpenZipSkipHandler(t *testing.T) {
+	var skipped []skippedEntry
+
+	s := &Scanner{
+		SkipHandler: recordSkips(&skipped),
+	}
+
+	zipFile := makeScannedFile("/a.zip")
+	zipFile.FS = &notReaderAtFS{}
+
+	zipFS, err := s.OpenZip(zipFile)
+	assert.NoError(t, err)
+	assert.Nil(t, zipFS)
+
+	assert.Equal(t, []skippedEntry{{path: "/a.zip", reason: SkipReasonZipNotWalkable}}, skipped)
+}
+
+type prefixPathFilter string
+
+func (f prefixPathFilter) Accept(ctx context.Context, path string, info fs.FileInfo) bool {
+	return path == string(f) || strings.HasPrefix(path, string(f)+string(filepath.Separator))
+}
+
+func TestScanner_ScanFileCreateMissingFolders(t *testing.T) {
+	root := t.TempDir()
+	library := filepath.Join(root, "library")
+	sub := filepath.Join(library, "sub")
+	path := filepath.Join(sub, "a.mp4")
+
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	scannedFile := makeScannedFile(path)
+	scannedFile.FS = &OsFS{}
+
+	newScanner := func(db *mocks.Database, createMissing bool) *Scanner {
+		return &Scanner{
+			FS:                    &OsFS{},
+			Repository:            newTestRepository(db),
+			FingerprintCalculator: &testFingerprintCalculator{},
+			ScanFilters:           []PathFilter{prefixPathFilter(library)},
+			CreateMissingFolders:  createMissing,
+		}
+	}
+
+	t.Run("create", func(t *testing.T) {
+		db := mocks.NewDatabase()
+		db.File.On("FindByPath", mock.Anything, mock.Anything, true).Return(nil, nil)
+		db.File.On("FindByFingerprint", mock.Anything, mock.Anything).Return(nil, nil)
+		db.File.On("FindByFileInfo", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+		db.File.On("Create", mock.Anything, mock.Anything).Return(nil)
+		db.Folder.On("FindByPath", mock.Anything, mock.Anything, true).Return(nil, nil)
+
+		var created []*models.Folder
+		db.Folder.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			f := args.Get(1).(*models.Folder)
+			f.ID = models.FolderID(len(created) + 1)
+			created = append(created, f)
+		}).Return(nil)
+
+		s := newScanner(db, true)
+
+		r, err := s.ScanFile(context.Background(), scannedFile)
+		assert.NoError(t, err)
+
+		// ancestors are created up to the library root, parent first
+		if assert.Len(t, created, 2) {
+			assert.Equal(t, library, created[0].Path)
+			assert.Nil(t, created[0].ParentFolderID)
+			assert.Equal(t, sub, created[1].Path)
+			assert.Equal(t, &created[0].ID, created[1].ParentFolderID)
+		}
+
+		if assert.NotNil(t, r) {
+			assert.True(t, r.New)
+			assert.Equal(t, created[1].ID, r.File.Base().ParentFolderID)
+		}
+
+		// created folders are cached
+		id, err := s.getFolderID(context.Background(), sub)
+		assert.NoError(t, err)
+		assert.Equal(t, &created[1].ID, id)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		db := mocks.NewDatabase()
+		db.File.On("FindByPath", mock.Anything, mock.Anything, true).Return(nil, nil)
+		db.Folder.On("FindByPath", mock.Anything, mock.Anything, true).Return(nil, nil)
+
+		s := newScanner(db, false)
+
+		r, err := s.ScanFile(context.Background(), scannedFile)
+		assert.Nil(t, r)
+		assert.Error(t, err)
+
+		db.Folder.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+// contentFingerprintCalculator returns the provided oshash, or the existing
+// oshash of the file if useExisting is true.
+type contentFingerprintCalculator struct {
+	oshash string
+}
+
+func (c *contentFingerprintCalculator) CalculateFingerprints(f *models.BaseFile, o Opener, useExisting bool) ([]models.Fingerprint, error) {
+	if useExisting {
+		if fp := f.Fingerprints.For(models.FingerprintTypeOshash); fp != nil {
+			return []models.Fingerprint{*fp}, nil
+		}
+	}
+
+	return []models.Fingerprint{
+		{
+			Type:        models.FingerprintTypeOshash,
+			Fingerprint: c.oshash,
+		},
+	}, nil
+}
+
+func TestScanner_ScanFileVerifyOshash(t *testing.T) {
+	const (
+		path         = "/nonexistent/a.mp4"
+		storedHash   = "0000000000000000"
+		actualHash   = "1111111111111111"
+		storedMD5Sum = "md5"
+	)
+
+	newExisting := func(oshash string) *models.BaseFile {
+		return &models.BaseFile{
+			ID:       models.FileID(10),
+			Path:     path,
+			Basename: filepath.Base(path),
+			Fingerprints: models.Fingerprints{
+				{Type: models.FingerprintTypeOshash, Fingerprint: oshash},
+				{Type: models.FingerprintTypeMD5, Fingerprint: storedMD5Sum},
+			},
+		}
+	}
+
+	scannedFile := makeScannedFile(path)
+	scannedFile.Basename = filepath.Base(path)
+
+	type mismatch struct {
+		stored     string
+		calculated string
+	}
+
+	tests := []struct {
+		name           string
+		storedHash     string
+		repair         bool
+		wantMismatches []mismatch
+		wantUpdate     bool
+		wantFP         models.Fingerprints
+	}{
+		{
+			"match",
+			actualHash,
+			true,
+			nil,
+			false,
+			newExisting(actualHash).Fingerprints,
+		},
+		{
+			"mismatch report only",
+			storedHash,
+			false,
+			[]mismatch{{storedHash, actualHash}},
+			false,
+			newExisting(storedHash).Fingerprints,
+		},
+		{
+			"mismatch repair",
+			storedHash,
+			true,
+			[]mismatch{{storedHash, actualHash}},
+			true,
+			// outdated MD5 is removed since it was not recalculated
+			models.Fingerprints{{Type: models.FingerprintTypeOshash, Fingerprint: actualHash}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := newExisting(tt.storedHash)
+
+			db := mocks.NewDatabase()
+			db.File.On("FindByPath", mock.Anything, path, true).Return(existing, nil)
+			db.File.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+			var got []mismatch
+			s := &Scanner{
+				Repository:            newTestRepository(db),
+				FingerprintCalculator: &contentFingerprintCalculator{oshash: actualHash},
+				VerifyOshash:          true,
+				RepairOshash:          tt.repair,
+				OshashMismatchHandler: OshashMismatchHandlerFunc(func(f models.File, stored string, calculated string) {
+					assert.Equal(t, existing.ID, f.Base().ID)
+					got = append(got, mismatch{stored, calculated})
+				}),
+			}
+
+			r, err := s.ScanFile(context.Background(), scannedFile)
+			assert.NoError(t, err)
+			assert.NotNil(t, r)
+
+			assert.Equal(t, tt.wantMismatches, got)
+			assert.Equal(t, tt.wantFP, existing.Fingerprints)
+
+			if tt.wantUpdate {
+				db.File.AssertCalled(t, "Update", mock.Anything, existing)
+			} else {
+				db.File.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestScanner_ScanFileStrictRenameDetection(t *testing.T) {
+	const (
+		oldPath = "/nonexistent/old.mp4"
+		newPath = "/nonexistent/new.mp4"
+	)
+
+	oshash := models.Fingerprint{Type: models.FingerprintTypeOshash, Fingerprint: "oshash"}
+	md5 := models.Fingerprint{Type: models.FingerprintTypeMD5, Fingerprint: "md5"}
+	otherMD5 := models.Fingerprint{Type: models.FingerprintTypeMD5, Fingerprint: "other"}
+
+	tests := []struct {
+		name          string
+		strict        bool
+		newFP         []models.Fingerprint
+		existingFP    models.Fingerprints
+		wantRenamed   bool
+		wantCreateNew bool
+	}{
+		{"oshash collision not strict", false, []models.Fingerprint{oshash, md5}, models.Fingerprints{oshash, otherMD5}, true, false},
+		{"oshash collision strict", true, []models.Fingerprint{oshash, md5}, models.Fingerprints{oshash, otherMD5}, false, true},
+		{"full hash match strict", true, []models.Fingerprint{oshash, md5}, models.Fingerprints{oshash, md5}, true, false},
+		{"existing missing full hash strict", true, []models.Fingerprint{oshash, md5}, models.Fingerprints{oshash}, false, true},
+		{"oshash only strict", true, []models.Fingerprint{oshash}, models.Fingerprints{oshash}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := &models.BaseFile{
+				ID:           models.FileID(10),
+				Path:         oldPath,
+				Basename:     filepath.Base(oldPath),
+				Fingerprints: tt.existingFP,
+			}
+
+			db := mocks.NewDatabase()
+			db.File.On("FindByFingerprint", mock.Anything, oshash).Return([]models.File{existing}, nil)
+			mockNewFiles(db)
+			db.File.On("FindByFileInfo", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+			db.File.On("Create", mock.Anything, mock.Anything).Return(nil)
+			db.File.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+			s := &Scanner{
+				FS:                    &OsFS{},
+				Repository:            newTestRepository(db),
+				FingerprintCalculator: &fixedFingerprintCalculator{fingerprints: tt.newFP},
+				StrictRenameDetection: tt.strict,
+			}
+
+			r, err := s.ScanFile(context.Background(), makeScannedFile(newPath))
+			assert.NoError(t, err)
+			if assert.NotNil(t, r) {
+				assert.Equal(t, tt.wantRenamed, r.Renamed)
+				assert.Equal(t, tt.wantCreateNew, r.New)
+			}
+
+			if tt.wantCreateNew {
+				db.File.AssertCalled(t, "Create", mock.Anything, mock.Anything)
+			} else {
+				db.File.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestScanner_ScanFileFingerprintCollision(t *testing.T) {
+	dir := t.TempDir()
+	existingPath := filepath.Join(dir, "a.mp4")
+	newPath := filepath.Join(dir, "b.mp4")
+
+	for _, p := range []string{existingPath, newPath} {
+		if err := os.WriteFile(p, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	oshash := models.Fingerprint{Type: models.FingerprintTypeOshash, Fingerprint: "oshash"}
+	md5 := models.Fingerprint{Type: models.FingerprintTypeMD5, Fingerprint: "md5"}
+
+	existing := &models.BaseFile{
+		ID:           models.FileID(10),
+		Path:         existingPath,
+		Basename:     filepath.Base(existingPath),
+		Fingerprints: models.Fingerprints{oshash, md5},
+	}
+
+	db := mocks.NewDatabase()
+	db.File.On("FindByPath", mock.Anything, mock.Anything, true).Return(nil, nil)
+	db.File.On("FindByFingerprint", mock.Anything, mock.Anything).Return([]models.File{existing}, nil)
+	db.Folder.On("FindByPath", mock.Anything, mock.Anything, true).Return(&models.Folder{ID: testFolderID}, nil)
+	db.File.On("FindByFileInfo", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+	db.File.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	var (
+		gotFile     models.File
+		gotFP       models.Fingerprint
+		gotExisting []models.File
+	)
+
+	s := &Scanner{
+		FS:                    &OsFS{},
+		Repository:            newTestRepository(db),
+		FingerprintCalculator: &fixedFingerprintCalculator{fingerprints: []models.Fingerprint{oshash, md5}},
+		FingerprintCollisionHandler: FingerprintCollisionHandlerFunc(func(f models.File, fp models.Fingerprint, existing []models.File) {
+			gotFile = f
+			gotFP = fp
+			gotExisting = existing
+		}),
+	}
+
+	r, err := s.ScanFile(context.Background(), makeScannedFile(newPath))
+	assert.NoError(t, err)
+	if assert.NotNil(t, r) {
+		assert.True(t, r.New)
+		assert.False(t, r.Renamed)
+	}
+
+	if assert.NotNil(t, gotFile) {
+		assert.Equal(t, newPath, gotFile.Base().Path)
+	}
+	assert.Equal(t, md5, gotFP)
+	assert.Equal(t, []models.File{existing}, gotExisting)
+	db.File.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestScanner_ScanFileSimilarImage(t *testing.T) {
+	oshash := models.Fingerprint{Type: models.FingerprintTypeOshash, Fingerprint: "oshash"}
+	phash := models.Fingerprint{Type: models.FingerprintTypePhash, Fingerprint: int64(1234)}
+
+	// the similar file is missing, but must not be treated as moved
+	similar := &models.BaseFile{
+		ID:           models.FileID(10),
+		Path:         "/nonexistent/similar.jpg",
+		Fingerprints: models.Fingerprints{{Type: models.FingerprintTypeOshash, Fingerprint: "other"}, phash},
+	}
+
+	db := mocks.NewDatabase()
+	db.File.On("FindByFingerprint", mock.Anything, phash).Return([]models.File{similar}, nil)
+	mockNewFiles(db)
+	db.File.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	var (
+		gotFP       models.Fingerprint
+		gotExisting []models.File
+	)
+
+	s := &Scanner{
+		FS:                    &OsFS{},
+		Repository:            newTestRepository(db),
+		FingerprintCalculator: &fixedFingerprintCalculator{fingerprints: []models.Fingerprint{oshash, phash}},
+		FingerprintCollisionHandler: FingerprintCollisionHandlerFunc(func(f models.File, fp models.Fingerprint, existing []models.File) {
+			gotFP = fp
+			gotExisting = existing
+		}),
+	}
+
+	r, err := s.ScanFile(context.Background(), makeScannedFile("/nonexistent/new.jpg"))
+	assert.NoError(t, err)
+	if assert.NotNil(t, r) {
+		assert.True(t, r.New)
+		assert.False(t, r.Renamed)
+	}
+
+	assert.Equal(t, phash, gotFP)
+	assert.Equal(t, []models.File{similar}, gotExisting)
+	db.File.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+// videoDecorator converts files into video files, counting the number of
+// files decorated.
+type videoDecorator struct {
+	decorated int
+}
+
+func (d *videoDecorator) Decorate(ctx context.Context, fs models.FS, f models.File) (models.File, error) {
+	d.decorated++
+	return &models.VideoFile{
+		BaseFile: f.Base(),
+		Width:    1920,
+		Height:   1080,
+	}, nil
+}
+
+func (d *videoDecorator) IsMissingMetadata(ctx context.Context, fs models.FS, f models.File) bool {
+	_, ok := f.(*models.VideoFile)
+	return !ok
+}
+
+// versionedDecorator converts files into video files, recording its version
+// on the file.
+type versionedDecorator struct {
+	videoDecorator
+	version int
+}
+
+func (d *versionedDecorator) Decorate(ctx context.Context, fs models.FS, f models.File) (models.File, error) {
+	d.decorated++
+	base := f.Base()
+	base.MetadataVersion = d.version
+	return &models.VideoFile{
+		BaseFile: base,
+	}, nil
+}
+
+func (d *versionedDecorator) MetadataVersion(ctx context.Context, fs models.FS, f models.File) (int, bool) {
+	vf, ok := f.(*models.VideoFile)
+	if !ok {
+		return 0, false
+	}
+
+	return vf.MetadataVersion, true
+}
+
+type countingHandler struct {
+	handled []models.File
+}
+
+func (h *countingHandler) Handle(ctx context.Context, f models.File, oldFile models.File) error {
+	h.handled = append(h.handled, f)
+	return nil
+}
+
+func TestScanner_fireHandlersRetry(t *testing.T) {
+	handler := &countingHandler{}
+
+	db := mocks.NewDatabase()
+	s := &Scanner{
+		Repository:   newTestRepository(db),
+		FileHandlers: []Handler{handler},
+	}
+
+	f := &models.BaseFile{ID: 1, Path: "/nonexistent/a.mp4"}
+	ctx := withFiredHandlers(context.Background())
+
+	errLocked := errors.New("database is locked")
+	fire := func(op handlerOperation, fail bool) error {
+		return s.Repository.WithTxn(ctx, func(ctx context.Context) error {
+			if err := s.fireHandlers(ctx, f, nil, op); err != nil {
+				return err
+			}
+			if fail {
+				return errLocked
+			}
+			return nil
+		})
+	}
+
+	// the handler must be fired again if its transaction was rolled back
+	assert.ErrorIs(t, fire(handlerOperationCreate, true), errLocked)
+	assert.NoError(t, fire(handlerOperationCreate, false))
+	assert.Len(t, handler.handled, 2)
+
+	// once committed, retrying does not fire the handler again
+	assert.NoError(t, fire(handlerOperationCreate, false))
+	assert.Len(t, handler.handled, 2)
+
+	// a different operation on the same file is handled
+	assert.NoError(t, fire(handlerOperationUpdate, false))
+	assert.Len(t, handler.handled, 3)
+
+	// handlers are tracked per ScanFile call
+	ctx = withFiredHandlers(context.Background())
+	assert.NoError(t, fire(handlerOperationCreate, false))
+	assert.Len(t, handler.handled, 4)
+}
+
+func TestScanner_ScanFileFingerprintDenylist(t *testing.T) {
+	const (
+		denied  = "/nonexistent/trailer.mp4"
+		allowed = "/nonexistent/a.mp4"
+	)
+
+	db := mocks.NewDatabase()
+	mockNewFiles(db)
+	db.File.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	decorator := &videoDecorator{}
+	skipped := make(map[string]SkipReason)
+
+	s := &Scanner{
+		Repository:            newTestRepository(db),
+		FingerprintCalculator: &testFingerprintCalculator{},
+		FileDecorators:        []Decorator{decorator},
+		FingerprintDenylist: models.Fingerprints{
+			{Type: models.FingerprintTypeMD5, Fingerprint: denied},
+			{Type: models.FingerprintTypeOshash, Fingerprint: denied},
+		},
+		SkipHandler: SkipHandlerFunc(func(path string, reason SkipReason) {
+			skipped[path] = reason
+		}),
+	}
+
+	// the denied file is not created, decorated or checked for renames
+	r, err := s.ScanFile(context.Background(), makeScannedFile(denied))
+	assert.NoError(t, err)
+	assert.Nil(t, r)
+	assert.Equal(t, map[string]SkipReason{denied: SkipReasonDeniedFingerprint}, skipped)
+	assert.Equal(t, 0, decorator.decorated)
+	db.File.AssertNotCalled(t, "FindByFingerprint", mock.Anything, mock.Anything)
+	db.File.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+
+	r, err = s.ScanFile(context.Background(), makeScannedFile(allowed))
+	assert.NoError(t, err)
+	if assert.NotNil(t, r) {
+		assert.True(t, r.New)
+	}
+	assert.NotContains(t, skipped, allowed)
+	db.File.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestScanner_ScanFileVerifyReadable(t *testing.T) {
+	dir := t.TempDir()
+
+	readable := filepath.Join(dir, "a.mp4")
+	if err := os.WriteFile(readable, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	empty := filepath.Join(dir, "empty.mp4")
+	if err := os.WriteFile(empty, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// a directory can be opened, but not read from
+	unreadable := filepath.Join(dir, "b.mp4")
+	if err := os.Mkdir(unreadable, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	missing := filepath.Join(dir, "c.mp4")
+
+	scannedFile := func(path string) ScannedFile {
+		f := makeScannedFile(path)
+		f.FS = &OsFS{}
+		return f
+	}
+
+	newScanner := func(db *mocks.Database, verify bool, skipped *[]skippedEntry) *Scanner {
+		return &Scanner{
+			Repository:            newTestRepository(db),
+			FingerprintCalculator: &testFingerprintCalculator{},
+			VerifyReadable:        verify,
+			SkipHandler:           recordSkips(skipped),
+		}
+	}
+
+	db := mocks.NewDatabase()
+	mockNewFiles(db)
+	db.File.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	var skipped []skippedEntry
+	s := newScanner(db, true, &skipped)
+	ctx := context.Background()
+
+	for _, p := range []string{readable, empty} {
+		r, err := s.ScanFile(ctx, scannedFile(p))
+		assert.NoError(t, err)
+		if assert.NotNil(t, r, p) {
+			assert.True(t, r.New)
+		}
+	}
+
+	for _, p := range []string{unreadable, missing} {
+		r, err := s.ScanFile(ctx, scannedFile(p))
+		assert.NoError(t, err)
+		assert.Nil(t, r, p)
+	}
+
+	assert.Equal(t, []skippedEntry{
+		{path: unreadable, reason: SkipReasonUnreadable},
+		{path: missing, reason: SkipReasonUnreadable},
+	}, skipped)
+	db.File.AssertNumberOfCalls(t, "Create", 2)
+
+	// unreadable files are created if the option is not set
+	db = mocks.NewDatabase()
+	mockNewFiles(db)
+	db.File.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	skipped = nil
+	r, err := newScanner(db, false, &skipped).ScanFile(ctx, scannedFile(unreadable))
+	assert.NoError(t, err)
+	assert.NotNil(t, r)
+	assert.Empty(t, skipped)
+}
+
+func TestScanner_ScanFileSkipDecorators(t *testing.T) {
+	const path = "/nonexistent/a.mp4"
+
+	decorator := &videoDecorator{}
+	handler := &countingHandler{}
+
+	newScanner := func(db *mocks.Database, skip bool) *Scanner {
+		return &Scanner{
+			Repository:            newTestRepository(db),
+			FingerprintCalculator: &testFingerprintCalculator{},
+			FileDecorators:        []Decorator{decorator},
+			FileHandlers:          []Handler{handler},
+			SkipDecorators:        skip,
+		}
+	}
+
+	// quick scan creates the file without decorating it
+	var created models.File
+	db := mocks.NewDatabase()
+	mockNewFiles(db)
+	db.File.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		created = args.Get(1).(models.File)
+	}).Return(nil)
+
+	r, err := newScanner(db, true).ScanFile(context.Background(), makeScannedFile(path))
+	assert.NoError(t, err)
+	if assert.NotNil(t, r) {
+		assert.True(t, r.New)
+	}
+
+	assert.IsType(t, &models.BaseFile{}, created)
+	assert.Equal(t, 0, decorator.decorated)
+	assert.Empty(t, handler.handled)
+	assert.True(t, decorator.IsMissingMetadata(context.Background(), nil, created))
+
+	// a subsequent quick scan does not set the missing metadata
+	db = mocks.NewDatabase()
+	db.File.On("FindByPath", mock.Anything, path, true).Return(created, nil)
+
+	_, err = newScanner(db, true).ScanFile(context.Background(), makeScannedFile(path))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, decorator.decorated)
+	db.File.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+
+	// a subsequent full scan decorates the file and fires the handlers
+	var updated models.File
+	db = mocks.NewDatabase()
+	db.File.On("FindByPath", mock.Anything, path, true).Return(created, nil)
+	db.File.On("Update", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		updated = args.Get(1).(models.File)
+	}).Return(nil)
+
+	r, err = newScanner(db, false).ScanFile(context.Background(), makeScannedFile(path))
+	assert.NoError(t, err)
+	if assert.NotNil(t, r) {
+		assert.True(t, r.Updated)
+	}
+
+	assert.Equal(t, 1, decorator.decorated)
+	assert.IsType(t, &models.VideoFile{}, updated)
+	if assert.Len(t, handler.handled, 1) {
+		assert.IsType(t, &models.VideoFile{}, handler.handled[0])
+	}
+}
+
+func TestScanner_ScanFileSkipDecoratorsUpdated(t *testing.T) {
+	const path = "/nonexistent/a.mp4"
+
+	oldModTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	existing := &models.VideoFile{
+		BaseFile: &models.BaseFile{
+			ID:       models.FileID(10),
+			Path:     path,
+			Basename: path,
+			DirEntry: models.DirEntry{
+				ModTime: oldModTime,
+			},
+		},
+	}
+
+	decorator := &videoDecorator{}
+	handler := &countingHandler{}
+
+	db := mocks.NewDatabase()
+	db.File.On("FindByPath", mock.Anything, path, true).Return(existing, nil)
+	db.File.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+	s := &Scanner{
+		Repository:            newTestRepository(db),
+		FingerprintCalculator: &testFingerprintCalculator{},
+		FileDecorators:        []Decorator{decorator},
+		FileHandlers:          []Handler{handler},
+		SkipDecorators:        true,
+	}
+
+	scanned := makeScannedFile(path)
+	scanned.ModTime = oldModTime.Add(time.Hour)
+
+	r, err := s.ScanFile(context.Background(), scanned)
+	assert.NoError(t, err)
+	if assert.NotNil(t, r) {
+		assert.True(t, r.Updated)
+	}
+
+	assert.Equal(t, 0, decorator.decorated)
+	assert.Empty(t, handler.handled)
+
+	// fingerprints are updated, but the mod time is retained so that the
+	// next full scan treats the file as updated
+	assert.NotNil(t, existing.Fingerprints.For(models.FingerprintTypeOshash))
+	assert.Equal(t, oldModTime, existing.ModTime)
+	db.File.AssertCalled(t, "Update", mock.Anything, existing)
+}
+
+func TestScanner_ScanFileVerifyContents(t *testing.T) {
+	const path = "/nonexistent/a.mp4"
+
+	tests := []struct {
+		name        string
+		filter      Filter
+		contents    string
+		wantUpdated bool
+	}{
+		{"disabled", nil, "changed", false},
+		{"not accepted by filter", extensionFilter(".jpg"), "changed", false},
+		{"contents changed", extensionFilter(".mp4"), "changed", true},
+		{"contents unchanged", extensionFilter(".mp4"), "original", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the mod time and basename are unchanged
+			existing := &models.VideoFile{
+				BaseFile: &models.BaseFile{
+					ID:       models.FileID(10),
+					Path:     path,
+					Basename: path,
+					Fingerprints: models.Fingerprints{
+						{Type: models.FingerprintTypeOshash, Fingerprint: "original"},
+					},
+				},
+				Format: "1",
+			}
+
+			var updated models.File
+			db := mocks.NewDatabase()
+			db.File.On("FindByPath", mock.Anything, path, true).Return(existing, nil)
+			db.File.On("Update", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				updated = args.Get(1).(models.File)
+			}).Return(nil)
+
+			handler := &countingHandler{}
+			s := &Scanner{
+				Repository:            newTestRepository(db),
+				FingerprintCalculator: &contentFingerprintCalculator{oshash: tt.contents},
+				FileDecorators:        []Decorator{&videoDecorator{}},
+				FileHandlers:          []Handler{handler},
+				HandlerRequiredFilters: []Filter{FilterFunc(func(ctx context.Context, f models.File) bool {
+					return false
+				})},
+			}
+			if tt.filter != nil {
+				s.VerifyContentFilters = []Filter{tt.filter}
+			}
+
+			r, err := s.ScanFile(context.Background(), makeScannedFile(path))
+			assert.NoError(t, err)
+			if !assert.NotNil(t, r) {
+				return
+			}
+
+			assert.Equal(t, tt.wantUpdated, r.Updated)
+			if !tt.wantUpdated {
+				db.File.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+				assert.Empty(t, handler.handled)
+				return
+			}
+
+			if assert.NotNil(t, updated) {
+				assert.Equal(t, "changed", updated.Base().Fingerprints.GetString(models.FingerprintTypeOshash))
+			}
+			assert.Len(t, handler.handled, 1)
+		})
+	}
+}
+
+func TestScanner_ScanFileMinMetadataVersion(t *testing.T) {
+	const path = "/nonexistent/a.mp4"
+
+	newExisting := func() *models.VideoFile {
+		return &models.VideoFile{
+			BaseFile: &models.BaseFile{
+				ID:       models.FileID(10),
+				Path:     path,
+				Basename: path,
+				Fingerprints: models.Fingerprints{
+					{Type: models.FingerprintTypeOshash, Fingerprint: path},
+				},
+				MetadataVersion: 1,
+			},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		minVersion    int
+		filtered      bool
+		wantDecorated bool
+	}{
+		{"disabled", 0, false, false},
+		{"current version", 1, false, false},
+		{"old version", 2, false, true},
+		{"old version not accepted by filter", 2, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decorator := &versionedDecorator{version: 2}
+
+			var d Decorator = decorator
+			if tt.filtered {
+				d = &FilteredDecorator{
+					Decorator: decorator,
+					Filter:    extensionFilter(".jpg"),
+				}
+			}
+
+			var updated models.File
+			db := mocks.NewDatabase()
+			db.File.On("FindByPath", mock.Anything, path, true).Return(newExisting(), nil)
+			db.File.On("Update", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				updated = args.Get(1).(models.File)
+			}).Return(nil)
+
+			s := &Scanner{
+				Repository:            newTestRepository(db),
+				FingerprintCalculator: &testFingerprintCalculator{},
+				FileDecorators:        []Decorator{d},
+				MinMetadataVersion:    tt.minVersion,
+			}
+
+			r, err := s.ScanFile(context.Background(), makeScannedFile(path))
+			assert.NoError(t, err)
+			if !assert.NotNil(t, r) {
+				return
+			}
+
+			if !tt.wantDecorated {
+				assert.Equal(t, 0, decorator.decorated)
+				db.File.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+				return
+			}
+
+			assert.Equal(t, 1, decorator.decorated)
+			assert.True(t, r.Updated)
+			if assert.IsType(t, &models.VideoFile{}, updated) {
+				assert.Equal(t, 2, updated.Base().MetadataVersion)
+			}
+		})
+	}
+}
+
+func TestScanner_ScanFilePartialHashRename(t *testing.T) {
+	const sampleSize = 16
+
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.mp4")
+	newPath := filepath.Join(dir, "new.mp4")
+
+	// contents differ only in the middle, outside the sampled regions
+	oldData := bytes.Repeat([]byte{'a'}, 100)
+	newData := bytes.Repeat([]byte{'a'}, 100)
+	newData[50] = 'b'
+
+	calculator := &PartialHashCalculator{SampleSize: sampleSize}
+
+	hashOf := func(data []byte) models.Fingerprint {
+		t.Helper()
+		p := filepath.Join(dir, "tmp")
+		if err := os.WriteFile(p, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(p)
+
+		fp, err := calculator.CalculateFingerprints(&models.BaseFile{Path: p, Size: int64(len(data))}, &fsOpener{fs: &OsFS{}, name: p}, false)
+		if err != nil || len(fp) != 1 {
+			t.Fatalf("calculating partial hash: %v", err)
+		}
+		return fp[0]
+	}
+
+	oldFP := hashOf(oldData)
+	newFP := hashOf(newData)
+	assert.Equal(t, models.FingerprintTypePartialSHA256, oldFP.Type)
+	assert.Equal(t, oldFP, newFP)
+
+	// the old file no longer exists
+	if err := os.WriteFile(newPath, newData, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	existing := &models.BaseFile{
+		ID:           models.FileID(10),
+		Path:         oldPath,
+		Basename:     filepath.Base(oldPath),
+		Fingerprints: models.Fingerprints{oldFP},
+	}
+
+	db := mocks.NewDatabase()
+	db.File.On("FindByPath", mock.Anything, mock.Anything, true).Return(nil, nil)
+	db.File.On("FindByFingerprint", mock.Anything, oldFP).Return([]models.File{existing}, nil)
+	db.File.On("FindByFingerprint", mock.Anything, mock.Anything).Return(nil, nil)
+	db.Folder.On("FindByPath", mock.Anything, mock.Anything, true).Return(&models.Folder{ID: testFolderID}, nil)
+	db.File.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+	s := &Scanner{
+		FS:                    &OsFS{},
+		Repository:            newTestRepository(db),
+		FingerprintCalculator: &testFingerprintCalculator{},
+		FingerprintCalculators: []FilteredFingerprintCalculator{
+			{FingerprintCalculator: calculator, Filter: extensionFilter(".mp4")},
+		},
+		StrictRenameDetection: true,
+	}
+
+	scanned := makeScannedFile(newPath)
+	scanned.FS = &OsFS{}
+	scanned.Size = int64(len(newData))
+
+	r, err := s.ScanFile(context.Background(), scanned)
+	assert.NoError(t, err)
+	if assert.NotNil(t, r) {
+		assert.True(t, r.Renamed)
+		assert.Equal(t, existing.ID, r.File.Base().ID)
+		assert.Equal(t, newPath, r.File.Base().Path)
+	}
+}
+
+// slowFingerprintCalculator calculates fingerprints as testFingerprintCalculator,
+// taking at least the provided duration.
+type slowFingerprintCalculator struct {
+	testFingerprintCalculator
+	delay time.Duration
+}
+
+func (c *slowFingerprintCalculator) CalculateFingerprints(f *models.BaseFile, o Opener, useExisting bool) ([]models.Fingerprint, error) {
+	time.Sleep(c.delay)
+	return c.testFingerprintCalculator.CalculateFingerprints(f, o, useExisting)
+}
+
+func TestScanner_ScanFileFingerprintTiming(t *testing.T) {
+	const (
+		path = "/nonexistent/a.mp4"
+		size = 1024
+	)
+
+	db := mocks.NewDatabase()
+	mockNewFiles(db)
+	db.File.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	type timing struct {
+		path     string
+		size     int64
+		duration time.Duration
+	}
+	var timings []timing
+
+	s := &Scanner{
+		Repository:            newTestRepository(db),
+		FingerprintCalculator: &slowFingerprintCalculator{delay: time.Millisecond},
+		FingerprintTimingHandler: FingerprintTimingHandlerFunc(func(path string, size int64, duration time.Duration) {
+			timings = append(timings, timing{path, size, duration})
+		}),
+	}
+
+	f := makeScannedFile(path)
+	f.Size = size
+
+	_, err := s.ScanFile(context.Background(), f)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	if assert.Len(t, timings, 1) {
+		assert.Equal(t, path, timings[0].path)
+		assert.Equal(t, int64(size), timings[0].size)
+		assert.GreaterOrEqual(t, timings[0].duration, time.Millisecond)
+	}
+}
+
+func TestScanner_ScanFileUnsettled(t *testing.T) {
+	const (
+		recent = "/nonexistent/recent.mp4"
+		empty  = "/nonexistent/empty.mp4"
+		old    = "/nonexistent/old.mp4"
+	)
+
+	db := mocks.NewDatabase()
+	mockNewFiles(db)
+	db.File.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	skipped := make(map[string]SkipReason)
+
+	s := &Scanner{
+		Repository:            newTestRepository(db),
+		FingerprintCalculator: &testFingerprintCalculator{},
+		SkipEmptyFiles:        true,
+		SettleTime:            time.Minute,
+		SkipHandler: SkipHandlerFunc(func(path string, reason SkipReason) {
+			skipped[path] = reason
+		}),
+	}
+
+	makeFile := func(path string, size int64, modTime time.Time) ScannedFile {
+		f := makeScannedFile(path)
+		f.Size = size
+		f.ModTime = modTime
+		return f
+	}
+
+	// files that may still be being written are skipped without being looked up
+	for _, f := range []ScannedFile{
+		makeFile(recent, 1024, time.Now().Truncate(time.Second)),
+		makeFile(empty, 0, time.Now().Add(-time.Hour)),
+	} {
+		r, err := s.ScanFile(context.Background(), f)
+		assert.NoError(t, err)
+		assert.Nil(t, r)
+	}
+
+	assert.Equal(t, map[string]SkipReason{
+		recent: SkipReasonUnsettled,
+		empty:  SkipReasonEmpty,
+	}, skipped)
+	db.File.AssertNotCalled(t, "FindByPath", mock.Anything, mock.Anything, mock.Anything)
+
+	r, err := s.ScanFile(context.Background(), makeFile(old, 1024, time.Now().Add(-time.Hour)))
+	assert.NoError(t, err)
+	if assert.NotNil(t, r) {
+		assert.True(t, r.New)
+	}
+	assert.NotContains(t, skipped, old)
+}
+
+func TestScanner_ScanFilePathFingerprint(t *testing.T) {
+	const (
+		sidecar = "/nonexistent/a.NFO"
+		video   = "/nonexistent/a.mp4"
+	)
+
+	db := mocks.NewDatabase()
+	mockNewFiles(db)
+
+	var created []models.File
+	db.File.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		created = append(created, args.Get(1).(models.File))
+	}).Return(nil)
+
+	s := &Scanner{
+		Repository: newTestRepository(db),
+		// contents of the sidecar file must not be read
+		FingerprintCalculator: &testFingerprintCalculator{
+			errors: map[string]error{sidecar: errors.New("contents read")},
+		},
+		PathFingerprintExtensions: []string{"nfo"},
+	}
+
+	f := makeScannedFile(sidecar)
+	f.Size = 100
+
+	r, err := s.ScanFile(context.Background(), f)
+	if !assert.NoError(t, err) || !assert.NotNil(t, r) {
+		return
+	}
+
+	assert.True(t, r.New)
+	assert.Equal(t, models.Fingerprints{
+		{Type: models.FingerprintTypePathSize, Fingerprint: sidecar + ":100"},
+	}, r.File.Base().Fingerprints)
+
+	// path fingerprints are not used to detect renames
+	db.File.AssertNotCalled(t, "FindByFingerprint", mock.Anything, mock.Anything)
+
+	// other files are fingerprinted as normal
+	r, err = s.ScanFile(context.Background(), makeScannedFile(video))
+	if !assert.NoError(t, err) || !assert.NotNil(t, r) {
+		return
+	}
+
+	assert.Equal(t, models.Fingerprints{
+		{Type: models.FingerprintTypeOshash, Fingerprint: video},
+	}, r.File.Base().Fingerprints)
+	db.File.AssertCalled(t, "FindByFingerprint", mock.Anything, mock.Anything)
+	assert.Len(t, created, 2)
+}
+
+func TestScanner_ScanFileErrored(t *testing.T) {
+	const (
+		corrupt = "/nonexistent/corrupt.mp4"
+		stale   = "/nonexistent/stale.mp4"
+	)
+
+	db := mocks.NewDatabase()
+	mockNewFiles(db)
+	db.File.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	calculator := &testFingerprintCalculator{
+		errors: map[string]error{corrupt: errors.New("corrupt file")},
+	}
+	tracker := NewMemoryErroredFileTracker()
+
+	var skipped []string
+
+	s := &Scanner{
+		Repository:            newTestRepository(db),
+		FingerprintCalculator: calculator,
+		ErroredFileTracker:    tracker,
+		ErrorRetryInterval:    time.Hour,
+		SkipHandler: SkipHandlerFunc(func(path string, reason SkipReason) {
+			assert.Equal(t, SkipReasonErrored, reason)
+			skipped = append(skipped, path)
+		}),
+	}
+
+	ctx := context.Background()
+	f := makeScannedFile(corrupt)
+
+	_, err := s.ScanFile(ctx, f)
+	assert.Error(t, err)
+	assert.Empty(t, skipped)
+
+	// recently errored file is skipped, even if it would now succeed
+	delete(calculator.errors, corrupt)
+
+	r, err := s.ScanFile(ctx, f)
+	assert.NoError(t, err)
+	assert.Nil(t, r)
+	assert.Equal(t, []string{corrupt}, skipped)
+
+	// modified file is scanned again
+	f.ModTime = f.ModTime.Add(time.Second)
+
+	r, err = s.ScanFile(ctx, f)
+	assert.NoError(t, err)
+	if assert.NotNil(t, r) {
+		assert.True(t, r.New)
+	}
+	_, ok := tracker.Get(corrupt, f.ModTime)
+	assert.False(t, ok)
+
+	// file is scanned again once the retry interval has passed
+	staleFile := makeScannedFile(stale)
+	tracker.Set(stale, staleFile.ModTime, time.Now().Add(-2*time.Hour))
+
+	r, err = s.ScanFile(ctx, staleFile)
+	assert.NoError(t, err)
+	assert.NotNil(t, r)
+	assert.Equal(t, []string{corrupt}, skipped)
+}
+
+func TestScanner_ScanFileRenameWithoutRehash(t *testing.T) {
+	const (
+		path        = "/nonexistent/a.mp4"
+		oldBasename = "a.mp4"
+		newBasename = "A.mp4"
+	)
+
+	modTime := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		enabled    bool
+		size       int64
+		wantRehash bool
+	}{
+		{"disabled", false, 100, true},
+		{"basename changed", true, 100, false},
+		{"size changed", true, 200, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := &models.VideoFile{
+				BaseFile: &models.BaseFile{
+					ID:       models.FileID(10),
+					Path:     path,
+					Basename: oldBasename,
+					DirEntry: models.DirEntry{ModTime: modTime},
+					Size:     100,
+					Fingerprints: models.Fingerprints{
+						{Type: models.FingerprintTypeOshash, Fingerprint: "original"},
+					},
+				},
+				Format: "1",
+			}
+
+			var updated models.File
+			db := mocks.NewDatabase()
+			db.File.On("FindByPath", mock.Anything, path, true).Return(existing, nil)
+			db.File.On("Update", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				updated = args.Get(1).(models.File)
+			}).Return(nil)
+
+			calculator := &countingFingerprintCalculator{}
+			decorator := &videoDecorator{}
+			handler := &countingHandler{}
+			s := &Scanner{
+				Repository:            newTestRepository(db),
+				FingerprintCalculator: calculator,
+				FileDecorators:        []Decorator{decorator},
+				FileHandlers:          []Handler{handler},
+				RenameWithoutRehash:   tt.enabled,
+			}
+
+			f := makeScannedFile(path)
+			f.Basename = newBasename
+			f.ModTime = modTime
+			f.Size = tt.size
+
+			r, err := s.ScanFile(context.Background(), f)
+			assert.NoError(t, err)
+			if !assert.NotNil(t, r) {
+				return
+			}
+
+			assert.True(t, r.Updated)
+			if assert.NotNil(t, updated) {
+				assert.Equal(t, newBasename, updated.Base().Basename)
+			}
+			assert.Len(t, handler.handled, 1)
+
+			if tt.wantRehash {
+				assert.Equal(t, 1, calculator.calls)
+				assert.Equal(t, 1, decorator.decorated)
+				return
+			}
+
+			assert.Equal(t, 0, calculator.calls)
+			assert.Equal(t, 0, decorator.decorated)
+			assert.Equal(t, "original", updated.Base().Fingerprints.GetString(models.FingerprintTypeOshash))
+		})
+	}
+}
+
+func TestScanner_PreloadFolders(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "library")
+	sub := filepath.Join(root, "sub")
+
+	paths := []string{
+		filepath.Join(sub, "a.mp4"),
+		filepath.Join(sub, "b.mp4"),
+		filepath.Join(sub, "c.mp4"),
+	}
+
+	db := mocks.NewDatabase()
+	db.File.On("FindByPath", mock.Anything, mock.Anything, true).Return(nil, nil)
+	db.File.On("FindByFingerprint", mock.Anything, mock.Anything).Return(nil, nil)
+	db.File.On("Create", mock.Anything, mock.Anything).Return(nil)
+	db.Folder.On("FindByPaths", mock.Anything, mock.Anything).Return([]*models.Folder{
+		{ID: 1, Path: root},
+		{ID: 2, Path: sub},
+	}, nil).Once()
+
+	s := &Scanner{
+		Repository:            newTestRepository(db),
+		FingerprintCalculator: &testFingerprintCalculator{},
+	}
+
+	assert.NoError(t, s.PreloadFolders(context.Background(), paths))
+
+	// each ancestor is queried once
+	db.Folder.AssertCalled(t, "FindByPaths", mock.Anything, []string{
+		sub,
+		root,
+		string(filepath.Separator),
+	})
+
+	for _, p := range paths {
+		r, err := s.ScanFile(context.Background(), makeScannedFile(p))
+		assert.NoError(t, err)
+		if assert.NotNil(t, r) {
+			assert.Equal(t, models.FolderID(2), r.File.Base().ParentFolderID)
+		}
+	}
+
+	db.Folder.AssertNotCalled(t, "FindByPath", mock.Anything, mock.Anything, mock.Anything)
+
+	// only the folder that was not found is queried again
+	rootOnly := []string{string(filepath.Separator)}
+	db.Folder.On("FindByPaths", mock.Anything, rootOnly).Return(nil, nil).Once()
+
+	assert.NoError(t, s.PreloadFolders(context.Background(), paths[:1]))
+	db.Folder.AssertCalled(t, "FindByPaths", mock.Anything, rootOnly)
+	db.Folder.AssertNumberOfCalls(t, "FindByPaths", 2)
+}
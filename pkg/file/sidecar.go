@@ -0,0 +1,197 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// sidecarMetadata is the metadata read from a sidecar file.
+// Fields that are not present in the sidecar file are nil.
+type sidecarMetadata struct {
+	Format     *string  `json:"format"`
+	Width      *int     `json:"width"`
+	Height     *int     `json:"height"`
+	Duration   *float64 `json:"duration"`
+	VideoCodec *string  `json:"video_codec"`
+	AudioCodec *string  `json:"audio_codec"`
+	FrameRate  *float64 `json:"frame_rate"`
+	BitRate    *int64   `json:"bitrate"`
+}
+
+// nfoStreamDetails is the stream details section of a Kodi nfo file.
+// The name of the root element is not checked.
+type nfoStreamDetails struct {
+	Video struct {
+		Codec    *string  `xml:"codec"`
+		Width    *int     `xml:"width"`
+		Height   *int     `xml:"height"`
+		Duration *float64 `xml:"durationinseconds"`
+	} `xml:"fileinfo>streamdetails>video"`
+	Audio struct {
+		Codec *string `xml:"codec"`
+	} `xml:"fileinfo>streamdetails>audio"`
+}
+
+func (d nfoStreamDetails) metadata() sidecarMetadata {
+	return sidecarMetadata{
+		VideoCodec: d.Video.Codec,
+		Width:      d.Video.Width,
+		Height:     d.Video.Height,
+		Duration:   d.Video.Duration,
+		AudioCodec: d.Audio.Codec,
+	}
+}
+
+// SidecarDecorator is a Decorator that applies the metadata in a sidecar file
+// to video and image files. The sidecar file has the same path as the file,
+// with the extension replaced by one of Extensions.
+//
+// Sidecar files with the json extension contain a JSON object, with the same
+// field names as exported video files, such as width, height and video_codec.
+// Sidecar files with the nfo or xml extension are Kodi nfo files, and only their
+// stream details are applied.
+//
+// Fields that are not present in the sidecar file are not changed. As decorators
+// that create the file type, such as the video decorator, replace the file
+// fields, SidecarDecorator should be listed after them.
+type SidecarDecorator struct {
+	// Extensions are the extensions of sidecar files, in priority order.
+	// Extension does not include the . character.
+	Extensions []string
+}
+
+// sidecarPath returns the path of the first sidecar file that exists for the
+// provided path. Returns an empty string if there is none.
+func (d *SidecarDecorator) sidecarPath(fs models.FS, path string) string {
+	fn := strings.TrimSuffix(path, filepath.Ext(path))
+	for _, ext := range d.Extensions {
+		p := fn + "." + ext
+		if p == path {
+			continue
+		}
+
+		if _, err := fs.Lstat(p); err == nil {
+			return p
+		}
+	}
+
+	return ""
+}
+
+func (d *SidecarDecorator) readSidecar(fs models.FS, path string) (*sidecarMetadata, error) {
+	f, err := fs.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		var ret sidecarMetadata
+		if err := json.Unmarshal(data, &ret); err != nil {
+			return nil, err
+		}
+		return &ret, nil
+	case ".nfo", ".xml":
+		var details nfoStreamDetails
+		if err := xml.Unmarshal(data, &details); err != nil {
+			return nil, err
+		}
+		ret := details.metadata()
+		return &ret, nil
+	default:
+		return nil, errors.New("unsupported sidecar file type")
+	}
+}
+
+// metadata returns the metadata in the sidecar file for f.
+// Returns nil if there is no sidecar file.
+func (d *SidecarDecorator) metadata(fs models.FS, f models.File) (*sidecarMetadata, error) {
+	// sidecar files within zip files are not supported
+	if f.Base().ZipFileID != nil {
+		return nil, nil
+	}
+
+	p := d.sidecarPath(fs, f.Base().Path)
+	if p == "" {
+		return nil, nil
+	}
+
+	m, err := d.readSidecar(fs, p)
+	if err != nil {
+		return nil, fmt.Errorf("reading sidecar file %q: %w", p, err)
+	}
+
+	return m, nil
+}
+
+func (d *SidecarDecorator) Decorate(ctx context.Context, fs models.FS, f models.File) (models.File, error) {
+	m, err := d.metadata(fs, f)
+	if err != nil || m == nil {
+		return f, err
+	}
+
+	switch f := f.(type) {
+	case *models.VideoFile:
+		setIfPresent(&f.Format, m.Format)
+		setIfPresent(&f.Width, m.Width)
+		setIfPresent(&f.Height, m.Height)
+		setIfPresent(&f.Duration, m.Duration)
+		setIfPresent(&f.VideoCodec, m.VideoCodec)
+		setIfPresent(&f.AudioCodec, m.AudioCodec)
+		setIfPresent(&f.FrameRate, m.FrameRate)
+		setIfPresent(&f.BitRate, m.BitRate)
+	case *models.ImageFile:
+		setIfPresent(&f.Format, m.Format)
+		setIfPresent(&f.Width, m.Width)
+		setIfPresent(&f.Height, m.Height)
+	}
+
+	return f, nil
+}
+
+// IsMissingMetadata returns true if the file has a sidecar file with values
+// that have not been applied to the file. Returns false if there is no sidecar
+// file, or if it cannot be read.
+func (d *SidecarDecorator) IsMissingMetadata(ctx context.Context, fs models.FS, f models.File) bool {
+	m, err := d.metadata(fs, f)
+	if err != nil || m == nil {
+		return false
+	}
+
+	switch f := f.(type) {
+	case *models.VideoFile:
+		return differs(f.Format, m.Format) || differs(f.Width, m.Width) ||
+			differs(f.Height, m.Height) || differs(f.Duration, m.Duration) ||
+			differs(f.VideoCodec, m.VideoCodec) || differs(f.AudioCodec, m.AudioCodec) ||
+			differs(f.FrameRate, m.FrameRate) || differs(f.BitRate, m.BitRate)
+	case *models.ImageFile:
+		return differs(f.Format, m.Format) || differs(f.Width, m.Width) ||
+			differs(f.Height, m.Height)
+	}
+
+	return false
+}
+
+func setIfPresent[T any](dest *T, v *T) {
+	if v != nil {
+		*dest = *v
+	}
+}
+
+func differs[T comparable](current T, v *T) bool {
+	return v != nil && current != *v
+}
@@ -0,0 +1,97 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSidecarDecorator(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFile := func(name string, contents string) string {
+		t.Helper()
+
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	withJSON := writeFile("a.mp4", "")
+	writeFile("a.json", `{"width": 1920, "height": 1080, "video_codec": "h264", "duration": 12.5}`)
+
+	withNfo := writeFile("b.mp4", "")
+	writeFile("b.nfo", `<movie>
+  <title>Title</title>
+  <fileinfo>
+    <streamdetails>
+      <video><codec>hevc</codec><width>3840</width><height>2160</height></video>
+      <audio><codec>aac</codec></audio>
+    </streamdetails>
+  </fileinfo>
+</movie>`)
+
+	without := writeFile("c.mp4", "")
+
+	newFile := func(path string) *models.VideoFile {
+		return &models.VideoFile{
+			BaseFile:   &models.BaseFile{Path: path},
+			Format:     "mp4",
+			Width:      640,
+			Height:     480,
+			VideoCodec: "mpeg4",
+			AudioCodec: "mp3",
+			Duration:   10,
+		}
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		want        func(f *models.VideoFile)
+		wantMissing bool
+	}{
+		{"json", withJSON, func(f *models.VideoFile) {
+			f.Width = 1920
+			f.Height = 1080
+			f.VideoCodec = "h264"
+			f.Duration = 12.5
+		}, true},
+		{"nfo", withNfo, func(f *models.VideoFile) {
+			f.Width = 3840
+			f.Height = 2160
+			f.VideoCodec = "hevc"
+			f.AudioCodec = "aac"
+		}, true},
+		{"no sidecar", without, func(f *models.VideoFile) {}, false},
+	}
+
+	d := &SidecarDecorator{Extensions: []string{"json", "nfo"}}
+	fs := &OsFS{}
+	ctx := context.Background()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFile(tt.path)
+			assert.Equal(t, tt.wantMissing, d.IsMissingMetadata(ctx, fs, f))
+
+			got, err := d.Decorate(ctx, fs, f)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			want := newFile(tt.path)
+			tt.want(want)
+			assert.Equal(t, want, got)
+
+			// metadata is no longer missing once applied
+			assert.False(t, d.IsMissingMetadata(ctx, fs, got))
+		})
+	}
+}
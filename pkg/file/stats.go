@@ -0,0 +1,46 @@
+package file
+
+import (
+	"sync"
+)
+
+// ScanError is an error encountered while scanning a specific path.
+type ScanError struct {
+	Path string
+	Err  error
+}
+
+func (e ScanError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e ScanError) Unwrap() error {
+	return e.Err
+}
+
+// ScanStats collects statistics during a scan. It is safe for concurrent use.
+type ScanStats struct {
+	mutex  sync.Mutex
+	errors []ScanError
+}
+
+// AddError records an error for the provided path.
+func (s *ScanStats) AddError(path string, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.errors = append(s.errors, ScanError{
+		Path: path,
+		Err:  err,
+	})
+}
+
+// Errors returns the errors recorded during the scan.
+func (s *ScanStats) Errors() []ScanError {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ret := make([]ScanError, len(s.errors))
+	copy(ret, s.errors)
+	return ret
+}
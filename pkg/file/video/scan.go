@@ -10,6 +10,11 @@ import (
 	"github.com/stashapp/stash/pkg/models"
 )
 
+// metadataVersion is the version of the metadata set by Decorator. It is
+// recorded on decorated files, and should be incremented when the metadata
+// set by Decorator changes, so that existing files can be decorated again.
+const metadataVersion = 1
+
 // Decorator adds video specific fields to a File.
 type Decorator struct {
 	FFProbe *ffmpeg.FFProbe
@@ -43,6 +48,8 @@ func (d *Decorator) Decorate(ctx context.Context, fs models.FS, f models.File) (
 		interactive = true
 	}
 
+	base.MetadataVersion = metadataVersion
+
 	return &models.VideoFile{
 		BaseFile:    base,
 		Format:      string(container),
@@ -79,3 +86,14 @@ func (d *Decorator) IsMissingMetadata(ctx context.Context, fs models.FS, f model
 		vf.Duration == unsetNumber ||
 		vf.BitRate == unsetNumber || interactive != vf.Interactive
 }
+
+// MetadataVersion returns the metadata version recorded on f. Returns false if
+// f is not a video file.
+func (d *Decorator) MetadataVersion(ctx context.Context, fs models.FS, f models.File) (int, bool) {
+	vf, ok := f.(*models.VideoFile)
+	if !ok {
+		return 0, false
+	}
+
+	return vf.MetadataVersion, true
+}
@@ -0,0 +1,76 @@
+//go:build windows
+
+package file
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSymWalkSkipJunctions(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sub, "a.mp4"), []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the junction points into the scanned tree, so following it scans sub twice
+	junction := filepath.Join(root, "junction")
+	if out, err := exec.Command("cmd", "/c", "mklink", "/J", junction, sub).CombinedOutput(); err != nil {
+		t.Fatalf("creating junction: %v: %s", err, out)
+	}
+
+	walk := func(s *Scanner) []string {
+		t.Helper()
+
+		var files []string
+		err := SymWalk(s.FS, root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+
+			if !s.AcceptEntry(context.Background(), path, info) {
+				if info.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+
+			if !info.IsDir() {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return files
+	}
+
+	s := &Scanner{
+		FS: &OsFS{},
+	}
+
+	assert.ElementsMatch(t, []string{
+		filepath.Join(junction, "a.mp4"),
+		filepath.Join(sub, "a.mp4"),
+	}, walk(s))
+
+	s.SkipJunctions = true
+	assert.Equal(t, []string{filepath.Join(sub, "a.mp4")}, walk(s))
+}
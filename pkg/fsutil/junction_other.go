@@ -0,0 +1,12 @@
+//go:build !windows
+
+package fsutil
+
+import "io/fs"
+
+// IsJunction returns true if info describes a directory reparse point other than a
+// symbolic link, such as a directory junction or a volume mount point.
+// Reparse points only exist on Windows, so this always returns false.
+func IsJunction(info fs.FileInfo) bool {
+	return false
+}
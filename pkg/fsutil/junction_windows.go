@@ -0,0 +1,24 @@
+//go:build windows
+
+package fsutil
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// IsJunction returns true if info describes a directory reparse point other than a
+// symbolic link, such as a directory junction or a volume mount point.
+func IsJunction(info fs.FileInfo) bool {
+	if info.Mode()&fs.ModeSymlink != 0 {
+		return false
+	}
+
+	attrs, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return false
+	}
+
+	const junction = syscall.FILE_ATTRIBUTE_DIRECTORY | syscall.FILE_ATTRIBUTE_REPARSE_POINT
+	return attrs.FileAttributes&junction == junction
+}
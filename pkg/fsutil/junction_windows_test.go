@@ -0,0 +1,57 @@
+//go:build windows
+
+package fsutil
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsJunction(t *testing.T) {
+	root := t.TempDir()
+	target := filepath.Join(root, "target")
+	junction := filepath.Join(root, "junction")
+	symlink := filepath.Join(root, "symlink")
+
+	if err := os.Mkdir(target, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if out, err := exec.Command("cmd", "/c", "mklink", "/J", junction, target).CombinedOutput(); err != nil {
+		t.Fatalf("creating junction: %v: %s", err, out)
+	}
+
+	lstat := func(path string) os.FileInfo {
+		t.Helper()
+		info, err := os.Lstat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info
+	}
+
+	assert.True(t, IsJunction(lstat(junction)))
+	assert.False(t, IsJunction(lstat(target)))
+
+	// entries read from the parent directory are also detected
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, e.Name() == "junction", IsJunction(info), e.Name())
+	}
+
+	// creating symlinks requires developer mode or elevation
+	if err := os.Symlink(target, symlink); err == nil {
+		assert.False(t, IsJunction(lstat(symlink)))
+	}
+}
@@ -0,0 +1,57 @@
+package imagephash
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/corona10/goimagehash"
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	_ "golang.org/x/image/webp"
+)
+
+// Calculator is a file.FingerprintCalculator that calculates a perceptual hash
+// of image files. Visually similar images, such as resized copies, have equal or
+// close hashes. It should be registered in Scanner.FingerprintCalculators with a
+// filter that only accepts image files.
+//
+// Images that cannot be decoded, such as AVIF images, are given no phash
+// fingerprint rather than failing the scan.
+type Calculator struct{}
+
+func (c *Calculator) CalculateFingerprints(f *models.BaseFile, o file.Opener, useExisting bool) ([]models.Fingerprint, error) {
+	if useExisting {
+		if fp := f.Fingerprints.For(models.FingerprintTypePhash); fp != nil {
+			return []models.Fingerprint{*fp}, nil
+		}
+	}
+
+	r, err := o.Open()
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+
+	defer r.Close()
+
+	img, _, err := image.Decode(r)
+	if err != nil {
+		logger.Warnf("Not calculating phash for %q: decoding image: %v", f.Path, err)
+		return nil, nil
+	}
+
+	hash, err := goimagehash.PerceptionHash(img)
+	if err != nil {
+		return nil, fmt.Errorf("calculating %s: %w", models.FingerprintTypePhash, err)
+	}
+
+	return []models.Fingerprint{
+		{
+			Type:        models.FingerprintTypePhash,
+			Fingerprint: int64(hash.GetHash()),
+		},
+	}, nil
+}
@@ -0,0 +1,115 @@
+package imagephash
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"testing"
+
+	"github.com/corona10/goimagehash"
+	"github.com/disintegration/imaging"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type bytesOpener []byte
+
+func (o bytesOpener) Open() (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(o)), nil
+}
+
+// testImage returns an image with a gradient and a few shapes, so that it has
+// enough structure to produce a meaningful perceptual hash.
+func testImage(size int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			c := color.RGBA{uint8(x * 255 / size), uint8(y * 255 / size), 128, 255}
+			switch {
+			case x > size/8 && x < size/3 && y > size/8 && y < size/2:
+				c = color.RGBA{255, 255, 255, 255}
+			case (x-size*2/3)*(x-size*2/3)+(y-size*2/3)*(y-size*2/3) < size*size/25:
+				c = color.RGBA{0, 0, 0, 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) bytesOpener {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func calculatePhash(t *testing.T, o bytesOpener) uint64 {
+	t.Helper()
+
+	c := &Calculator{}
+	fp, err := c.CalculateFingerprints(&models.BaseFile{Path: "image.png"}, o, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !assert.Len(t, fp, 1) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, models.FingerprintTypePhash, fp[0].Type)
+	return uint64(fp[0].Fingerprint.(int64))
+}
+
+func TestCalculator_resized(t *testing.T) {
+	original := testImage(512)
+	resized := imaging.Resize(original, 200, 200, imaging.Lanczos)
+
+	originalHash := calculatePhash(t, encodePNG(t, original))
+	resizedHash := calculatePhash(t, encodePNG(t, resized))
+
+	distance, err := goimagehash.NewImageHash(originalHash, goimagehash.PHash).Distance(goimagehash.NewImageHash(resizedHash, goimagehash.PHash))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.LessOrEqual(t, distance, 2, "resized image should have a close phash")
+
+	// a different image should not be close
+	flipped := imaging.FlipH(imaging.FlipV(original))
+	flippedHash := calculatePhash(t, encodePNG(t, flipped))
+
+	distance, err = goimagehash.NewImageHash(originalHash, goimagehash.PHash).Distance(goimagehash.NewImageHash(flippedHash, goimagehash.PHash))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Greater(t, distance, 10, "different image should not have a close phash")
+}
+
+func TestCalculator_useExisting(t *testing.T) {
+	existing := models.Fingerprint{Type: models.FingerprintTypePhash, Fingerprint: int64(1234)}
+
+	c := &Calculator{}
+	fp, err := c.CalculateFingerprints(&models.BaseFile{
+		Fingerprints: models.Fingerprints{existing},
+	}, bytesOpener("not read"), true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Fingerprint{existing}, fp)
+}
+
+func TestCalculator_invalidImage(t *testing.T) {
+	c := &Calculator{}
+	fp, err := c.CalculateFingerprints(&models.BaseFile{Path: "image.avif"}, bytesOpener("not an image"), false)
+
+	assert.NoError(t, err)
+	assert.Empty(t, fp)
+}
@@ -4,6 +4,7 @@ package md5
 import (
 	"crypto/md5"
 	"fmt"
+	"hash"
 	"io"
 	"os"
 )
@@ -42,3 +43,18 @@ func FromReader(src io.Reader) (string, error) {
 	checksum := h.Sum(nil)
 	return fmt.Sprintf("%x", checksum), nil
 }
+
+// Writer calculates an MD5 checksum of the data written to it.
+type Writer struct {
+	hash.Hash
+}
+
+// NewWriter returns a new Writer.
+func NewWriter() *Writer {
+	return &Writer{Hash: md5.New()}
+}
+
+// Checksum returns the MD5 checksum string of the data written so far.
+func (w *Writer) Checksum() string {
+	return fmt.Sprintf("%x", w.Sum(nil))
+}
@@ -46,18 +46,33 @@ func oshash(size int64, head []byte, tail []byte) (string, error) {
 	return fmt.Sprintf("%016x", result), nil
 }
 
-// FromReader calculates the hash reading from src.
-func FromReader(src io.ReadSeeker, fileSize int64) (string, error) {
+// fileChunkSize returns the number of bytes hashed from each of the head
+// and tail of a file of the provided size.
+func fileChunkSize(fileSize int64) int64 {
+	if fileSize < chunkSize {
+		// Must be a multiple of 8.
+		return (fileSize / 8) * 8
+	}
+
+	return chunkSize
+}
+
+func checkFileSize(fileSize int64) error {
 	if fileSize <= 8 {
-		return "", fmt.Errorf("cannot calculate oshash where size < 8 (%d)", fileSize)
+		return fmt.Errorf("cannot calculate oshash where size < 8 (%d)", fileSize)
 	}
 
-	fileChunkSize := chunkSize
-	if fileSize < fileChunkSize {
-		// Must be a multiple of 8.
-		fileChunkSize = (fileSize / 8) * 8
+	return nil
+}
+
+// FromReader calculates the hash reading from src.
+func FromReader(src io.ReadSeeker, fileSize int64) (string, error) {
+	if err := checkFileSize(fileSize); err != nil {
+		return "", err
 	}
 
+	fileChunkSize := fileChunkSize(fileSize)
+
 	head := make([]byte, fileChunkSize)
 	tail := make([]byte, fileChunkSize)
 
@@ -99,3 +114,61 @@ func FromFilePath(filePath string) (string, error) {
 
 	return FromReader(f, fileSize)
 }
+
+// Writer calculates the hash of a file from its contents, which are written to
+// it in order. This allows the hash to be calculated while the file is read for
+// another purpose, such as calculating an MD5 checksum, rather than reading the
+// head and tail of the file separately.
+type Writer struct {
+	fileSize  int64
+	head      []byte
+	tail      []byte
+	tailStart int64
+	written   int64
+}
+
+// NewWriter returns a Writer for a file of the provided size.
+func NewWriter(fileSize int64) *Writer {
+	n := max(fileChunkSize(fileSize), 0)
+
+	return &Writer{
+		fileSize:  fileSize,
+		head:      make([]byte, n),
+		tail:      make([]byte, n),
+		tailStart: fileSize - n,
+	}
+}
+
+// Write copies the parts of p that fall within the head or tail of the file.
+// It never returns an error.
+func (w *Writer) Write(p []byte) (int, error) {
+	start := w.written
+	end := start + int64(len(p))
+
+	headLen := int64(len(w.head))
+	if start < headLen {
+		copy(w.head[start:], p)
+	}
+
+	if end > w.tailStart && start < w.fileSize {
+		from := max(w.tailStart-start, 0)
+		copy(w.tail[start+from-w.tailStart:], p[from:])
+	}
+
+	w.written = end
+	return len(p), nil
+}
+
+// Sum returns the hash of the file. Returns an error if fewer bytes than the
+// file size have been written.
+func (w *Writer) Sum() (string, error) {
+	if err := checkFileSize(w.fileSize); err != nil {
+		return "", err
+	}
+
+	if w.written < w.fileSize {
+		return "", io.ErrUnexpectedEOF
+	}
+
+	return oshash(w.fileSize, w.head, w.tail)
+}
@@ -34,7 +34,14 @@ func BenchmarkOsHash(b *testing.B) {
 	}
 }
 
-func TestFromReader(t *testing.T) {
+type oshashTest struct {
+	name    string
+	data    []byte
+	want    string
+	wantErr bool
+}
+
+func oshashTests() []oshashTest {
 	makeByteArray := func(base []byte, mag int) []byte {
 		ret := base
 		for i := 0; i < mag; i++ {
@@ -51,12 +58,7 @@ func TestFromReader(t *testing.T) {
 		return ret
 	}
 
-	tests := []struct {
-		name    string
-		data    []byte
-		want    string
-		wantErr bool
-	}{
+	return []oshashTest{
 		{
 			"empty",
 			[]byte{},
@@ -94,7 +96,10 @@ func TestFromReader(t *testing.T) {
 			false,
 		},
 	}
-	for _, tt := range tests {
+}
+
+func TestFromReader(t *testing.T) {
+	for _, tt := range oshashTests() {
 		t.Run(tt.name, func(t *testing.T) {
 			r := bytes.NewReader(tt.data)
 
@@ -109,3 +114,43 @@ func TestFromReader(t *testing.T) {
 		})
 	}
 }
+
+func TestWriter(t *testing.T) {
+	// write in small, uneven pieces so that the head and tail span writes
+	const writeSize = 1000
+
+	for _, tt := range oshashTests() {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWriter(int64(len(tt.data)))
+
+			for data := tt.data; len(data) > 0; {
+				n := min(writeSize, len(data))
+				if _, err := w.Write(data[:n]); err != nil {
+					t.Errorf("Writer.Write() error = %v", err)
+					return
+				}
+				data = data[n:]
+			}
+
+			got, err := w.Sum()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Writer.Sum() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Writer.Sum() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriterShort(t *testing.T) {
+	w := NewWriter(100)
+	if _, err := w.Write(make([]byte, 50)); err != nil {
+		t.Fatalf("Writer.Write() error = %v", err)
+	}
+
+	if _, err := w.Sum(); err == nil {
+		t.Error("Writer.Sum() expected error for short write")
+	}
+}
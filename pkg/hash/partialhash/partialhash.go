@@ -0,0 +1,168 @@
+// Package partialhash implements a fast content hash for large files.
+//
+// Calculation is as follows:
+// SHA-256 of the file size, followed by the first and last sampleSize bytes
+// of the file. Files no larger than twice the sample size are hashed in full.
+package partialhash
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+)
+
+// DefaultSampleSize is the default number of bytes sampled from the start and
+// end of the file.
+const DefaultSampleSize int64 = 4 * 1024 * 1024
+
+var ErrInvalidSampleSize = errors.New("sample size must be positive")
+
+// newHash returns a SHA-256 hash to which the file size has been written.
+func newHash(fileSize int64) hash.Hash {
+	h := sha256.New()
+
+	var sizeBuf [8]byte
+	binary.LittleEndian.PutUint64(sizeBuf[:], uint64(fileSize))
+	h.Write(sizeBuf[:])
+
+	return h
+}
+
+// FromReader calculates the hash reading from src, sampling sampleSize bytes
+// from the start and end of the file. If src is not an io.Seeker, the middle
+// of the file is read and discarded.
+func FromReader(src io.Reader, fileSize int64, sampleSize int64) (string, error) {
+	if sampleSize <= 0 {
+		return "", ErrInvalidSampleSize
+	}
+
+	h := newHash(fileSize)
+
+	if fileSize <= 2*sampleSize {
+		// hash the whole file
+		if _, err := io.Copy(h, src); err != nil {
+			return "", fmt.Errorf("reading file: %w", err)
+		}
+
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	// read the head of the file
+	if _, err := io.CopyN(h, src, sampleSize); err != nil {
+		return "", fmt.Errorf("reading head: %w", err)
+	}
+
+	// seek to the end of the file - the sample size
+	if seeker, ok := src.(io.Seeker); ok {
+		if _, err := seeker.Seek(-sampleSize, io.SeekEnd); err != nil {
+			return "", err
+		}
+	} else if _, err := io.CopyN(io.Discard, src, fileSize-2*sampleSize); err != nil {
+		return "", fmt.Errorf("skipping to tail: %w", err)
+	}
+
+	// read the tail of the file
+	if _, err := io.CopyN(h, src, sampleSize); err != nil {
+		return "", fmt.Errorf("reading tail: %w", err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// FromFilePath calculates the hash of the file at filePath. The hash is the
+// SHA-256 of the file size, followed by the first and last sampleSize bytes
+// of the file. Files no larger than 2*sampleSize are hashed in full, after
+// the size. The size is taken from the opened file.
+func FromFilePath(filePath string, sampleSize int64) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	return FromReader(f, fi.Size(), sampleSize)
+}
+
+// Writer calculates the hash of a file from its contents, which are written to
+// it in order. This allows the hash to be calculated while the file is read for
+// another purpose, such as calculating an MD5 checksum, rather than reading the
+// start and end of the file separately. The end of the file is buffered, so a
+// Writer holds up to sampleSize bytes.
+type Writer struct {
+	h          hash.Hash
+	fileSize   int64
+	sampleSize int64
+	tail       []byte
+	tailStart  int64
+	written    int64
+}
+
+// NewWriter returns a Writer for a file of the provided size, sampling
+// sampleSize bytes from the start and end of the file.
+func NewWriter(fileSize int64, sampleSize int64) (*Writer, error) {
+	if sampleSize <= 0 {
+		return nil, ErrInvalidSampleSize
+	}
+
+	w := &Writer{
+		h:          newHash(fileSize),
+		fileSize:   fileSize,
+		sampleSize: sampleSize,
+	}
+
+	if fileSize > 2*sampleSize {
+		w.tail = make([]byte, sampleSize)
+		w.tailStart = fileSize - sampleSize
+	}
+
+	return w, nil
+}
+
+// Write hashes the parts of p that fall within the start of the file, and
+// buffers the parts that fall within the end of the file. Files no larger than
+// twice the sample size are hashed in full. It never returns an error.
+func (w *Writer) Write(p []byte) (int, error) {
+	start := w.written
+	end := start + int64(len(p))
+	w.written = end
+
+	if w.tail == nil {
+		w.h.Write(p)
+		return len(p), nil
+	}
+
+	if start < w.sampleSize {
+		w.h.Write(p[:min(end, w.sampleSize)-start])
+	}
+
+	if end > w.tailStart && start < w.fileSize {
+		from := max(w.tailStart-start, 0)
+		copy(w.tail[start+from-w.tailStart:], p[from:])
+	}
+
+	return len(p), nil
+}
+
+// Sum returns the hash of the file. Returns an error if fewer bytes than the
+// file size have been written. Sum must only be called once.
+func (w *Writer) Sum() (string, error) {
+	if w.written < w.fileSize {
+		return "", io.ErrUnexpectedEOF
+	}
+
+	if w.tail != nil {
+		w.h.Write(w.tail)
+	}
+
+	return hex.EncodeToString(w.h.Sum(nil)), nil
+}
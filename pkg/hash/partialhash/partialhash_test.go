@@ -0,0 +1,105 @@
+package partialhash
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// readerOnly hides the Seek method of the underlying reader.
+type readerOnly struct {
+	io.Reader
+}
+
+func TestFromReader(t *testing.T) {
+	const sampleSize = 16
+
+	makeData := func(size int, middle byte) []byte {
+		ret := bytes.Repeat([]byte{'a'}, size)
+		ret[size/2] = middle
+		return ret
+	}
+
+	hash := func(data []byte) string {
+		t.Helper()
+		ret, err := FromReader(bytes.NewReader(data), int64(len(data)), sampleSize)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return ret
+	}
+
+	// large files that differ only in the middle have the same hash
+	large := makeData(100, 'b')
+	assert.Equal(t, hash(large), hash(makeData(100, 'c')))
+
+	// files that differ in the sampled regions have different hashes
+	head := makeData(100, 'b')
+	head[0] = 'z'
+	assert.NotEqual(t, hash(large), hash(head))
+
+	tail := makeData(100, 'b')
+	tail[len(tail)-1] = 'z'
+	assert.NotEqual(t, hash(large), hash(tail))
+
+	// files of a different size have different hashes
+	assert.NotEqual(t, hash(large), hash(makeData(101, 'b')))
+
+	// small files are hashed in full
+	assert.NotEqual(t, hash(makeData(2*sampleSize, 'b')), hash(makeData(2*sampleSize, 'c')))
+
+	// non-seekable readers produce the same hash
+	got, err := FromReader(readerOnly{bytes.NewReader(large)}, int64(len(large)), sampleSize)
+	if assert.NoError(t, err) {
+		assert.Equal(t, hash(large), got)
+	}
+
+	_, err = FromReader(bytes.NewReader(large), int64(len(large)), 0)
+	assert.ErrorIs(t, err, ErrInvalidSampleSize)
+}
+
+func TestWriter(t *testing.T) {
+	const sampleSize = 16
+
+	for _, size := range []int{0, 10, 2 * sampleSize, 2*sampleSize + 1, 100} {
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = byte(i)
+		}
+
+		want, err := FromReader(bytes.NewReader(data), int64(size), sampleSize)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		w, err := NewWriter(int64(size), sampleSize)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// write in small, uneven pieces so that the start and end span writes
+		for p := data; len(p) > 0; {
+			n := min(7, len(p))
+			_, _ = w.Write(p[:n])
+			p = p[n:]
+		}
+
+		got, err := w.Sum()
+		if assert.NoError(t, err, "size %d", size) {
+			assert.Equal(t, want, got, "size %d", size)
+		}
+	}
+
+	// the whole file must be written
+	w, err := NewWriter(100, sampleSize)
+	if assert.NoError(t, err) {
+		_, _ = w.Write(make([]byte, 50))
+		_, err = w.Sum()
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	}
+
+	_, err = NewWriter(100, 0)
+	assert.ErrorIs(t, err, ErrInvalidSampleSize)
+}
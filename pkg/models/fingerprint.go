@@ -9,6 +9,13 @@ var (
 	FingerprintTypeOshash = "oshash"
 	FingerprintTypeMD5    = "md5"
 	FingerprintTypePhash  = "phash"
+
+	// FingerprintTypePartialSHA256 is a SHA-256 hash of the start and end of the file.
+	FingerprintTypePartialSHA256 = "partial_sha256"
+
+	// FingerprintTypePathSize is the path and size of the file. It is used in place
+	// of content hashes for files where hashing the contents is not useful.
+	FingerprintTypePathSize = "path_size"
 )
 
 // Fingerprint represents a fingerprint of a file.
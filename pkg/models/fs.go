@@ -19,6 +19,13 @@ type FS interface {
 	IsPathCaseSensitive(path string) (bool, error)
 }
 
+// JunctionFS is implemented by file systems that can detect directory junctions.
+type JunctionFS interface {
+	// IsJunction returns true if info describes a directory reparse point other
+	// than a symbolic link, such as a Windows directory junction or volume mount point.
+	IsJunction(info fs.FileInfo) bool
+}
+
 // ZipFS represents a zip file system.
 type ZipFS interface {
 	FS
@@ -155,6 +155,29 @@ func (_m *FolderReaderWriter) FindByPath(ctx context.Context, path string, caseS
 	return r0, r1
 }
 
+// FindByPaths provides a mock function with given fields: ctx, paths
+func (_m *FolderReaderWriter) FindByPaths(ctx context.Context, paths []string) ([]*models.Folder, error) {
+	ret := _m.Called(ctx, paths)
+
+	var r0 []*models.Folder
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []*models.Folder); ok {
+		r0 = rf(ctx, paths)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Folder)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, paths)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindByZipFileID provides a mock function with given fields: ctx, zipFileID
 func (_m *FolderReaderWriter) FindByZipFileID(ctx context.Context, zipFileID models.FileID) ([]*models.Folder, error) {
 	ret := _m.Called(ctx, zipFileID)
@@ -138,6 +138,14 @@ type BaseFile struct {
 
 	Size int64 `json:"size"`
 
+	// MimeType is the MIME type of the file, detected from its contents.
+	// Empty if it has not been detected.
+	MimeType string `json:"mime_type,omitempty"`
+
+	// MetadataVersion is the version of the decorator that set the metadata
+	// of the file. Zero if the file was decorated before versions were recorded.
+	MetadataVersion int `json:"metadata_version,omitempty"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
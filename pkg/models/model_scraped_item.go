@@ -191,6 +191,11 @@ type ScrapedPerformer struct {
 	RemoteSiteID       *string  `json:"remote_site_id"`
 	RemoteDeleted      bool     `json:"remote_deleted"`
 	RemoteMergedIntoId *string  `json:"remote_merged_into_id"`
+
+	CustomFields map[string]interface{} `json:"custom_fields"`
+
+	// Scenes of the performer, such as those listed on the performer's page.
+	Scenes []*ScrapedScene `json:"scenes"`
 }
 
 func (ScrapedPerformer) IsScrapedContent() {}
@@ -547,6 +552,9 @@ type ScrapedGroup struct {
 	FrontImage *string `json:"front_image"`
 	// This should be a base64 encoded data URL
 	BackImage *string `json:"back_image"`
+	// SceneIndex is the index of the scene within the group, such as an
+	// episode number. Only set for the groups of a scraped scene.
+	SceneIndex *int `json:"scene_index"`
 }
 
 func (ScrapedGroup) IsScrapedContent() {}
@@ -587,17 +595,26 @@ type ScrapedScene struct {
 	Image        *string                `json:"image"`
 	File         *SceneFileType         `json:"file"`
 	Studio       *ScrapedStudio         `json:"studio"`
+	Studios      []*ScrapedStudio       `json:"studios"`
 	Tags         []*ScrapedTag          `json:"tags"`
 	Performers   []*ScrapedPerformer    `json:"performers"`
 	Groups       []*ScrapedGroup        `json:"groups"`
 	Movies       []*ScrapedMovie        `json:"movies"`
+	Markers      []*ScrapedSceneMarker  `json:"markers"`
 	RemoteSiteID *string                `json:"remote_site_id"`
 	Duration     *int                   `json:"duration"`
 	Fingerprints []*StashBoxFingerprint `json:"fingerprints"`
+	CustomFields map[string]interface{} `json:"custom_fields"`
 }
 
 func (ScrapedScene) IsScrapedContent() {}
 
+type ScrapedSceneMarker struct {
+	Title   string      `json:"title"`
+	Seconds float64     `json:"seconds"`
+	Tag     *ScrapedTag `json:"tag"`
+}
+
 type ScrapedSceneInput struct {
 	Title        *string  `json:"title"`
 	Code         *string  `json:"code"`
@@ -641,6 +658,8 @@ type ScrapedGallery struct {
 	Studio       *ScrapedStudio      `json:"studio"`
 	Tags         []*ScrapedTag       `json:"tags"`
 	Performers   []*ScrapedPerformer `json:"performers"`
+	// Images contains the urls of the images in the gallery
+	Images []string `json:"images"`
 
 	// deprecated
 	URL *string `json:"url"`
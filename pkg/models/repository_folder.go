@@ -13,6 +13,10 @@ type FolderFinder interface {
 	FolderGetter
 	FindAllInPaths(ctx context.Context, p []string, limit, offset int) ([]*Folder, error)
 	FindByPath(ctx context.Context, path string, caseSensitive bool) (*Folder, error)
+	// FindByPaths returns the folders with the provided paths, matched case-sensitively.
+	// Paths without a folder are ignored, and the order of the returned folders is
+	// not guaranteed to be the same as the order of the paths.
+	FindByPaths(ctx context.Context, paths []string) ([]*Folder, error)
 	FindByZipFileID(ctx context.Context, zipFileID FileID) ([]*Folder, error)
 	FindByParentFolderID(ctx context.Context, parentFolderID FolderID) ([]*Folder, error)
 }
@@ -14,21 +14,43 @@ const (
 	scraperActionStash  scraperAction = "stash"
 	scraperActionXPath  scraperAction = "scrapeXPath"
 	scraperActionJson   scraperAction = "scrapeJson"
+	scraperActionXML    scraperAction = "scrapeXML"
+
+	// scraperActionComposite runs a list of xpath and json scrapers against
+	// the same page, and merges their results. Only supported by url scrapers.
+	scraperActionComposite scraperAction = "scrapeComposite"
 )
 
 func (e scraperAction) IsValid() bool {
 	switch e {
-	case scraperActionScript, scraperActionStash, scraperActionXPath, scraperActionJson:
+	case scraperActionScript, scraperActionStash, scraperActionXPath, scraperActionJson, scraperActionXML, scraperActionComposite:
 		return true
 	}
 	return false
 }
 
+// httpClient returns the client to use for requests made by the scraper.
+func (c Definition) httpClient(client *http.Client, globalConfig GlobalConfig) *http.Client {
+	if c.SkipTLSVerify {
+		return clientWithoutTLSVerify(client, globalConfig)
+	}
+
+	return client
+}
+
 type urlScraperActionImpl interface {
 	scrapeByURL(ctx context.Context, url string, ty ScrapeContentType) (ScrapedContent, error)
 }
 
+// multiURLScraperActionImpl is implemented by url scrapers which can return
+// multiple results from a single URL.
+type multiURLScraperActionImpl interface {
+	scrapeByURLMulti(ctx context.Context, url string, ty ScrapeContentType) ([]ScrapedContent, error)
+}
+
 func (c Definition) getURLScraper(def ByURLDefinition, client *http.Client, globalConfig GlobalConfig) urlScraperActionImpl {
+	client = c.httpClient(client, globalConfig)
+
 	switch def.Action {
 	case scraperActionScript:
 		return &scriptURLScraper{
@@ -58,6 +80,22 @@ func (c Definition) getURLScraper(def ByURLDefinition, client *http.Client, glob
 			},
 			definition: def,
 		}
+	case scraperActionXML:
+		return &xmlURLScraper{
+			xmlScraper: xmlScraper{
+				definition:   c,
+				globalConfig: globalConfig,
+				client:       client,
+			},
+			definition: def,
+		}
+	case scraperActionComposite:
+		return &compositeURLScraper{
+			definition:   c,
+			globalConfig: globalConfig,
+			client:       client,
+			urlDef:       def,
+		}
 	}
 
 	panic("unknown scraper action: " + def.Action)
@@ -68,6 +106,8 @@ type nameScraperActionImpl interface {
 }
 
 func (c Definition) getNameScraper(def ByNameDefinition, client *http.Client, globalConfig GlobalConfig) nameScraperActionImpl {
+	client = c.httpClient(client, globalConfig)
+
 	switch def.Action {
 	case scraperActionScript:
 		return &scriptNameScraper{
@@ -97,6 +137,15 @@ func (c Definition) getNameScraper(def ByNameDefinition, client *http.Client, gl
 			},
 			definition: def,
 		}
+	case scraperActionXML:
+		return &xmlNameScraper{
+			xmlScraper: xmlScraper{
+				definition:   c,
+				globalConfig: globalConfig,
+				client:       client,
+			},
+			definition: def,
+		}
 	}
 
 	panic("unknown scraper action: " + def.Action)
@@ -111,6 +160,8 @@ type fragmentScraperActionImpl interface {
 }
 
 func (c Definition) getFragmentScraper(actionDef ByFragmentDefinition, client *http.Client, globalConfig GlobalConfig) fragmentScraperActionImpl {
+	client = c.httpClient(client, globalConfig)
+
 	switch actionDef.Action {
 	case scraperActionScript:
 		return &scriptFragmentScraper{
@@ -140,6 +191,15 @@ func (c Definition) getFragmentScraper(actionDef ByFragmentDefinition, client *h
 			},
 			definition: actionDef,
 		}
+	case scraperActionXML:
+		return &xmlFragmentScraper{
+			xmlScraper: xmlScraper{
+				definition:   c,
+				globalConfig: globalConfig,
+				client:       client,
+			},
+			definition: actionDef,
+		}
 	}
 
 	panic("unknown scraper action: " + actionDef.Action)
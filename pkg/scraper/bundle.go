@@ -0,0 +1,170 @@
+package scraper
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// bundleEntryError is returned when a definition in a bundle cannot be loaded.
+type bundleEntryError struct {
+	Bundle string
+	Entry  string
+	Err    error
+}
+
+func (e *bundleEntryError) Error() string {
+	return fmt.Sprintf("%s: %s: %v", e.Bundle, e.Entry, e.Err)
+}
+
+func (e *bundleEntryError) Unwrap() error {
+	return e.Err
+}
+
+// isBundle returns true if the file at fp is a scraper definition bundle,
+// based on its extension.
+func isBundle(fp string) bool {
+	lower := strings.ToLower(fp)
+	return strings.HasSuffix(lower, ".zip") || strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz")
+}
+
+// isBundleEntry returns true if name is a yml file that should be loaded
+// from a bundle. Files in hidden directories, such as the __MACOSX directory
+// added by some zip tools, are ignored.
+func isBundleEntry(name string) bool {
+	if path.Ext(name) != ".yml" {
+		return false
+	}
+
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// loadConfigsFromBundle loads the scraper definitions from the yml files in
+// the zip or gzipped tar archive at fp. The id of each definition is the base
+// name of its file. Definitions that cannot be loaded are skipped, and a
+// *bundleEntryError is returned for each of them. The returned error is
+// non-nil only if the bundle itself cannot be read.
+//
+// Other files in the bundle are not extracted, so script scrapers that
+// depend on them will not work when loaded from a bundle.
+func loadConfigsFromBundle(fp string) ([]*Definition, []error, error) {
+	lower := strings.ToLower(fp)
+	if strings.HasSuffix(lower, ".zip") {
+		return loadConfigsFromZip(fp)
+	}
+
+	f, err := os.Open(fp)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	return loadConfigsFromTarGz(fp, f)
+}
+
+func loadConfigsFromZip(fp string) ([]*Definition, []error, error) {
+	r, err := zip.OpenReader(fp)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer r.Close()
+
+	var ret []*Definition
+	var errs []error
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() || !isBundleEntry(f.Name) {
+			continue
+		}
+
+		def, err := loadBundleEntry(fp, f.Name, f.Open)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		ret = append(ret, def)
+	}
+
+	return ret, errs, nil
+}
+
+func loadConfigsFromTarGz(fp string, reader io.Reader) ([]*Definition, []error, error) {
+	gz, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+
+	var ret []*Definition
+	var errs []error
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// the rest of the archive cannot be read, but the definitions
+			// loaded so far are still valid
+			errs = append(errs, fmt.Errorf("%s: %w", fp, err))
+			break
+		}
+
+		if hdr.Typeflag != tar.TypeReg || !isBundleEntry(hdr.Name) {
+			continue
+		}
+
+		def, err := loadBundleEntry(fp, hdr.Name, func() (io.ReadCloser, error) {
+			return io.NopCloser(tr), nil
+		})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		ret = append(ret, def)
+	}
+
+	return ret, errs, nil
+}
+
+func loadBundleEntry(fp string, name string, open func() (io.ReadCloser, error)) (*Definition, error) {
+	wrapErr := func(err error) error {
+		return &bundleEntryError{
+			Bundle: fp,
+			Entry:  name,
+			Err:    err,
+		}
+	}
+
+	r, err := open()
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	defer r.Close()
+
+	id := path.Base(name)
+	id = strings.TrimSuffix(id, path.Ext(id))
+
+	ret, err := loadConfigFromYAML(id, r)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+
+	ret.path = fp
+
+	return ret, nil
+}
@@ -0,0 +1,145 @@
+package scraper
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	bundleValidYAML = `name: Valid
+sceneByURL:
+  - action: scrapeXPath
+    url:
+      - example.com
+    scraper: sceneScraper
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Title: //h1
+`
+	bundleInvalidYAML = `name: Invalid
+unknownField: true
+`
+)
+
+var bundleFiles = map[string]string{
+	"scrapers/valid.yml":   bundleValidYAML,
+	"scrapers/invalid.yml": bundleInvalidYAML,
+	"scrapers/README.md":   "not a scraper",
+	"__MACOSX/valid.yml":   "ignored",
+}
+
+func writeZipBundle(t *testing.T, fp string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, contents := range bundleFiles {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(fp, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTarGzBundle(t *testing.T, fp string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	w := tar.NewWriter(gz)
+	for name, contents := range bundleFiles {
+		if err := w.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(contents)),
+			Typeflag: tar.TypeReg,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(fp, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadConfigsFromBundle(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name  string
+		write func(t *testing.T, fp string)
+	}{
+		{"scrapers.zip", writeZipBundle},
+		{"scrapers.tar.gz", writeTarGzBundle},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := filepath.Join(dir, tt.name)
+			tt.write(t, fp)
+
+			assert.True(t, isBundle(fp))
+
+			defs, errs, err := loadConfigsFromBundle(fp)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			// the invalid definition does not prevent the valid one from loading
+			if assert.Len(t, defs, 1) {
+				assert.Equal(t, "valid", defs[0].ID)
+				assert.Equal(t, "Valid", defs[0].Name)
+				assert.Equal(t, fp, defs[0].path)
+			}
+
+			if assert.Len(t, errs, 1) {
+				var entryErr *bundleEntryError
+				if assert.True(t, errors.As(errs[0], &entryErr)) {
+					assert.Equal(t, fp, entryErr.Bundle)
+					assert.Equal(t, "scrapers/invalid.yml", entryErr.Entry)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfigsFromBundleInvalidArchive(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "scrapers.zip")
+	if err := os.WriteFile(fp, []byte("not a zip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	defs, errs, err := loadConfigsFromBundle(fp)
+	assert.Error(t, err)
+	assert.Empty(t, defs)
+	assert.Empty(t, errs)
+}
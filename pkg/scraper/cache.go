@@ -8,8 +8,10 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/stashapp/stash/pkg/fsutil"
@@ -25,11 +27,14 @@ const (
 	// is needed.
 	scrapeGetTimeout = time.Second * 60
 
-	// maxIdleConnsPerHost is the maximum number of idle connections the HTTP client will
-	// keep on a per-host basis.
+	// maxIdleConnsPerHost is the default maximum number of idle connections the HTTP
+	// client will keep on a per-host basis.
 	maxIdleConnsPerHost = 8
 
-	// maxRedirects defines the maximum number of redirects the HTTP client will follow
+	// idleConnTimeout is the time an idle connection is kept open for reuse.
+	idleConnTimeout = 90 * time.Second
+
+	// maxRedirects defines the default maximum number of redirects the HTTP client will follow
 	maxRedirects = 20
 )
 
@@ -42,6 +47,14 @@ type GlobalConfig interface {
 	GetPythonPath() string
 	GetProxy() string
 	GetScraperExcludeTagPatterns() []string
+	// GetScraperAllowLocalFiles returns true if file:// urls may be scraped.
+	GetScraperAllowLocalFiles() bool
+	// GetScraperMaxIdleConnsPerHost returns the maximum number of idle
+	// connections kept per host. A value <= 0 uses the default.
+	GetScraperMaxIdleConnsPerHost() int
+	// GetScraperMaxRedirects returns the maximum number of redirects followed
+	// by scraper requests. A value <= 0 uses the default.
+	GetScraperMaxRedirects() int
 }
 
 func isCDPPathHTTP(c GlobalConfig) bool {
@@ -117,46 +130,203 @@ func (r *Repository) WithReadTxn(ctx context.Context, fn txn.TxnFunc) error {
 // Cache stores the database of scrapers
 type Cache struct {
 	client       *http.Client
+	transports   *transportPool
+	responses    *responseCache
+	subScrapes   *hostLimiter
 	scrapers     map[string]scraper // Scraper ID -> Scraper
 	globalConfig GlobalConfig
 
 	repository Repository
 }
 
-// newClient creates a scraper-local http client we use throughout the scraper subsystem.
-func newClient(gc GlobalConfig) *http.Client {
-	client := &http.Client{
+type transportKey struct {
+	certCheck           bool
+	maxIdleConnsPerHost int
+}
+
+// transportPool holds the http transports used by scraper clients, so that
+// connections are reused across scrapers. Cookies and headers are set per
+// request, so are not affected by sharing a transport.
+//
+// The pool also refers to the response cache and sub-scrape limiter of the
+// Cache that owns it, so that clients created from the pool share them, and
+// holds the client used by scrapers that do not verify TLS certificates.
+// Clients from a pool without a response cache do not cache responses, and
+// clients from a pool without a limiter do not limit sub-scrapes.
+type transportPool struct {
+	mutex      sync.Mutex
+	transports map[transportKey]*scraperTransport
+	responses  *responseCache
+	subScrapes *hostLimiter
+	insecure   *http.Client
+}
+
+func newTransportPool(responses *responseCache, subScrapes *hostLimiter) *transportPool {
+	return &transportPool{
+		transports: make(map[transportKey]*scraperTransport),
+		responses:  responses,
+		subScrapes: subScrapes,
+	}
+}
+
+// get returns the transport in the pool for the global configuration,
+// overriding the certificate check option.
+func (p *transportPool) get(gc GlobalConfig, certCheck bool) *scraperTransport {
+	key := transportKey{
+		certCheck:           certCheck,
+		maxIdleConnsPerHost: gc.GetScraperMaxIdleConnsPerHost(),
+	}
+	if key.maxIdleConnsPerHost <= 0 {
+		key.maxIdleConnsPerHost = maxIdleConnsPerHost
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if t, found := p.transports[key]; found {
+		return t
+	}
+
+	t := &scraperTransport{
 		Transport: &http.Transport{ // ignore insecure certificates
-			TLSClientConfig:     &tls.Config{InsecureSkipVerify: !gc.GetScraperCertCheck()},
-			MaxIdleConnsPerHost: maxIdleConnsPerHost,
+			TLSClientConfig:     &tls.Config{InsecureSkipVerify: !key.certCheck},
+			MaxIdleConnsPerHost: key.maxIdleConnsPerHost,
+			IdleConnTimeout:     idleConnTimeout,
 			Proxy:               http.ProxyFromEnvironment,
 		},
-		Timeout: scrapeGetTimeout,
-		// defaultCheckRedirect code with max changed from 10 to maxRedirects
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			if len(via) >= maxRedirects {
-				return fmt.Errorf("%w: gave up after %d redirects", ErrMaxRedirects, maxRedirects)
-			}
-			return nil
-		},
+		pool: p,
+	}
+	p.transports[key] = t
+	return t
+}
+
+// scraperTransport is the transport of a scraper client. It refers to the
+// pool it was taken from, so that copies of the client with different
+// transport options use the same pool.
+type scraperTransport struct {
+	*http.Transport
+	pool *transportPool
+}
+
+// clientPool returns the transport pool that client was created from, or nil
+// if client was not created by newClient.
+func clientPool(client *http.Client) *transportPool {
+	if t, ok := client.Transport.(*scraperTransport); ok {
+		return t.pool
+	}
+
+	return nil
+}
+
+// clientResponseCache returns the response cache used by client, or nil if
+// responses to client requests should not be cached.
+func clientResponseCache(client *http.Client) *responseCache {
+	if pool := clientPool(client); pool != nil {
+		return pool.responses
+	}
+
+	return nil
+}
+
+// clientSubScrapeLimiter returns the limiter of sub-scrape requests made by
+// client, or nil if they should not be limited.
+func clientSubScrapeLimiter(client *http.Client) *hostLimiter {
+	if pool := clientPool(client); pool != nil {
+		return pool.subScrapes
+	}
+
+	return nil
+}
+
+// newClient creates a scraper-local http client we use throughout the scraper subsystem.
+// The client uses the transport from pool for the global configuration.
+func newClient(gc GlobalConfig, pool *transportPool) *http.Client {
+	max := gc.GetScraperMaxRedirects()
+	if max <= 0 {
+		max = maxRedirects
+	}
+
+	client := &http.Client{
+		Transport:     pool.get(gc, gc.GetScraperCertCheck()),
+		Timeout:       scrapeGetTimeout,
+		CheckRedirect: checkRedirect(max),
 	}
 
 	return client
 }
 
+// checkRedirect returns a CheckRedirect function for an http.Client with
+// the maximum changed from 10 to max, as in the default CheckRedirect. The
+// error returned when the maximum is reached includes the URL that was being
+// redirected to.
+func checkRedirect(max int) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) >= max {
+			return fmt.Errorf("%w: gave up after %d redirects, last redirected to %s", ErrMaxRedirects, max, req.URL)
+		}
+		return nil
+	}
+}
+
+// clientWithMaxRedirects returns a copy of client that follows at most max redirects.
+// The copy shares the transport and cookie jar of client.
+func clientWithMaxRedirects(client *http.Client, max int) *http.Client {
+	ret := *client
+	ret.CheckRedirect = checkRedirect(max)
+	return &ret
+}
+
+// insecureClient returns the client of the pool that does not verify TLS
+// certificates. The client is created from client on first use, and is
+// recreated if the transport for the global configuration changes.
+func (p *transportPool) insecureClient(client *http.Client, gc GlobalConfig) *http.Client {
+	t := p.get(gc, false)
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.insecure == nil || p.insecure.Transport != t {
+		ret := *client
+		ret.Transport = t
+		p.insecure = &ret
+	}
+
+	return p.insecure
+}
+
+// clientWithoutTLSVerify returns a client that does not verify TLS
+// certificates, taken from the transport pool of client. Clients that were
+// not created by newClient are returned unchanged.
+func clientWithoutTLSVerify(client *http.Client, gc GlobalConfig) *http.Client {
+	pool := clientPool(client)
+	if pool == nil {
+		logger.Warnf("[scraper] cannot disable TLS certificate verification for a client without a transport pool")
+		return client
+	}
+
+	return pool.insecureClient(client, gc)
+}
+
 // NewCache returns a new Cache.
 //
 // Scraper configurations are loaded from yml files in the scrapers
-// directory in the config and any subdirectories.
+// directory in the config and any subdirectories, and from zip and gzipped
+// tar bundles of yml files.
 //
 // Does not load scrapers. Scrapers will need to be
 // loaded explicitly using ReloadScrapers.
 func NewCache(globalConfig GlobalConfig, repo Repository) *Cache {
 	// HTTP Client setup
-	client := newClient(globalConfig)
+	responses := newResponseCache()
+	subScrapes := newHostLimiter(maxSubScrapesPerHost)
+	transports := newTransportPool(responses, subScrapes)
+	client := newClient(globalConfig, transports)
 
 	return &Cache{
 		client:       client,
+		transports:   transports,
+		responses:    responses,
+		subScrapes:   subScrapes,
 		globalConfig: globalConfig,
 		repository:   repo,
 	}
@@ -164,7 +334,10 @@ func NewCache(globalConfig GlobalConfig, repo Repository) *Cache {
 
 // ReloadScrapers clears the scraper cache and reloads from the scraper path.
 // If a scraper cannot be loaded, an error is logged and the scraper is skipped.
+// Cached responses are discarded.
 func (c *Cache) ReloadScrapers() {
+	c.responses.clear()
+
 	path := c.globalConfig.GetScrapersPath()
 	scrapers := make(map[string]scraper)
 
@@ -185,6 +358,18 @@ func (c *Cache) ReloadScrapers() {
 				scraper := scraperFromDefinition(*conf, c.globalConfig)
 				scrapers[scraper.spec().ID] = scraper
 			}
+		} else if isBundle(fp) {
+			confs, errs, err := loadConfigsFromBundle(fp)
+			if err != nil {
+				logger.Errorf("Error loading scraper bundle %s: %v", fp, err)
+			}
+			for _, err := range errs {
+				logger.Errorf("Error loading scraper from bundle: %v", err)
+			}
+			for _, conf := range confs {
+				scraper := scraperFromDefinition(*conf, c.globalConfig)
+				scrapers[scraper.spec().ID] = scraper
+			}
 		}
 		return nil
 	})
@@ -228,6 +413,16 @@ func (c Cache) GetScraper(scraperID string) *Scraper {
 	return nil
 }
 
+// scraperClient returns the http client used for requests made by s,
+// including image downloads.
+func (c Cache) scraperClient(s scraper) *http.Client {
+	if d, ok := s.(definedScraper); ok {
+		return d.config.httpClient(c.client, c.globalConfig)
+	}
+
+	return c.client
+}
+
 func (c Cache) findScraper(scraperID string) scraper {
 	s, ok := c.scrapers[scraperID]
 	if ok {
@@ -264,6 +459,7 @@ func (c Cache) ScrapeName(ctx context.Context, id, query string, ty ScrapeConten
 	pp := postScraper{
 		Cache:        c,
 		excludeTagRE: c.compileExcludeTagPatterns(),
+		client:       c.scraperClient(s),
 	}
 	if err := c.repository.WithReadTxn(ctx, func(ctx context.Context) error {
 		for i, cc := range content {
@@ -302,7 +498,7 @@ func (c Cache) ScrapeFragment(ctx context.Context, id string, input Input) (Scra
 		return nil, fmt.Errorf("error while fragment scraping with scraper %s: %w", id, err)
 	}
 
-	return c.postScrapeSingle(ctx, content)
+	return c.postScrapeSingle(ctx, s, content)
 }
 
 // ScrapeURL scrapes a given url for the given content. Searches the scraper cache
@@ -324,8 +520,108 @@ func (c Cache) ScrapeURL(ctx context.Context, url string, ty ScrapeContentType)
 				return ret, nil
 			}
 
-			return c.postScrapeSingle(ctx, ret)
+			return c.postScrapeSingle(ctx, s, ret)
+		}
+	}
+
+	return nil, nil
+}
+
+// ScrapeURLAuto scrapes a given url, determining the content type from the
+// url patterns of the loaded scrapers. If the url matches scrapers of more than
+// one content type, the content type of the most specific (longest) matching
+// url pattern is used. Returns an ErrAmbiguousURL error if the content type
+// cannot be determined, or nil if no scraper is capable of scraping the url.
+func (c Cache) ScrapeURLAuto(ctx context.Context, url string) (ScrapedContent, error) {
+	ty, err := c.urlContentType(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if ty == "" {
+		return nil, nil
+	}
+
+	return c.ScrapeURL(ctx, url, ty)
+}
+
+// urlContentType returns the content type of the scrapers with the most
+// specific url pattern matching url. Returns an empty string if no scraper
+// matches the url.
+func (c Cache) urlContentType(url string) (ScrapeContentType, error) {
+	best := 0
+	var types []ScrapeContentType
+
+	for _, s := range c.scrapers {
+		m, ok := s.(urlMatcher)
+		if !ok {
+			continue
+		}
+
+		for _, ty := range urlContentTypes {
+			l := m.urlMatchLength(url, ty)
+			switch {
+			case l == 0 || l < best:
+				continue
+			case l > best:
+				best = l
+				types = []ScrapeContentType{ty}
+			case !slices.Contains(types, ty):
+				types = append(types, ty)
+			}
+		}
+	}
+
+	switch len(types) {
+	case 0:
+		return "", nil
+	case 1:
+		return types[0], nil
+	}
+
+	slices.Sort(types)
+	return "", fmt.Errorf("%w: %s matches %v", ErrAmbiguousURL, url, types)
+}
+
+// ScrapeURLMulti scrapes a given url for the given content, returning all
+// results. This is used for URLs that return a list of results, such as
+// listing pages. Returns nil if no scraper is capable of scraping the url.
+func (c Cache) ScrapeURLMulti(ctx context.Context, url string, ty ScrapeContentType) ([]ScrapedContent, error) {
+	for _, s := range c.scrapers {
+		if !s.supportsURL(url, ty) {
+			continue
+		}
+
+		ul, ok := s.(multiURLScraper)
+		if !ok {
+			return nil, fmt.Errorf("%w: cannot use scraper %s as an url scraper", ErrNotSupported, s.spec().ID)
+		}
+
+		content, err := ul.viaURLMulti(ctx, c.client, url, ty)
+		if err != nil {
+			return nil, err
 		}
+
+		pp := postScraper{
+			Cache:        c,
+			excludeTagRE: c.compileExcludeTagPatterns(),
+			client:       c.scraperClient(s),
+		}
+		if err := c.repository.WithReadTxn(ctx, func(ctx context.Context) error {
+			for i, cc := range content {
+				content[i], err = pp.postScrape(ctx, cc)
+				if err != nil {
+					return fmt.Errorf("error while post-scraping with scraper %s: %w", s.spec().ID, err)
+				}
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+
+		LogIgnoredTags(pp.ignoredTags)
+
+		return content, nil
 	}
 
 	return nil, nil
@@ -409,7 +705,7 @@ func (c Cache) ScrapeID(ctx context.Context, scraperID string, id int, ty Scrape
 		}
 	}
 
-	return c.postScrapeSingle(ctx, ret)
+	return c.postScrapeSingle(ctx, s, ret)
 }
 
 func (c Cache) getScene(ctx context.Context, sceneID int) (*models.Scene, error) {
@@ -0,0 +1,76 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const urlAutoSiteYAML = `name: Site
+sceneByURL:
+  - action: scrapeXPath
+    url:
+      - example.com/videos/
+      - shared.com
+    scraper: sceneScraper
+performerByURL:
+  - action: scrapeXPath
+    url:
+      - example.com/models/
+      - shared.com
+    scraper: performerScraper
+galleryByURL:
+  - action: scrapeXPath
+    url:
+      - example.com/
+    scraper: galleryScraper
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Title: //h1
+  performerScraper:
+    performer:
+      Name: //h1
+  galleryScraper:
+    gallery:
+      Title: //h1
+`
+
+func TestCache_urlContentType(t *testing.T) {
+	def, err := loadConfigFromYAML("site", strings.NewReader(urlAutoSiteYAML))
+	if err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	c := Cache{
+		scrapers: map[string]scraper{
+			"site": scraperFromDefinition(*def, nil),
+		},
+	}
+
+	tests := []struct {
+		name    string
+		url     string
+		want    ScrapeContentType
+		wantErr error
+	}{
+		{"single match", "https://example.com/galleries/1", ScrapeContentTypeGallery, nil},
+		{"most specific scene", "https://example.com/videos/1", ScrapeContentTypeScene, nil},
+		{"most specific performer", "https://example.com/models/1", ScrapeContentTypePerformer, nil},
+		{"no match", "https://other.com/videos/1", "", nil},
+		{"ambiguous", "https://shared.com/1", "", ErrAmbiguousURL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.urlContentType(tt.url)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
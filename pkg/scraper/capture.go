@@ -0,0 +1,89 @@
+package scraper
+
+import (
+	"context"
+	"sync"
+)
+
+// defaultCaptureLimit is the maximum number of bytes captured for each
+// response, if not set by the scraper definition.
+const defaultCaptureLimit = 64 * 1024
+
+// CapturedResponse is the raw body of a response fetched during a scrape.
+type CapturedResponse struct {
+	URL  string
+	Body []byte
+	// Truncated is true if Body was truncated to the capture limit.
+	Truncated bool
+}
+
+// ResponseCapture collects the raw responses fetched by scrapers with the
+// captureResponse debug option set. It is safe for concurrent use.
+type ResponseCapture struct {
+	mutex     sync.Mutex
+	responses []CapturedResponse
+}
+
+// Responses returns the captured responses, in the order they were fetched.
+func (c *ResponseCapture) Responses() []CapturedResponse {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	ret := make([]CapturedResponse, len(c.responses))
+	copy(ret, c.responses)
+	return ret
+}
+
+func (c *ResponseCapture) add(url string, body []byte, limit int) {
+	r := CapturedResponse{
+		URL: url,
+	}
+
+	if len(body) > limit {
+		body = body[:limit]
+		r.Truncated = true
+	}
+
+	// copy the body so that the capture does not share it with the response cache
+	r.Body = append([]byte(nil), body...)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.responses = append(c.responses, r)
+}
+
+type responseCaptureKey struct{}
+
+// WithResponseCapture returns a context that captures the responses fetched
+// by scrapers to c. Responses are only captured for scrapers that have the
+// captureResponse debug option set.
+func WithResponseCapture(ctx context.Context, c *ResponseCapture) context.Context {
+	return context.WithValue(ctx, responseCaptureKey{}, c)
+}
+
+func responseCaptureFromContext(ctx context.Context) *ResponseCapture {
+	c, _ := ctx.Value(responseCaptureKey{}).(*ResponseCapture)
+	return c
+}
+
+// captureResponse records body to the response capture of ctx, if the
+// definition has the captureResponse debug option set.
+func captureResponse(ctx context.Context, def Definition, url string, body []byte) {
+	opts := def.DebugOptions
+	if opts == nil || !opts.CaptureResponse {
+		return
+	}
+
+	c := responseCaptureFromContext(ctx)
+	if c == nil {
+		return
+	}
+
+	limit := opts.CaptureLimit
+	if limit <= 0 {
+		limit = defaultCaptureLimit
+	}
+
+	c.add(url, body, limit)
+}
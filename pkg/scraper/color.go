@@ -0,0 +1,101 @@
+package scraper
+
+import (
+	"context"
+	"strings"
+)
+
+// hairColorVocabulary maps hair colors to their canonical form.
+var hairColorVocabulary = map[string]string{
+	"auburn":            "Auburn",
+	"bald":              "Bald",
+	"black":             "Black",
+	"blond":             "Blonde",
+	"blonde":            "Blonde",
+	"brown":             "Brunette",
+	"brunette":          "Brunette",
+	"chestnut":          "Brunette",
+	"dirty blonde":      "Blonde",
+	"ginger":            "Red",
+	"gray":              "Grey",
+	"grey":              "Grey",
+	"honey blonde":      "Blonde",
+	"multicolored":      "Various",
+	"multicolor":        "Various",
+	"platinum":          "Blonde",
+	"platinum blonde":   "Blonde",
+	"red":               "Red",
+	"redhead":           "Red",
+	"shaved":            "Bald",
+	"silver":            "Grey",
+	"strawberry blonde": "Blonde",
+	"various":           "Various",
+	"white":             "White",
+}
+
+// eyeColorVocabulary maps eye colors to their canonical form.
+var eyeColorVocabulary = map[string]string{
+	"blue":  "Blue",
+	"brown": "Brown",
+	"gray":  "Grey",
+	"green": "Green",
+	"grey":  "Grey",
+	"hazel": "Hazel",
+	"red":   "Red",
+}
+
+// colorModifiers are words that qualify a color, which are ignored when
+// looking up the canonical form.
+var colorModifiers = map[string]bool{
+	"light":  true,
+	"lt":     true,
+	"dark":   true,
+	"dk":     true,
+	"medium": true,
+	"deep":   true,
+	"pale":   true,
+	"hair":   true,
+	"eye":    true,
+	"eyes":   true,
+}
+
+// colorKey returns the key used to look up the color in a vocabulary. The
+// value is lowercased, punctuation is removed and modifiers such as "light"
+// and "dark" are ignored, so that "Lt. Brown" and "light-brown" are both
+// looked up as "brown".
+func colorKey(value string) string {
+	value = strings.ToLower(value)
+	value = strings.NewReplacer(".", " ", "-", " ", "_", " ").Replace(value)
+
+	var words []string
+	for _, w := range strings.Fields(value) {
+		if !colorModifiers[w] {
+			words = append(words, w)
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+// postProcessColor normalizes a hair or eye color to the canonical form in
+// vocabulary. Overrides are matched against the whole value, case-insensitively,
+// and take precedence over the vocabulary. The original value is returned if it
+// is not recognised.
+type postProcessColor struct {
+	vocabulary map[string]string
+	overrides  mappedOverridesConfig
+}
+
+func (p *postProcessColor) Apply(ctx context.Context, value string, q mappedQuery) string {
+	trimmed := strings.TrimSpace(value)
+
+	if mapped, ok := p.overrides.lookup(trimmed); ok {
+		return mapped
+	}
+
+	if mapped, ok := p.vocabulary[colorKey(trimmed)]; ok {
+		return mapped
+	}
+
+	return value
+}
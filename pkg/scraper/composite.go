@@ -0,0 +1,193 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/tidwall/gjson"
+	"golang.org/x/net/html"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// compositeURLScraper loads a URL once, and runs each of its sub-scrapers
+// against the page. The results are merged in order, so that later
+// sub-scrapers only fill the fields that earlier ones did not set.
+type compositeURLScraper struct {
+	definition   Definition
+	globalConfig GlobalConfig
+	client       *http.Client
+	urlDef       ByURLDefinition
+}
+
+// compositePage is a page loaded by a compositeURLScraper. The page is only
+// parsed as HTML if a sub-scraper requires it.
+type compositePage struct {
+	url  string
+	body []byte
+	doc  *html.Node
+}
+
+func (p *compositePage) html() (*html.Node, error) {
+	if p.doc == nil {
+		doc, err := html.Parse(bytes.NewReader(p.body))
+		if err != nil {
+			return nil, fmt.Errorf("parsing HTML: %w", err)
+		}
+		p.doc = doc
+	}
+
+	return p.doc, nil
+}
+
+// json returns the JSON document selected by selector, or the page itself if
+// selector is empty.
+func (p *compositePage) json(selector string) (string, error) {
+	doc := string(p.body)
+
+	if selector != "" {
+		root, err := p.html()
+		if err != nil {
+			return "", err
+		}
+
+		n, err := htmlquery.Query(root, selector)
+		if err != nil {
+			return "", fmt.Errorf("invalid jsonSelector %q: %w", selector, err)
+		}
+		if n == nil {
+			return "", fmt.Errorf("jsonSelector %q did not match", selector)
+		}
+
+		doc = strings.TrimSpace(htmlquery.InnerText(n))
+	}
+
+	if !gjson.Valid(doc) {
+		return "", fmt.Errorf("%w: %s", ErrInvalidJSON, documentSnippet(doc))
+	}
+
+	return doc, nil
+}
+
+func (s *compositeURLScraper) loadPage(ctx context.Context, url string) (*compositePage, error) {
+	r, err := loadURL(ctx, url, s.client, s.definition, s.globalConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load URL %q: %w", url, err)
+	}
+
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &compositePage{
+		url:  url,
+		body: body,
+	}, nil
+}
+
+// subQuery returns the mapped scraper and query for the sub-scraper.
+func (s *compositeURLScraper) subQuery(sub compositeSubScraper, page *compositePage) (*mappedScraper, mappedQuery, error) {
+	switch sub.Action {
+	case scraperActionXPath:
+		xs := &xpathScraper{
+			definition:   s.definition,
+			globalConfig: s.globalConfig,
+			client:       s.client,
+		}
+
+		scraper, err := xs.getXpathScraper(sub.Scraper)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		doc, err := page.html()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return scraper, xs.getXPathQuery(doc, page.url), nil
+	case scraperActionJson:
+		js := &jsonScraper{
+			definition:   s.definition,
+			globalConfig: s.globalConfig,
+			client:       s.client,
+		}
+
+		scraper, err := js.getJsonScraper(sub.Scraper)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		doc, err := page.json(sub.JSONSelector)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return scraper, js.getJsonQuery(doc, page.url), nil
+	}
+
+	return nil, nil, fmt.Errorf("%s is not a valid composite sub-scraper action", sub.Action)
+}
+
+func (s *compositeURLScraper) scrapeByURL(ctx context.Context, url string, ty ScrapeContentType) (ScrapedContent, error) {
+	page, err := s.loadPage(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret ScrapedContent
+	for _, sub := range s.urlDef.Scrapers {
+		scraper, q, err := s.subQuery(sub, page)
+		if err != nil {
+			return nil, fmt.Errorf("%s sub-scraper %q: %w", sub.Action, sub.Scraper, err)
+		}
+
+		content, err := scraper.scrapeContent(ctx, q, ty)
+		if err != nil {
+			return nil, fmt.Errorf("%s sub-scraper %q: %w", sub.Action, sub.Scraper, err)
+		}
+
+		ret = mergeScrapedContent(ret, content)
+	}
+
+	return ret, nil
+}
+
+// mergeScrapedContent fills the empty fields of dest with the values from src,
+// returning the result. dest and src must be of the same type.
+func mergeScrapedContent(dest ScrapedContent, src ScrapedContent) ScrapedContent {
+	if dest == nil {
+		return src
+	}
+
+	switch d := dest.(type) {
+	case *models.ScrapedScene:
+		if s, ok := src.(*models.ScrapedScene); ok {
+			MergeScene(d, s)
+		}
+	case *models.ScrapedGallery:
+		if s, ok := src.(*models.ScrapedGallery); ok {
+			MergeGallery(d, s)
+		}
+	case *models.ScrapedImage:
+		if s, ok := src.(*models.ScrapedImage); ok {
+			MergeImage(d, s)
+		}
+	case *models.ScrapedPerformer:
+		if s, ok := src.(*models.ScrapedPerformer); ok {
+			MergePerformer(d, s)
+		}
+	case *models.ScrapedGroup:
+		if s, ok := src.(*models.ScrapedGroup); ok {
+			MergeGroup(d, s)
+		}
+	}
+
+	return dest
+}
@@ -0,0 +1,196 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+const compositePageHTML = `<html>
+<head>
+	<script type="application/ld+json">
+	{
+		"name": "JSON Title",
+		"description": "JSON Details",
+		"uploadDate": "2024-01-02",
+		"keywords": ["Tag A", "Tag C"]
+	}
+	</script>
+</head>
+<body>
+	<h1>HTML Title</h1>
+	<a class="studio">Studio</a>
+	<ul class="tags"><li>Tag A</li><li>Tag B</li></ul>
+</body>
+</html>`
+
+const compositeScraperYAML = `name: Composite
+sceneByURL:
+  - action: scrapeComposite
+    url:
+      - %s
+    scrapers:
+      - action: scrapeXPath
+        scraper: sceneXPath
+      - action: scrapeJson
+        scraper: sceneJSON
+        jsonSelector: //script[@type="application/ld+json"]
+xPathScrapers:
+  sceneXPath:
+    scene:
+      Title: //h1
+      Studio:
+        Name: //a[@class="studio"]
+      Tags:
+        Name: //ul[@class="tags"]/li
+jsonScrapers:
+  sceneJSON:
+    scene:
+      Title: name
+      Details: description
+      Date: uploadDate
+      Tags:
+        Name: keywords
+`
+
+func TestCompositeScraper(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, compositePageHTML)
+	}))
+	defer ts.Close()
+
+	def, err := loadConfigFromYAML("composite", strings.NewReader(fmt.Sprintf(compositeScraperYAML, ts.URL)))
+	if err != nil {
+		t.Fatalf("loading definition: %v", err)
+	}
+
+	gc := mockGlobalConfig{}
+	content, err := scraperFromDefinition(*def, gc).viaURL(context.Background(), newClient(gc, newTransportPool(nil, nil)), ts.URL, ScrapeContentTypeScene)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// the page is only loaded once
+	assert.Equal(t, 1, requests)
+
+	scene, ok := content.(*models.ScrapedScene)
+	if !assert.True(t, ok) {
+		return
+	}
+
+	// values from the xpath scraper take precedence
+	verifyField(t, "HTML Title", scene.Title, "Title")
+	verifyField(t, "JSON Details", scene.Details, "Details")
+	verifyField(t, "2024-01-02", scene.Date, "Date")
+
+	if assert.NotNil(t, scene.Studio) {
+		assert.Equal(t, "Studio", scene.Studio.Name)
+	}
+
+	var tags []string
+	for _, tag := range scene.Tags {
+		tags = append(tags, tag.Name)
+	}
+	assert.Equal(t, []string{"Tag A", "Tag B", "Tag C"}, tags)
+}
+
+func TestCompositeScraperInvalidJSON(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><h1>HTML Title</h1><script type="application/ld+json">not json</script></html>`)
+	}))
+	defer ts.Close()
+
+	def, err := loadConfigFromYAML("composite", strings.NewReader(fmt.Sprintf(compositeScraperYAML, ts.URL)))
+	if err != nil {
+		t.Fatalf("loading definition: %v", err)
+	}
+
+	gc := mockGlobalConfig{}
+	_, err = scraperFromDefinition(*def, gc).viaURL(context.Background(), newClient(gc, newTransportPool(nil, nil)), ts.URL, ScrapeContentTypeScene)
+	assert.ErrorIs(t, err, ErrInvalidJSON)
+}
+
+func TestCompositeScraperValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+	}{
+		{
+			"no scrapers",
+			`name: Test
+sceneByURL:
+  - action: scrapeComposite
+    url: [example.com]
+`,
+		},
+		{
+			"invalid sub-scraper action",
+			`name: Test
+sceneByURL:
+  - action: scrapeComposite
+    url: [example.com]
+    scrapers:
+      - action: script
+`,
+		},
+		{
+			"jsonSelector on xpath sub-scraper",
+			`name: Test
+sceneByURL:
+  - action: scrapeComposite
+    url: [example.com]
+    scrapers:
+      - action: scrapeXPath
+        scraper: s
+        jsonSelector: //script
+`,
+		},
+		{
+			"invalid jsonSelector",
+			`name: Test
+sceneByURL:
+  - action: scrapeComposite
+    url: [example.com]
+    scrapers:
+      - action: scrapeJson
+        scraper: s
+        jsonSelector: //script[
+`,
+		},
+		{
+			"multiple",
+			`name: Test
+sceneByURL:
+  - action: scrapeComposite
+    url: [example.com]
+    multiple: true
+    scrapers:
+      - action: scrapeXPath
+        scraper: s
+`,
+		},
+		{
+			"name scraper",
+			`name: Test
+sceneByName:
+  action: scrapeComposite
+  queryURL: example.com
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadConfigFromYAML("test", strings.NewReader(tt.yaml))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -269,6 +269,68 @@ var countryNameMapping = map[string]string{
 	"sint maarten (dutch part)":            "SX",
 	"south sudan":                          "SS",
 	"kosovo":                               "XK",
+
+	// native names
+	"brasil":          "BR",
+	"česko":           "CZ",
+	"česká republika": "CZ",
+	"danmark":         "DK",
+	"deutschland":     "DE",
+	"españa":          "ES",
+	"espana":          "ES",
+	"holland":         "NL",
+	"italia":          "IT",
+	"magyarország":    "HU",
+	"méxico":          "MX",
+	"nederland":       "NL",
+	"norge":           "NO",
+	"österreich":      "AT",
+	"polska":          "PL",
+	"россия":          "RU",
+	"schweiz":         "CH",
+	"suisse":          "CH",
+	"suomi":           "FI",
+	"sverige":         "SE",
+}
+
+// countryCodeAliases maps two letter codes which are commonly used in place of
+// the ISO 3166-1 alpha-2 code.
+var countryCodeAliases = map[string]string{
+	"UK": "GB",
+	"EL": "GR",
+}
+
+// countryCodes is the set of ISO 3166-1 alpha-2 codes in countryNameMapping.
+var countryCodes = func() map[string]struct{} {
+	ret := make(map[string]struct{})
+	for _, code := range countryNameMapping {
+		ret[code] = struct{}{}
+	}
+	return ret
+}()
+
+// normalizeCountryCode returns the ISO 3166-1 alpha-2 code for a two letter
+// code. Known aliases, such as UK, are mapped to their ISO code. Returns false
+// if the code is not recognised.
+func normalizeCountryCode(code string) (string, bool) {
+	code = strings.ToUpper(code)
+
+	if v, exists := countryCodeAliases[code]; exists {
+		return v, true
+	}
+
+	if _, exists := countryCodes[code]; exists {
+		return code, true
+	}
+
+	return "", false
+}
+
+// lookupCountryCode returns the ISO 3166-1 alpha-2 code for the country name.
+// The name is matched case-insensitively.
+func lookupCountryCode(name string) (string, bool) {
+	v, exists := countryNameMapping[strings.ToLower(name)]
+	return v, exists
 }
 
 func resolveCountryName(name *string) *string {
@@ -284,7 +346,7 @@ func resolveCountryName(name *string) *string {
 		return nil
 	}
 
-	v, exists := countryNameMapping[strings.ToLower(trimmedName)]
+	v, exists := lookupCountryCode(trimmedName)
 	if exists {
 		return &v
 	}
@@ -0,0 +1,87 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// PostProcessQuery is the query of the scraped value that a custom post-process
+// action is applied to.
+type PostProcessQuery interface {
+	// URL returns the url of the scraped page, or an empty string if it is not known.
+	URL() string
+	// Query returns the values matched by the selector in the scraped document.
+	Query(selector string) ([]string, error)
+}
+
+// CustomPostProcessFunc is a post-process action that is registered with
+// RegisterPostProcessAction. It returns the post-processed value.
+type CustomPostProcessFunc func(ctx context.Context, value string, q PostProcessQuery) string
+
+var (
+	customActionsMutex sync.RWMutex
+	customActions      = make(map[string]CustomPostProcessFunc)
+)
+
+// RegisterPostProcessAction registers a post-process action, which scrapers
+// reference by name using the custom post-process field. Actions must be
+// registered before the scrapers that use them are loaded.
+// Panics if the name is empty or is already registered, or if fn is nil.
+func RegisterPostProcessAction(name string, fn CustomPostProcessFunc) {
+	customActionsMutex.Lock()
+	defer customActionsMutex.Unlock()
+
+	if name == "" {
+		panic("scraper: post-process action name is empty")
+	}
+	if fn == nil {
+		panic("scraper: post-process action " + name + " is nil")
+	}
+	if _, dup := customActions[name]; dup {
+		panic("scraper: post-process action " + name + " is already registered")
+	}
+
+	customActions[name] = fn
+}
+
+func getCustomAction(name string) (CustomPostProcessFunc, error) {
+	customActionsMutex.RLock()
+	defer customActionsMutex.RUnlock()
+
+	fn, ok := customActions[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown custom post-process action %q", name)
+	}
+
+	return fn, nil
+}
+
+// postProcessQuery adapts a mappedQuery to PostProcessQuery.
+// The mappedQuery may be nil.
+type postProcessQuery struct {
+	q mappedQuery
+}
+
+func (q postProcessQuery) URL() string {
+	if q.q == nil {
+		return ""
+	}
+	return q.q.getURL()
+}
+
+func (q postProcessQuery) Query(selector string) ([]string, error) {
+	if q.q == nil {
+		return nil, nil
+	}
+	return q.q.runQuery(selector)
+}
+
+// postProcessCustom applies a registered CustomPostProcessFunc.
+type postProcessCustom struct {
+	fn CustomPostProcessFunc
+}
+
+func (p *postProcessCustom) Apply(ctx context.Context, value string, q mappedQuery) string {
+	return p.fn(ctx, value, postProcessQuery{q: q})
+}
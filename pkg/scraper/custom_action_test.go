@@ -0,0 +1,86 @@
+package scraper
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func init() {
+	RegisterPostProcessAction("testSuffixFromPage", func(ctx context.Context, value string, q PostProcessQuery) string {
+		suffix, err := q.Query(`//span[@class="suffix"]`)
+		if err != nil || len(suffix) == 0 {
+			return value
+		}
+
+		return strings.ToUpper(value) + " " + suffix[0] + " " + q.URL()
+	})
+}
+
+func TestCustomPostProcessAction(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Title:
+        selector: //h1
+        postProcess:
+          - custom: testSuffixFromPage
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	const html = `<html>
+<h1>Title</h1>
+<span class="suffix">Suffix</span>
+</html>`
+
+	doc, err := htmlquery.Parse(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("Error loading document: %s", err.Error())
+	}
+
+	q := &xpathQuery{
+		doc: doc,
+		url: "https://example.com/scene",
+	}
+
+	scene, err := c.XPathScrapers["sceneScraper"].scrapeScene(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping scene: %s", err.Error())
+	}
+
+	verifyField(t, "TITLE Suffix https://example.com/scene", scene.Title, "Title")
+
+	// a nil query has no url or document
+	action := mappedPostProcessAction{Custom: "testSuffixFromPage"}
+	pp, err := action.ToPostProcessAction()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "value", pp.Apply(context.Background(), "value", nil))
+	}
+}
+
+func TestCustomPostProcessActionInvalid(t *testing.T) {
+	invalid := []mappedPostProcessAction{
+		{Custom: "unknown"},
+		{Custom: "testSuffixFromPage", TrimPrefix: "a"},
+	}
+
+	for _, a := range invalid {
+		_, err := a.ToPostProcessAction()
+		assert.Error(t, err)
+	}
+
+	fn := func(ctx context.Context, value string, q PostProcessQuery) string { return value }
+
+	assert.Panics(t, func() { RegisterPostProcessAction("testSuffixFromPage", fn) })
+	assert.Panics(t, func() { RegisterPostProcessAction("", fn) })
+	assert.Panics(t, func() { RegisterPostProcessAction("testNil", nil) })
+}
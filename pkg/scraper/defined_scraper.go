@@ -105,6 +105,31 @@ func loadUrlCandidates(c Definition, ty ScrapeContentType) []*ByURLDefinition {
 	panic("loadUrlCandidates: unreachable")
 }
 
+// urlContentTypes are the content types that may be scraped by url.
+// ScrapeContentTypeMovie is omitted as it is equivalent to ScrapeContentTypeGroup.
+var urlContentTypes = []ScrapeContentType{
+	ScrapeContentTypePerformer,
+	ScrapeContentTypeScene,
+	ScrapeContentTypeGallery,
+	ScrapeContentTypeImage,
+	ScrapeContentTypeGroup,
+}
+
+func (g definedScraper) urlMatchLength(url string, ty ScrapeContentType) int {
+	ret := 0
+	for _, scraper := range loadUrlCandidates(g.config, ty) {
+		if !scraper.matchesURL(url) {
+			continue
+		}
+
+		if l := scraper.matchLength(url); l > ret {
+			ret = l
+		}
+	}
+
+	return ret
+}
+
 func (g definedScraper) viaURL(ctx context.Context, client *http.Client, url string, ty ScrapeContentType) (ScrapedContent, error) {
 	candidates := loadUrlCandidates(g.config, ty)
 	for _, scraper := range candidates {
@@ -125,6 +150,49 @@ func (g definedScraper) viaURL(ctx context.Context, client *http.Client, url str
 	return nil, nil
 }
 
+// viaURLMulti scrapes the url, returning all results. URL scrapers that are
+// not configured to return multiple results return at most one result.
+func (g definedScraper) viaURLMulti(ctx context.Context, client *http.Client, url string, ty ScrapeContentType) ([]ScrapedContent, error) {
+	candidates := loadUrlCandidates(g.config, ty)
+	for _, scraper := range candidates {
+		if !scraper.matchesURL(url) {
+			continue
+		}
+
+		u := replaceURL(url, *scraper) // allow a URL Replace for url-queries
+		s := g.config.getURLScraper(*scraper, client, g.globalConf)
+
+		if !scraper.Multiple {
+			ret, err := s.scrapeByURL(ctx, u, ty)
+			if err != nil {
+				return nil, err
+			}
+
+			if ret != nil {
+				return []ScrapedContent{ret}, nil
+			}
+
+			continue
+		}
+
+		ms, ok := s.(multiURLScraperActionImpl)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s action cannot return multiple results", ErrNotSupported, scraper.Action)
+		}
+
+		ret, err := ms.scrapeByURLMulti(ctx, u, ty)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(ret) > 0 {
+			return ret, nil
+		}
+	}
+
+	return nil, nil
+}
+
 func (g definedScraper) viaName(ctx context.Context, client *http.Client, name string, ty ScrapeContentType) ([]ScrapedContent, error) {
 	switch ty {
 	case ScrapeContentTypePerformer:
@@ -6,9 +6,14 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
+	"github.com/antchfx/xpath"
 	"gopkg.in/yaml.v2"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/sliceutil"
 )
 
 // Definition represents a scraper definition (typically) loaded from a YAML configuration file.
@@ -70,8 +75,28 @@ type Definition struct {
 	// Json scraping configurations
 	JsonScrapers mappedScrapers `yaml:"jsonScrapers"`
 
+	// XML scraping configurations
+	XMLScrapers mappedScrapers `yaml:"xmlScrapers"`
+
 	// Scraping driver options
 	DriverOptions *scraperDriverOptions `yaml:"driver"`
+
+	// User-Agent to use for requests made by this scraper.
+	// Overrides the global scraper User-Agent if set.
+	UserAgent string `yaml:"userAgent"`
+
+	// Maximum number of redirects followed by requests made by this scraper.
+	// Overrides the global scraper maximum if set.
+	MaxRedirects int `yaml:"maxRedirects"`
+
+	// SkipTLSVerify disables verification of TLS certificates for requests made
+	// by this scraper, regardless of the global scraper certificate check. This is
+	// insecure, and is intended for self-hosted services with self-signed certificates.
+	SkipTLSVerify bool `yaml:"skipTLSVerify"`
+
+	// DisableResponseCache prevents responses to requests made by this scraper
+	// from being cached and revalidated with conditional requests.
+	DisableResponseCache bool `yaml:"disableResponseCache"`
 }
 
 func (c Definition) validate() error {
@@ -79,6 +104,10 @@ func (c Definition) validate() error {
 		return errors.New("name must not be empty")
 	}
 
+	if c.MaxRedirects < 0 {
+		return errors.New("maxRedirects must not be negative")
+	}
+
 	if c.PerformerByName != nil {
 		if err := c.PerformerByName.validate(); err != nil {
 			return err
@@ -97,6 +126,12 @@ func (c Definition) validate() error {
 		}
 	}
 
+	for _, a := range c.nonURLActions() {
+		if a == scraperActionComposite {
+			return fmt.Errorf("%s action is only supported by url scrapers", a)
+		}
+	}
+
 	for _, s := range c.PerformerByURL {
 		if err := s.validate(); err != nil {
 			return err
@@ -119,12 +154,43 @@ func (c Definition) validate() error {
 		}
 	}
 
+	// only scene url scrapers may return multiple results
+	for _, defs := range [][]*ByURLDefinition{c.PerformerByURL, c.GalleryByURL, c.ImageByURL, c.MovieByURL, c.GroupByURL} {
+		for _, s := range defs {
+			if s.Multiple {
+				return errors.New("multiple is only supported by sceneByURL scrapers")
+			}
+		}
+	}
+
 	return nil
 }
 
+// nonURLActions returns the actions of the name and fragment scraper definitions.
+func (c Definition) nonURLActions() []scraperAction {
+	var ret []scraperAction
+	for _, d := range []*ByNameDefinition{c.PerformerByName, c.SceneByName} {
+		if d != nil {
+			ret = append(ret, d.Action)
+		}
+	}
+
+	for _, d := range []*ByFragmentDefinition{c.PerformerByFragment, c.SceneByFragment, c.GalleryByFragment, c.SceneByQueryFragment, c.ImageByFragment} {
+		if d != nil {
+			ret = append(ret, d.Action)
+		}
+	}
+
+	return ret
+}
+
 type stashServer struct {
 	URL    string `yaml:"url"`
 	ApiKey string `yaml:"apiKey"`
+	// MaxPhashDistance is the maximum distance between the phash of a scene
+	// and a scene on the stash server for the scenes to match. If nil, scenes
+	// are only matched by their checksum or oshash.
+	MaxPhashDistance *int `yaml:"maxPhashDistance"`
 }
 
 type ActionDefinition struct {
@@ -150,26 +216,167 @@ type ByURLDefinition struct {
 	URL                  []string             `yaml:"url,flow"`
 	QueryURL             string               `yaml:"queryURL"`
 	QueryURLReplacements queryURLReplacements `yaml:"queryURLReplace"`
+
+	// URLRegex is a list of regular expressions matched against the url.
+	// They are only tried if none of the URL patterns match.
+	URLRegex urlRegexps `yaml:"urlRegex,flow"`
+
+	// IgnoreQuery indicates that the query string and fragment of the url are
+	// ignored when matching it against URL and URLRegex. The url is scraped
+	// unchanged.
+	IgnoreQuery bool `yaml:"ignoreQuery"`
+
+	// Multiple indicates that the URL returns a list of results, such as a
+	// listing page. Only supported for scenes.
+	Multiple bool `yaml:"multiple"`
+
+	// Scrapers are the sub-scrapers run by the scrapeComposite action.
+	// Values scraped by earlier sub-scrapers take precedence.
+	Scrapers []compositeSubScraper `yaml:"scrapers"`
+}
+
+// urlRegexps is a list of url regular expressions. The expressions are
+// compiled when the definition is loaded, and an invalid expression fails the
+// load of the definition.
+type urlRegexps []*regexp.Regexp
+
+func (r *urlRegexps) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var patterns []string
+	if err := unmarshal(&patterns); err != nil {
+		return err
+	}
+
+	ret := make(urlRegexps, len(patterns))
+	for i, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid urlRegex %q: %w", pattern, err)
+		}
+		ret[i] = re
+	}
+
+	*r = ret
+	return nil
+}
+
+// compositeSubScraper is a scraper run by the scrapeComposite action.
+type compositeSubScraper struct {
+	// Action is either scrapeXPath or scrapeJson.
+	Action  scraperAction `yaml:"action"`
+	Scraper string        `yaml:"scraper"`
+
+	// JSONSelector is an xpath selector for the element of the page that
+	// contains the JSON document, such as a script element. Only used by
+	// scrapeJson sub-scrapers. If empty, the page is the JSON document.
+	JSONSelector string `yaml:"jsonSelector"`
+}
+
+func (c compositeSubScraper) validate() error {
+	switch c.Action {
+	case scraperActionXPath, scraperActionJson:
+	default:
+		return fmt.Errorf("%s is not a valid composite sub-scraper action", c.Action)
+	}
+
+	if c.JSONSelector != "" {
+		if c.Action != scraperActionJson {
+			return errors.New("jsonSelector is only supported by scrapeJson sub-scrapers")
+		}
+
+		if _, err := xpath.Compile(c.JSONSelector); err != nil {
+			return fmt.Errorf("invalid jsonSelector %q: %w", c.JSONSelector, err)
+		}
+	}
+
+	return nil
 }
 
 func (c ByURLDefinition) validate() error {
-	if len(c.URL) == 0 {
-		return errors.New("url is mandatory for scrape by url scrapers")
+	if len(c.URL) == 0 && len(c.URLRegex) == 0 {
+		return errors.New("url or urlRegex is mandatory for scrape by url scrapers")
+	}
+
+	if c.Action == scraperActionComposite {
+		if len(c.Scrapers) == 0 {
+			return errors.New("scrapers is mandatory for scrapeComposite action")
+		}
+
+		if c.Multiple {
+			return errors.New("multiple is not supported by scrapeComposite action")
+		}
+
+		for _, s := range c.Scrapers {
+			if err := s.validate(); err != nil {
+				return err
+			}
+		}
 	}
 
 	return c.ActionDefinition.validate()
 }
 
+// matchURL returns the part of url that is matched against the url patterns.
+func (c ByURLDefinition) matchURL(url string) string {
+	if c.IgnoreQuery {
+		if i := strings.IndexAny(url, "?#"); i >= 0 {
+			return url[:i]
+		}
+	}
+
+	return url
+}
+
 func (c ByURLDefinition) matchesURL(url string) bool {
+	url = c.matchURL(url)
+
 	for _, thisURL := range c.URL {
 		if strings.Contains(url, thisURL) {
 			return true
 		}
 	}
 
+	for _, re := range c.URLRegex {
+		if re.MatchString(url) {
+			return true
+		}
+	}
+
 	return false
 }
 
+// regexMatchLength returns the length of the leftmost match of the regular
+// expression in url. Returns -1 if the expression does not match.
+func regexMatchLength(re *regexp.Regexp, url string) int {
+	loc := re.FindStringIndex(url)
+	if loc == nil {
+		return -1
+	}
+
+	return loc[1] - loc[0]
+}
+
+// matchLength returns the length of the longest url pattern contained in url.
+// Regular expressions contribute the length of the text they match.
+// Returns 0 if no url pattern matches.
+func (c ByURLDefinition) matchLength(url string) int {
+	url = c.matchURL(url)
+
+	ret := 0
+	for _, thisURL := range c.URL {
+		if strings.Contains(url, thisURL) && len(thisURL) > ret {
+			ret = len(thisURL)
+		}
+	}
+
+	for _, re := range c.URLRegex {
+		if l := regexMatchLength(re, url); l > ret {
+			ret = l
+		}
+	}
+
+	return ret
+}
+
 type ByFragmentDefinition struct {
 	ActionDefinition `yaml:",inline"`
 
@@ -184,6 +391,12 @@ type ByNameDefinition struct {
 
 type scraperDebugOptions struct {
 	PrintHTML bool `yaml:"printHTML"`
+	// CaptureResponse stores the raw body of fetched responses in the
+	// ResponseCapture of the scrape context, if present.
+	CaptureResponse bool `yaml:"captureResponse"`
+	// CaptureLimit is the maximum number of bytes captured for each response.
+	// Defaults to defaultCaptureLimit if not set.
+	CaptureLimit int `yaml:"captureLimit"`
 }
 
 type scraperCookies struct {
@@ -233,6 +446,10 @@ func loadConfigFromYAML(id string, reader io.Reader) (*Definition, error) {
 		return nil, err
 	}
 
+	if ret.SkipTLSVerify {
+		logger.Warnf("[scraper %s] TLS certificate verification is disabled by skipTLSVerify. Connections made by this scraper are insecure.", id)
+	}
+
 	return ret, nil
 }
 
@@ -362,6 +579,76 @@ func (c Definition) supports(ty ScrapeContentType) bool {
 	panic("Unhandled ScrapeContentType")
 }
 
+// Capability describes how a scraper definition scrapes a content type.
+type Capability struct {
+	ContentType ScrapeContentType
+	// ScrapeTypes are the supported ways of scraping the content type.
+	ScrapeTypes []ScrapeType
+	// Actions are the distinct actions used, such as scrapeXPath or script.
+	Actions []string
+}
+
+func (c *Capability) add(ty ScrapeType, actions ...ActionDefinition) {
+	c.ScrapeTypes = sliceutil.AppendUnique(c.ScrapeTypes, ty)
+	for _, a := range actions {
+		c.Actions = sliceutil.AppendUnique(c.Actions, string(a.Action))
+	}
+}
+
+func (c *Capability) addURL(defs []*ByURLDefinition) {
+	for _, d := range defs {
+		c.add(ScrapeTypeURL, d.ActionDefinition)
+	}
+}
+
+// Capabilities returns the content types supported by the definition, along
+// with the scrape types and actions used for each. It follows the same rules
+// as supports. Movies are reported as groups.
+func (c Definition) Capabilities() []Capability {
+	performer := Capability{ContentType: ScrapeContentTypePerformer}
+	if c.PerformerByName != nil {
+		performer.add(ScrapeTypeName, c.PerformerByName.ActionDefinition)
+	}
+	if c.PerformerByFragment != nil {
+		performer.add(ScrapeTypeFragment, c.PerformerByFragment.ActionDefinition)
+	}
+	performer.addURL(c.PerformerByURL)
+
+	scene := Capability{ContentType: ScrapeContentTypeScene}
+	if c.SceneByName != nil && c.SceneByQueryFragment != nil {
+		scene.add(ScrapeTypeName, c.SceneByName.ActionDefinition, c.SceneByQueryFragment.ActionDefinition)
+	}
+	if c.SceneByFragment != nil {
+		scene.add(ScrapeTypeFragment, c.SceneByFragment.ActionDefinition)
+	}
+	scene.addURL(c.SceneByURL)
+
+	gallery := Capability{ContentType: ScrapeContentTypeGallery}
+	if c.GalleryByFragment != nil {
+		gallery.add(ScrapeTypeFragment, c.GalleryByFragment.ActionDefinition)
+	}
+	gallery.addURL(c.GalleryByURL)
+
+	image := Capability{ContentType: ScrapeContentTypeImage}
+	if c.ImageByFragment != nil {
+		image.add(ScrapeTypeFragment, c.ImageByFragment.ActionDefinition)
+	}
+	image.addURL(c.ImageByURL)
+
+	group := Capability{ContentType: ScrapeContentTypeGroup}
+	group.addURL(c.GroupByURL)
+	group.addURL(c.MovieByURL)
+
+	var ret []Capability
+	for _, capability := range []Capability{performer, scene, gallery, image, group} {
+		if len(capability.ScrapeTypes) > 0 {
+			ret = append(ret, capability)
+		}
+	}
+
+	return ret
+}
+
 func (c Definition) matchesURL(url string, ty ScrapeContentType) bool {
 	switch ty {
 	case ScrapeContentTypePerformer:
@@ -0,0 +1,201 @@
+package scraper
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestByURLDefinition_matchesURL(t *testing.T) {
+	def := ByURLDefinition{
+		URL: []string{"example.com/scene/"},
+		URLRegex: urlRegexps{
+			regexp.MustCompile(`^https?://(www\.)?example\.com/video\.php\?(.*&)?id=\d+`),
+		},
+	}
+
+	tests := []struct {
+		name      string
+		url       string
+		want      bool
+		wantMatch int
+	}{
+		{"literal", "https://example.com/scene/1", true, len("example.com/scene/")},
+		{"regex", "https://example.com/video.php?id=1", true, len("https://example.com/video.php?id=1")},
+		{"regex with other params", "https://www.example.com/video.php?lang=en&id=12&ref=home", true, len("https://www.example.com/video.php?lang=en&id=12")},
+		{"regex missing id", "https://example.com/video.php?lang=en", false, 0},
+		{"regex non-numeric id", "https://example.com/video.php?id=abc", false, 0},
+		{"other site", "https://other.com/video.php?id=1", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, def.matchesURL(tt.url))
+			assert.Equal(t, tt.wantMatch, def.matchLength(tt.url))
+		})
+	}
+}
+
+func TestByURLDefinition_matchesURLIgnoreQuery(t *testing.T) {
+	def := ByURLDefinition{
+		URLRegex: urlRegexps{
+			regexp.MustCompile(`^https?://example\.com/scene/\d+$`),
+		},
+		IgnoreQuery: true,
+	}
+
+	tests := []struct {
+		name      string
+		url       string
+		want      bool
+		wantMatch int
+	}{
+		{"no query", "https://example.com/scene/1", true, len("https://example.com/scene/1")},
+		{"query", "https://example.com/scene/1?utm_source=feed&ref=home", true, len("https://example.com/scene/1")},
+		{"fragment", "https://example.com/scene/1#comments", true, len("https://example.com/scene/1")},
+		{"other path", "https://example.com/scene/abc?id=1", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, def.matchesURL(tt.url))
+			assert.Equal(t, tt.wantMatch, def.matchLength(tt.url))
+		})
+	}
+
+	// the query is not ignored by default
+	def.IgnoreQuery = false
+	assert.False(t, def.matchesURL("https://example.com/scene/1?utm_source=feed"))
+}
+
+func TestByURLDefinition_validate(t *testing.T) {
+	action := ActionDefinition{Action: scraperActionXPath}
+
+	tests := []struct {
+		name    string
+		def     ByURLDefinition
+		wantErr bool
+	}{
+		{"url", ByURLDefinition{ActionDefinition: action, URL: []string{"example.com"}}, false},
+		{"regex only", ByURLDefinition{ActionDefinition: action, URLRegex: urlRegexps{regexp.MustCompile(`example\.com`)}}, false},
+		{"neither", ByURLDefinition{ActionDefinition: action}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.def.validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFromYAMLURLRegex(t *testing.T) {
+	const valid = `name: Test
+sceneByURL:
+  - action: scrapeXPath
+    scraper: sceneScraper
+    urlRegex:
+      - ^https?://example\.com/scene/\d+$
+`
+
+	def, err := loadConfigFromYAML("test", strings.NewReader(valid))
+	if assert.NoError(t, err) {
+		assert.True(t, def.SceneByURL[0].matchesURL("https://example.com/scene/1"))
+	}
+
+	const invalid = `name: Test
+sceneByURL:
+  - action: scrapeXPath
+    scraper: sceneScraper
+    urlRegex:
+      - example(
+`
+
+	_, err = loadConfigFromYAML("test", strings.NewReader(invalid))
+	assert.ErrorContains(t, err, "invalid urlRegex")
+}
+
+func TestDefinition_Capabilities(t *testing.T) {
+	xpath := ActionDefinition{Action: scraperActionXPath, Scraper: "scraper"}
+	json := ActionDefinition{Action: scraperActionJson, Scraper: "scraper"}
+	script := ActionDefinition{Action: scraperActionScript, Script: []string{"script.py"}}
+
+	tests := []struct {
+		name string
+		def  Definition
+		want []Capability
+	}{
+		{"none", Definition{}, nil},
+		{
+			"performer",
+			Definition{
+				PerformerByName:     &ByNameDefinition{ActionDefinition: xpath},
+				PerformerByFragment: &ByFragmentDefinition{ActionDefinition: script},
+				PerformerByURL: []*ByURLDefinition{
+					{ActionDefinition: xpath, URL: []string{"example.com"}},
+				},
+			},
+			[]Capability{
+				{ScrapeContentTypePerformer, []ScrapeType{ScrapeTypeName, ScrapeTypeFragment, ScrapeTypeURL}, []string{"scrapeXPath", "script"}},
+			},
+		},
+		{
+			"scene name requires query fragment",
+			Definition{
+				SceneByName: &ByNameDefinition{ActionDefinition: xpath},
+				SceneByURL: []*ByURLDefinition{
+					{ActionDefinition: json, URL: []string{"example.com"}},
+				},
+			},
+			[]Capability{
+				{ScrapeContentTypeScene, []ScrapeType{ScrapeTypeURL}, []string{"scrapeJson"}},
+			},
+		},
+		{
+			"scene name and gallery",
+			Definition{
+				SceneByName:          &ByNameDefinition{ActionDefinition: xpath},
+				SceneByQueryFragment: &ByFragmentDefinition{ActionDefinition: script},
+				GalleryByFragment:    &ByFragmentDefinition{ActionDefinition: script},
+			},
+			[]Capability{
+				{ScrapeContentTypeScene, []ScrapeType{ScrapeTypeName}, []string{"scrapeXPath", "script"}},
+				{ScrapeContentTypeGallery, []ScrapeType{ScrapeTypeFragment}, []string{"script"}},
+			},
+		},
+		{
+			"image and movies as groups",
+			Definition{
+				ImageByURL: []*ByURLDefinition{
+					{ActionDefinition: xpath, URL: []string{"example.com/images"}},
+					{ActionDefinition: json, URL: []string{"example.com/api/images"}},
+				},
+				MovieByURL: []*ByURLDefinition{
+					{ActionDefinition: xpath, URL: []string{"example.com/movies"}},
+				},
+			},
+			[]Capability{
+				{ScrapeContentTypeImage, []ScrapeType{ScrapeTypeURL}, []string{"scrapeXPath", "scrapeJson"}},
+				{ScrapeContentTypeGroup, []ScrapeType{ScrapeTypeURL}, []string{"scrapeXPath"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.def.Capabilities()
+			assert.Equal(t, tt.want, got)
+
+			// consistent with supports
+			for _, c := range got {
+				assert.True(t, tt.def.supports(c.ContentType))
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package scraper
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// FieldChange describes how the scraped value of a field compares to its existing value.
+type FieldChange string
+
+const (
+	// FieldUnchanged indicates that the scraped value is equal to the existing value.
+	FieldUnchanged FieldChange = "unchanged"
+	// FieldAdded indicates that the field has a scraped value but no existing value.
+	FieldAdded FieldChange = "added"
+	// FieldChanged indicates that the scraped value differs from the existing value.
+	FieldChanged FieldChange = "changed"
+)
+
+// FieldDiff compares the scraped value of a field to its existing value.
+type FieldDiff struct {
+	Field  string
+	Change FieldChange
+
+	// Existing and Scraped are the values of a single-valued field.
+	Existing string
+	Scraped  string
+
+	// Added and Removed are the values of a list field that are only present in
+	// the scraped or the existing values respectively. Values are compared
+	// case-insensitively.
+	Added   []string
+	Removed []string
+}
+
+// differ builds a list of FieldDiffs. Fields without a scraped value are omitted,
+// since applying the scraped result does not change them.
+type differ struct {
+	ret []FieldDiff
+}
+
+func (d *differ) string(field string, existing string, scraped *string) {
+	if scraped == nil || strings.TrimSpace(*scraped) == "" {
+		return
+	}
+
+	existing = strings.TrimSpace(existing)
+	s := strings.TrimSpace(*scraped)
+
+	change := FieldChanged
+	switch {
+	case existing == "":
+		change = FieldAdded
+	case existing == s:
+		change = FieldUnchanged
+	}
+
+	d.ret = append(d.ret, FieldDiff{
+		Field:    field,
+		Change:   change,
+		Existing: existing,
+		Scraped:  s,
+	})
+}
+
+func (d *differ) list(field string, existing []string, scraped []string) {
+	if len(scraped) == 0 {
+		return
+	}
+
+	contains := func(values []string, v string) bool {
+		for _, vv := range values {
+			if strings.EqualFold(strings.TrimSpace(vv), strings.TrimSpace(v)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	diff := FieldDiff{
+		Field: field,
+	}
+
+	for _, v := range scraped {
+		if !contains(existing, v) && !contains(diff.Added, v) {
+			diff.Added = append(diff.Added, v)
+		}
+	}
+
+	for _, v := range existing {
+		if !contains(scraped, v) {
+			diff.Removed = append(diff.Removed, v)
+		}
+	}
+
+	switch {
+	case len(existing) == 0:
+		diff.Change = FieldAdded
+	case len(diff.Added) == 0 && len(diff.Removed) == 0:
+		diff.Change = FieldUnchanged
+	default:
+		diff.Change = FieldChanged
+	}
+
+	d.ret = append(d.ret, diff)
+}
+
+func dateString(d *models.Date) string {
+	if d == nil {
+		return ""
+	}
+	return d.String()
+}
+
+func intString(i *int) string {
+	if i == nil {
+		return ""
+	}
+	return strconv.Itoa(*i)
+}
+
+func idStrings(ids []int) []string {
+	ret := make([]string, len(ids))
+	for i, id := range ids {
+		ret[i] = strconv.Itoa(id)
+	}
+	return ret
+}
+
+// storedIDOrName returns the stored ID of a matched scraped object, or its name
+// if it was not matched.
+func storedIDOrName(storedID *string, name string) string {
+	if storedID != nil && *storedID != "" {
+		return *storedID
+	}
+	return name
+}
+
+func scrapedTagValues(tags []*models.ScrapedTag) []string {
+	var ret []string
+	for _, t := range tags {
+		ret = append(ret, storedIDOrName(t.StoredID, t.Name))
+	}
+	return ret
+}
+
+// scrapedURLs returns the URLs of a scraped result, falling back to the
+// deprecated URL field if URLs is not set.
+func scrapedURLs(urls []string, url *string) []string {
+	if len(urls) == 0 && url != nil && *url != "" {
+		return []string{*url}
+	}
+	return urls
+}
+
+// DiffScene compares a scraped scene with an existing scene, returning the
+// differences in the fields that the scraped scene sets. The URLs, TagIDs and
+// PerformerIDs relationships of existing must be loaded.
+//
+// The existing scene references its studio, tags and performers by ID, so these
+// are compared with the stored IDs of the matched scraped objects. Unmatched
+// scraped objects are identified by name, and are always reported as added.
+func DiffScene(existing *models.Scene, scraped *models.ScrapedScene) []FieldDiff {
+	d := &differ{}
+
+	d.string("title", existing.Title, scraped.Title)
+	d.string("code", existing.Code, scraped.Code)
+	d.string("details", existing.Details, scraped.Details)
+	d.string("director", existing.Director, scraped.Director)
+	d.string("date", dateString(existing.Date), scraped.Date)
+	d.list("urls", existing.URLs.List(), scrapedURLs(scraped.URLs, scraped.URL))
+
+	if scraped.Studio != nil {
+		studio := storedIDOrName(scraped.Studio.StoredID, scraped.Studio.Name)
+		d.string("studio", intString(existing.StudioID), &studio)
+	}
+
+	d.list("tags", idStrings(existing.TagIDs.List()), scrapedTagValues(scraped.Tags))
+
+	var performers []string
+	for _, p := range scraped.Performers {
+		performers = append(performers, storedIDOrName(p.StoredID, derefString(p.Name)))
+	}
+	d.list("performers", idStrings(existing.PerformerIDs.List()), performers)
+
+	return d.ret
+}
+
+// DiffPerformer compares a scraped performer with an existing performer,
+// returning the differences in the fields that the scraped performer sets.
+// The Aliases, URLs and TagIDs relationships of existing must be loaded.
+//
+// Scraped aliases are split on commas. Tags are compared as in DiffScene.
+func DiffPerformer(existing *models.Performer, scraped *models.ScrapedPerformer) []FieldDiff {
+	d := &differ{}
+
+	var gender string
+	if existing.Gender != nil {
+		gender = existing.Gender.String()
+	}
+
+	var scrapedGender *string
+	if scraped.Gender != nil {
+		// gender values are upper case, but may be scraped in any case
+		g := strings.ToUpper(*scraped.Gender)
+		scrapedGender = &g
+	}
+
+	d.string("name", existing.Name, scraped.Name)
+	d.string("disambiguation", existing.Disambiguation, scraped.Disambiguation)
+	d.string("gender", gender, scrapedGender)
+	d.string("birthdate", dateString(existing.Birthdate), scraped.Birthdate)
+	d.string("death_date", dateString(existing.DeathDate), scraped.DeathDate)
+	d.string("ethnicity", existing.Ethnicity, scraped.Ethnicity)
+	d.string("country", existing.Country, scraped.Country)
+	d.string("eye_color", existing.EyeColor, scraped.EyeColor)
+	d.string("hair_color", existing.HairColor, scraped.HairColor)
+	d.string("height", intString(existing.Height), scraped.Height)
+	d.string("weight", intString(existing.Weight), scraped.Weight)
+	d.string("measurements", existing.Measurements, scraped.Measurements)
+	d.string("fake_tits", existing.FakeTits, scraped.FakeTits)
+	d.string("career_length", existing.CareerLength, scraped.CareerLength)
+	d.string("tattoos", existing.Tattoos, scraped.Tattoos)
+	d.string("piercings", existing.Piercings, scraped.Piercings)
+	d.string("details", existing.Details, scraped.Details)
+
+	var aliases []string
+	if scraped.Aliases != nil {
+		for _, a := range strings.Split(*scraped.Aliases, ",") {
+			if a = strings.TrimSpace(a); a != "" {
+				aliases = append(aliases, a)
+			}
+		}
+	}
+	d.list("aliases", existing.Aliases.List(), aliases)
+	d.list("urls", existing.URLs.List(), scrapedURLs(scraped.URLs, scraped.URL))
+	d.list("tags", idStrings(existing.TagIDs.List()), scrapedTagValues(scraped.Tags))
+
+	return d.ret
+}
@@ -0,0 +1,95 @@
+package scraper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffScene(t *testing.T) {
+	studioID := 3
+	date := models.Date{Time: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}
+	existing := &models.Scene{
+		Title:        "Existing Title",
+		Code:         "ABC-123",
+		Date:         &date,
+		StudioID:     &studioID,
+		URLs:         models.NewRelatedStrings([]string{"https://a.example/1", "https://b.example/1"}),
+		TagIDs:       models.NewRelatedIDs([]int{1, 2}),
+		PerformerIDs: models.NewRelatedIDs([]int{}),
+	}
+
+	scraped := &models.ScrapedScene{
+		Title:    strPtr("Scraped Title"),
+		Code:     strPtr("ABC-123"),
+		Details:  strPtr("Scraped Details"),
+		Date:     strPtr("2024-01-02"),
+		Studio:   &models.ScrapedStudio{StoredID: strPtr("3"), Name: "Studio"},
+		URLs:     []string{"https://A.example/1", "https://c.example/1"},
+		Tags:     []*models.ScrapedTag{{StoredID: strPtr("1"), Name: "Tag A"}, {Name: "New Tag"}},
+		Director: strPtr(""),
+		Performers: []*models.ScrapedPerformer{
+			{Name: strPtr("Performer")},
+		},
+	}
+
+	assert.Equal(t, []FieldDiff{
+		{Field: "title", Change: FieldChanged, Existing: "Existing Title", Scraped: "Scraped Title"},
+		{Field: "code", Change: FieldUnchanged, Existing: "ABC-123", Scraped: "ABC-123"},
+		{Field: "details", Change: FieldAdded, Scraped: "Scraped Details"},
+		// empty director is omitted
+		{Field: "date", Change: FieldUnchanged, Existing: "2024-01-02", Scraped: "2024-01-02"},
+		{
+			Field:   "urls",
+			Change:  FieldChanged,
+			Added:   []string{"https://c.example/1"},
+			Removed: []string{"https://b.example/1"},
+		},
+		{Field: "studio", Change: FieldUnchanged, Existing: "3", Scraped: "3"},
+		{
+			Field:   "tags",
+			Change:  FieldChanged,
+			Added:   []string{"New Tag"},
+			Removed: []string{"2"},
+		},
+		{Field: "performers", Change: FieldAdded, Added: []string{"Performer"}},
+	}, DiffScene(existing, scraped))
+}
+
+func TestDiffPerformer(t *testing.T) {
+	gender := models.GenderEnumFemale
+	height := 170
+	existing := &models.Performer{
+		Name:    "Performer",
+		Gender:  &gender,
+		Height:  &height,
+		Aliases: models.NewRelatedStrings([]string{"Alias One", "Alias Two"}),
+		URLs:    models.NewRelatedStrings([]string{"https://a.example/performer"}),
+		TagIDs:  models.NewRelatedIDs([]int{}),
+	}
+
+	scraped := &models.ScrapedPerformer{
+		Name:    strPtr("Performer"),
+		Gender:  strPtr("female"),
+		Height:  strPtr("172"),
+		Country: strPtr("US"),
+		Aliases: strPtr("alias one, Alias Three"),
+		URL:     strPtr("https://a.example/performer"),
+	}
+
+	assert.Equal(t, []FieldDiff{
+		{Field: "name", Change: FieldUnchanged, Existing: "Performer", Scraped: "Performer"},
+		{Field: "gender", Change: FieldUnchanged, Existing: "FEMALE", Scraped: "FEMALE"},
+		{Field: "country", Change: FieldAdded, Scraped: "US"},
+		{Field: "height", Change: FieldChanged, Existing: "170", Scraped: "172"},
+		{
+			Field:   "aliases",
+			Change:  FieldChanged,
+			Added:   []string{"Alias Three"},
+			Removed: []string{"Alias Two"},
+		},
+		{Field: "urls", Change: FieldUnchanged},
+	}, DiffPerformer(existing, scraped))
+}
@@ -0,0 +1,81 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/tidwall/gjson"
+	"golang.org/x/net/html"
+)
+
+// ScrapeDocument scrapes the provided document using the named xpath, json or
+// xml scraper configuration of the definition, returning a single result of the
+// given content type. The document is processed directly rather than being
+// loaded from a url, so that scraper configurations can be tested against
+// static documents. Sub-scrapers still load their urls using globalConfig.
+func (c Definition) ScrapeDocument(ctx context.Context, globalConfig GlobalConfig, scraperName string, doc string, ty ScrapeContentType) (ScrapedContent, error) {
+	client := newClient(globalConfig, newTransportPool(nil, nil))
+
+	if scraper, ok := c.XPathScrapers[scraperName]; ok {
+		node, err := html.Parse(strings.NewReader(doc))
+		if err != nil {
+			return nil, fmt.Errorf("parsing document: %w", err)
+		}
+
+		s := &xpathScraper{
+			definition:   c,
+			globalConfig: globalConfig,
+			client:       client,
+		}
+
+		return scraper.scrapeContent(ctx, s.getXPathQuery(node, ""), ty)
+	}
+
+	if scraper, ok := c.JsonScrapers[scraperName]; ok {
+		if !gjson.Valid(doc) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidJSON, documentSnippet(doc))
+		}
+
+		s := &jsonScraper{
+			definition:   c,
+			globalConfig: globalConfig,
+			client:       client,
+		}
+
+		return scraper.scrapeContent(ctx, s.getJsonQuery(doc, ""), ty)
+	}
+
+	if scraper, ok := c.XMLScrapers[scraperName]; ok {
+		node, err := xmlquery.Parse(strings.NewReader(doc))
+		if err != nil {
+			return nil, fmt.Errorf("parsing document: %w", err)
+		}
+
+		s := &xmlScraper{
+			definition:   c,
+			globalConfig: globalConfig,
+			client:       client,
+		}
+
+		return scraper.scrapeContent(ctx, s.getXMLQuery(node, ""), ty)
+	}
+
+	return nil, fmt.Errorf("xpath, json or xml scraper with name %s not found in config", scraperName)
+}
+
+// ScrapeDocumentWithProvenance scrapes the document as ScrapeDocument does,
+// additionally returning the provenance of each field of the scraped object,
+// keyed by field name. This allows scraper authors to see which selector and
+// raw value produced each field. The provenance of nested objects, such as
+// tags and studios, is not returned.
+func (c Definition) ScrapeDocumentWithProvenance(ctx context.Context, globalConfig GlobalConfig, scraperName string, doc string, ty ScrapeContentType) (ScrapedContent, map[string]FieldProvenance, error) {
+	prov := make(mappedProvenance)
+	ret, err := c.ScrapeDocument(withProvenance(ctx, prov), globalConfig, scraperName, doc, ty)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ret, prov, nil
+}
@@ -0,0 +1,163 @@
+package scraper
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+const scrapeDocumentYAML = `name: Test
+xPathScrapers:
+  htmlScraper:
+    scene:
+      Title: //h1
+      Date:
+        selector: //span[@class="date"]
+        postProcess:
+          - parseDate: January 2, 2006
+      Tags:
+        Name: //li[@class="tag"]
+jsonScrapers:
+  jsonScraper:
+    scene:
+      Title: title
+      Date:
+        selector: date
+        postProcess:
+          - parseDate: January 2, 2006
+      Tags:
+        Name: tags
+`
+
+func TestDefinition_ScrapeDocument(t *testing.T) {
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(scrapeDocumentYAML), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	const htmlDoc = `
+	<html>
+	<h1>Scene Title</h1>
+	<span class="date">March 23, 2001</span>
+	<ul>
+		<li class="tag">Tag 1</li>
+		<li class="tag">Tag 2</li>
+	</ul>
+	</html>
+	`
+
+	const jsonDoc = `{
+		"title": "Scene Title",
+		"date": "March 23, 2001",
+		"tags": ["Tag 1", "Tag 2"]
+	}`
+
+	tests := []struct {
+		name        string
+		scraperName string
+		doc         string
+	}{
+		{"xpath", "htmlScraper", htmlDoc},
+		{"json", "jsonScraper", jsonDoc},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := c.ScrapeDocument(context.Background(), mockGlobalConfig{}, tt.scraperName, tt.doc, ScrapeContentTypeScene)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			scene, ok := content.(*models.ScrapedScene)
+			if !assert.True(t, ok) {
+				return
+			}
+
+			verifyField(t, "Scene Title", scene.Title, "Title")
+			verifyField(t, "2001-03-23", scene.Date, "Date")
+
+			var tags []string
+			for _, tag := range scene.Tags {
+				tags = append(tags, tag.Name)
+			}
+			assert.Equal(t, []string{"Tag 1", "Tag 2"}, tags)
+		})
+	}
+
+	t.Run("invalid json", func(t *testing.T) {
+		_, err := c.ScrapeDocument(context.Background(), mockGlobalConfig{}, "jsonScraper", "<html></html>", ScrapeContentTypeScene)
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown scraper", func(t *testing.T) {
+		_, err := c.ScrapeDocument(context.Background(), mockGlobalConfig{}, "missing", htmlDoc, ScrapeContentTypeScene)
+		assert.Error(t, err)
+	})
+}
+
+func TestDefinition_ScrapeDocumentWithProvenance(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  sceneScraper:
+    common:
+      $info: //div[@class="info"]
+    scene:
+      Title:
+        selector: $info/h1
+        postProcess:
+          - replace:
+              - regex: ^Watch\s+
+                with:
+      Code:
+        fixed: ABC-123
+      Tags:
+        Name: //li[@class="tag"]
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	const htmlDoc = `<html>
+<div class="info"><h1>Watch Scene Title</h1></div>
+<ul><li class="tag">Tag 1</li></ul>
+</html>`
+
+	ctx := context.Background()
+	content, prov, err := c.ScrapeDocumentWithProvenance(ctx, mockGlobalConfig{}, "sceneScraper", htmlDoc, ScrapeContentTypeScene)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	scene, ok := content.(*models.ScrapedScene)
+	if !assert.True(t, ok) {
+		return
+	}
+
+	verifyField(t, "Scene Title", scene.Title, "Title")
+	verifyField(t, "ABC-123", scene.Code, "Code")
+
+	// the selector is recorded after applying the common fragments, along
+	// with the value before post-processing. Nested objects are not recorded.
+	assert.Equal(t, map[string]FieldProvenance{
+		"Title": {
+			Selector: `//div[@class="info"]/h1`,
+			Raw:      []string{"Watch Scene Title"},
+		},
+		"Code": {
+			Raw: []string{"ABC-123"},
+		},
+	}, prov)
+
+	// the result is the same as without provenance
+	want, err := c.ScrapeDocument(ctx, mockGlobalConfig{}, "sceneScraper", htmlDoc, ScrapeContentTypeScene)
+	assert.NoError(t, err)
+	assert.Equal(t, want, content)
+
+	_, _, err = c.ScrapeDocumentWithProvenance(ctx, mockGlobalConfig{}, "missing", htmlDoc, ScrapeContentTypeScene)
+	assert.Error(t, err)
+}
@@ -0,0 +1,109 @@
+package scraper
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/stashapp/stash/pkg/logger"
+)
+
+// envVarPrefix is the prefix required of environment variables used in
+// {env:VAR} placeholders. Other variables, which may hold credentials of the
+// stash process, are not available to scrapers.
+const envVarPrefix = "STASH_SCRAPER_"
+
+// minRedactLength is the minimum length of a substituted value that is
+// redacted from log messages. Shorter values, such as "1" or "true", are too
+// likely to appear in unrelated parts of a message.
+const minRedactLength = 8
+
+// envPlaceholderRE matches {env:VAR} placeholders, capturing the variable name.
+var envPlaceholderRE = regexp.MustCompile(`\{env:([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// envSecrets holds the values substituted for {env:VAR} placeholders, so that
+// they can be redacted from log messages.
+var envSecrets = &secretSet{}
+
+// secretSet holds secret values, keyed by the name of their variable.
+type secretSet struct {
+	mutex  sync.RWMutex
+	values map[string]string
+}
+
+func (s *secretSet) add(name string, value string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.values == nil {
+		s.values = make(map[string]string)
+	}
+	s.values[name] = value
+}
+
+// redact replaces any substituted values in str with their placeholders.
+// Values shorter than minRedactLength are not redacted. Longer values are
+// replaced first, so that values containing other values are redacted whole.
+func (s *secretSet) redact(str string) string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	names := make([]string, 0, len(s.values))
+	for name, value := range s.values {
+		if len(value) >= minRedactLength {
+			names = append(names, name)
+		}
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		vi, vj := s.values[names[i]], s.values[names[j]]
+		if len(vi) != len(vj) {
+			return len(vi) > len(vj)
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		str = strings.ReplaceAll(str, s.values[name], "{env:"+name+"}")
+	}
+
+	return str
+}
+
+// substituteEnv replaces {env:VAR} placeholders in str with the value of the
+// environment variable VAR. VAR must begin with envVarPrefix. Placeholders for
+// unset or disallowed variables are replaced with an empty string. The values
+// are secret, and must not be logged. Use redactSecrets on messages that may
+// include them.
+func substituteEnv(str string) string {
+	if !strings.Contains(str, "{env:") {
+		return str
+	}
+
+	return envPlaceholderRE.ReplaceAllStringFunc(str, func(m string) string {
+		name := envPlaceholderRE.FindStringSubmatch(m)[1]
+		if !strings.HasPrefix(name, envVarPrefix) {
+			logger.Warnf("[scraper] environment variable %s may not be used: only variables beginning with %s are allowed", name, envVarPrefix)
+			return ""
+		}
+
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			logger.Warnf("[scraper] environment variable %s is not set", name)
+			return ""
+		}
+
+		if value != "" {
+			envSecrets.add(name, value)
+		}
+		return value
+	})
+}
+
+// redactSecrets replaces the values substituted by substituteEnv in str with
+// their placeholders.
+func redactSecrets(str string) string {
+	return envSecrets.redact(str)
+}
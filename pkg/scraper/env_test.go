@@ -0,0 +1,181 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	testSecretEnv   = "STASH_SCRAPER_TEST_API_KEY"
+	testSecretValue = "s3cr3t-api-key"
+)
+
+// recordingLogger records all logged messages.
+type recordingLogger struct {
+	logger.LoggerImpl
+
+	mutex    sync.Mutex
+	messages []string
+}
+
+func (l *recordingLogger) record(format string, args ...interface{}) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Tracef(format string, args ...interface{}) { l.record(format, args...) }
+func (l *recordingLogger) Debugf(format string, args ...interface{}) { l.record(format, args...) }
+func (l *recordingLogger) Infof(format string, args ...interface{})  { l.record(format, args...) }
+func (l *recordingLogger) Warnf(format string, args ...interface{})  { l.record(format, args...) }
+func (l *recordingLogger) Errorf(format string, args ...interface{}) { l.record(format, args...) }
+func (l *recordingLogger) Debug(args ...interface{})                 { l.record("%s", fmt.Sprint(args...)) }
+func (l *recordingLogger) Info(args ...interface{})                  { l.record("%s", fmt.Sprint(args...)) }
+func (l *recordingLogger) Warn(args ...interface{})                  { l.record("%s", fmt.Sprint(args...)) }
+func (l *recordingLogger) Error(args ...interface{})                 { l.record("%s", fmt.Sprint(args...)) }
+
+func (l *recordingLogger) output() string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return strings.Join(l.messages, "\n")
+}
+
+func recordLogs(t *testing.T) *recordingLogger {
+	t.Helper()
+
+	l := &recordingLogger{LoggerImpl: &logger.BasicLogger{}}
+	old := logger.Logger
+	logger.Logger = l
+	t.Cleanup(func() {
+		logger.Logger = old
+	})
+
+	return l
+}
+
+func Test_substituteEnv(t *testing.T) {
+	t.Setenv(testSecretEnv, testSecretValue)
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"no placeholder", "//div", "//div"},
+		{"placeholder", "Bearer {env:" + testSecretEnv + "}", "Bearer " + testSecretValue},
+		{"unset", "key={env:STASH_SCRAPER_TEST_UNSET}", "key="},
+		{"invalid name", "{env:1ABC}", "{env:1ABC}"},
+		{"disallowed", "key={env:TEST_DISALLOWED_API_KEY}", "key="},
+	}
+
+	t.Setenv("TEST_DISALLOWED_API_KEY", testSecretValue)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, substituteEnv(tt.value))
+		})
+	}
+
+	assert.Equal(t, "error in {env:"+testSecretEnv+"}", redactSecrets("error in "+testSecretValue))
+}
+
+func Test_secretSet_redact(t *testing.T) {
+	s := &secretSet{}
+	s.add("STASH_SCRAPER_FLAG", "true")
+	s.add("STASH_SCRAPER_A", "same-secret-value")
+	s.add("STASH_SCRAPER_B", "same-secret-value")
+	s.add("STASH_SCRAPER_LONG", "same-secret-value-longer")
+
+	// short values are not redacted
+	assert.Equal(t, "enabled: true", s.redact("enabled: true"))
+
+	// values are stored for each variable, and longer values are redacted first
+	assert.Len(t, s.values, 4)
+	assert.Equal(t, "{env:STASH_SCRAPER_A} {env:STASH_SCRAPER_LONG}", s.redact("same-secret-value same-secret-value-longer"))
+}
+
+func TestEnvSubstitutionXPath(t *testing.T) {
+	t.Setenv(testSecretEnv, testSecretValue)
+	logs := recordLogs(t)
+
+	const yamlStr = `name: Test
+xPathScrapers:
+  performerScraper:
+    performer:
+      Name: //div[@data-key="{env:` + testSecretEnv + `}"]
+      Details:
+        fixed: "key: {env:` + testSecretEnv + `}"
+      Country: //div[@data-key="{env:` + testSecretEnv + `}"
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	const html = `<html>
+<div data-key="` + testSecretValue + `">Jane Doe</div>
+<div data-key="other">Other</div>
+</html>`
+
+	doc, err := htmlquery.Parse(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("Error loading document: %s", err.Error())
+	}
+
+	q := &xpathQuery{
+		doc: doc,
+	}
+
+	performer, err := c.XPathScrapers["performerScraper"].scrapePerformer(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping performer: %s", err.Error())
+	}
+
+	if assert.NotNil(t, performer) {
+		assert.Equal(t, "Jane Doe", *performer.Name)
+		assert.Equal(t, "key: "+testSecretValue, *performer.Details)
+		assert.Nil(t, performer.Country)
+	}
+
+	// the invalid Country selector is logged without the secret
+	out := logs.output()
+	assert.Contains(t, out, "key 'Country'")
+	assert.NotContains(t, out, testSecretValue)
+}
+
+func TestLoadURLEnvHeader(t *testing.T) {
+	t.Setenv(testSecretEnv, testSecretValue)
+	logs := recordLogs(t)
+
+	var gotHeader string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("Authorization")
+		fmt.Fprint(w, "<html></html>")
+	}))
+	defer ts.Close()
+
+	def := Definition{
+		DriverOptions: &scraperDriverOptions{
+			Headers: []*header{{Key: "Authorization", Value: "Bearer {env:" + testSecretEnv + "}"}},
+		},
+	}
+
+	_, err := loadURL(context.Background(), ts.URL, &http.Client{}, def, mockGlobalConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer "+testSecretValue, gotHeader)
+
+	out := logs.output()
+	assert.Contains(t, out, "adding header <Authorization:Bearer {env:"+testSecretEnv+"}>")
+	assert.NotContains(t, out, testSecretValue)
+}
@@ -0,0 +1,86 @@
+package scraper
+
+import (
+	"context"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+var genderMapping = map[string]models.GenderEnum{
+	"f":      models.GenderEnumFemale,
+	"female": models.GenderEnumFemale,
+	"girl":   models.GenderEnumFemale,
+	"lady":   models.GenderEnumFemale,
+	"woman":  models.GenderEnumFemale,
+
+	"m":    models.GenderEnumMale,
+	"male": models.GenderEnumMale,
+	"man":  models.GenderEnumMale,
+	"guy":  models.GenderEnumMale,
+	"boy":  models.GenderEnumMale,
+
+	"transgender female": models.GenderEnumTransgenderFemale,
+	"transgender woman":  models.GenderEnumTransgenderFemale,
+	"trans female":       models.GenderEnumTransgenderFemale,
+	"trans woman":        models.GenderEnumTransgenderFemale,
+	"transwoman":         models.GenderEnumTransgenderFemale,
+	"mtf":                models.GenderEnumTransgenderFemale,
+	"m2f":                models.GenderEnumTransgenderFemale,
+	"tgirl":              models.GenderEnumTransgenderFemale,
+	"t girl":             models.GenderEnumTransgenderFemale,
+	"ts":                 models.GenderEnumTransgenderFemale,
+
+	"transgender male": models.GenderEnumTransgenderMale,
+	"transgender man":  models.GenderEnumTransgenderMale,
+	"trans male":       models.GenderEnumTransgenderMale,
+	"trans man":        models.GenderEnumTransgenderMale,
+	"transman":         models.GenderEnumTransgenderMale,
+	"ftm":              models.GenderEnumTransgenderMale,
+	"f2m":              models.GenderEnumTransgenderMale,
+
+	"intersex": models.GenderEnumIntersex,
+
+	"non binary":  models.GenderEnumNonBinary,
+	"nonbinary":   models.GenderEnumNonBinary,
+	"nb":          models.GenderEnumNonBinary,
+	"enby":        models.GenderEnumNonBinary,
+	"genderqueer": models.GenderEnumNonBinary,
+}
+
+// lookupGender returns the gender for the name. The name is matched
+// case-insensitively, treating hyphens and underscores as spaces, so that
+// the gender values themselves, such as TRANSGENDER_FEMALE, are recognised.
+func lookupGender(name string) (models.GenderEnum, bool) {
+	key := strings.ToLower(name)
+	key = strings.NewReplacer("-", " ", "_", " ").Replace(key)
+	key = strings.Join(strings.Fields(key), " ")
+
+	v, exists := genderMapping[key]
+	return v, exists
+}
+
+// postProcessGender converts gender names to gender values, such as FEMALE or
+// TRANSGENDER_MALE. The map holds additional names, which take precedence over
+// the built-in names. Names are matched case-insensitively. The original value
+// is returned if it is not recognised.
+type postProcessGender mappedOverridesConfig
+
+func (p *postProcessGender) Apply(ctx context.Context, value string, q mappedQuery) string {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return value
+	}
+
+	if gender, ok := mappedOverridesConfig(*p).lookup(trimmed); ok {
+		return gender
+	}
+
+	if gender, ok := lookupGender(trimmed); ok {
+		return gender.String()
+	}
+
+	logger.Warnf("Gender %q was not recognized", trimmed)
+	return value
+}
@@ -2,6 +2,7 @@ package scraper
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -89,10 +90,12 @@ type imageGetter struct {
 	requestModifier func(req *http.Request)
 }
 
-func (i *imageGetter) getImage(ctx context.Context, url string) (*string, error) {
+// fetchImage gets the image at the provided url, returning the image data
+// and its content type.
+func (i *imageGetter) fetchImage(ctx context.Context, url string) ([]byte, string, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	userAgent := i.globalConfig.GetScraperUserAgent()
@@ -114,26 +117,35 @@ func (i *imageGetter) getImage(ctx context.Context, url string) (*string, error)
 	resp, err := i.client.Do(req)
 
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("http error %d", resp.StatusCode)
+		return nil, "", fmt.Errorf("http error %d", resp.StatusCode)
 	}
 
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	// determine the image type and set the base64 type
+	// determine the image type
 	contentType := resp.Header.Get("Content-Type")
 	if contentType == "" {
 		contentType = http.DetectContentType(body)
 	}
 
+	return body, contentType, nil
+}
+
+func (i *imageGetter) getImage(ctx context.Context, url string) (*string, error) {
+	body, contentType, err := i.fetchImage(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
 	img := "data:" + contentType + ";base64," + utils.GetBase64StringFromData(body)
 	return &img, nil
 }
@@ -147,6 +159,71 @@ func getImage(ctx context.Context, url string, client *http.Client, globalConfig
 	return g.getImage(ctx, url)
 }
 
+// DownloadImage returns the data and content type of a scraped image value.
+// The value may be a base64 encoded data URI, or a URL which is downloaded
+// using the http client of the scraper with the provided ID, so that options
+// such as skipTLSVerify apply. If scraperID is empty, the default scraper http
+// client is used. Returns an error if the data is not an image.
+func (c Cache) DownloadImage(ctx context.Context, scraperID string, image string) ([]byte, string, error) {
+	var data []byte
+	var contentType string
+	var err error
+
+	if strings.HasPrefix(image, "data:") {
+		data, contentType, err = decodeImageDataURI(image)
+	} else {
+		client := c.client
+		if scraperID != "" {
+			s := c.findScraper(scraperID)
+			if s == nil {
+				return nil, "", fmt.Errorf("%w: id %s", ErrNotFound, scraperID)
+			}
+			client = c.scraperClient(s)
+		}
+
+		g := imageGetter{
+			client:       client,
+			globalConfig: c.globalConfig,
+		}
+		data, contentType, err = g.fetchImage(ctx, image)
+	}
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	// fall back to the detected content type if the provided one is not an image type
+	if !strings.HasPrefix(contentType, "image/") {
+		contentType = http.DetectContentType(data)
+	}
+
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, "", fmt.Errorf("%w: content type %s", ErrNotImage, contentType)
+	}
+
+	return data, contentType, nil
+}
+
+// decodeImageDataURI returns the data and content type of a base64 encoded data URI.
+func decodeImageDataURI(uri string) ([]byte, string, error) {
+	header, encoded, found := strings.Cut(strings.TrimPrefix(uri, "data:"), ",")
+	if !found {
+		return nil, "", errors.New("invalid data URI")
+	}
+
+	contentType, isBase64 := strings.CutSuffix(header, ";base64")
+	if !isBase64 {
+		return nil, "", errors.New("data URI is not base64 encoded")
+	}
+
+	data, err := utils.GetDataFromBase64String(encoded)
+	if err != nil {
+		return nil, "", fmt.Errorf("decoding data URI: %w", err)
+	}
+
+	return data, contentType, nil
+}
+
 func getStashPerformerImage(ctx context.Context, stashURL string, performerID string, imageGetter imageGetter) (*string, error) {
 	return imageGetter.getImage(ctx, stashURL+"/performer/"+performerID+"/image")
 }
@@ -0,0 +1,123 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeTestPNG(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestCache_DownloadImage(t *testing.T) {
+	imgData := makeTestPNG(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/image.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write(imgData)
+		case "/untyped":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, _ = w.Write(imgData)
+		case "/page.html":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	c := Cache{
+		client:       server.Client(),
+		globalConfig: mockGlobalConfig{},
+	}
+
+	dataURI := "data:image/png;base64," + utils.GetBase64StringFromData(imgData)
+
+	tests := []struct {
+		name            string
+		image           string
+		wantContentType string
+		wantErr         bool
+	}{
+		{"http", server.URL + "/image.png", "image/png", false},
+		{"http detected type", server.URL + "/untyped", "image/png", false},
+		{"http not image", server.URL + "/page.html", "", true},
+		{"http not found", server.URL + "/missing.png", "", true},
+		{"data uri", dataURI, "image/png", false},
+		{"data uri not base64", "data:image/png,abc", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, contentType, err := c.DownloadImage(context.Background(), "", tt.image)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantContentType, contentType)
+			assert.Equal(t, imgData, data)
+		})
+	}
+}
+
+func TestCache_DownloadImageSkipTLSVerify(t *testing.T) {
+	imgData := makeTestPNG(t)
+
+	// the test server uses a self-signed certificate
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(imgData)
+	}))
+	defer server.Close()
+
+	gc := certCheckGlobalConfig{}
+	newScraper := func(id string, skip bool) definedScraper {
+		return scraperFromDefinition(Definition{ID: id, Name: id, SkipTLSVerify: skip}, gc)
+	}
+
+	c := Cache{
+		client: newClient(gc, newTransportPool(nil, nil)),
+		scrapers: map[string]scraper{
+			"secure":   newScraper("secure", false),
+			"insecure": newScraper("insecure", true),
+		},
+		globalConfig: gc,
+	}
+
+	ctx := context.Background()
+	imageURL := server.URL + "/image.png"
+
+	_, _, err := c.DownloadImage(ctx, "", imageURL)
+	assert.Error(t, err)
+
+	_, _, err = c.DownloadImage(ctx, "secure", imageURL)
+	assert.Error(t, err)
+
+	data, _, err := c.DownloadImage(ctx, "insecure", imageURL)
+	if assert.NoError(t, err) {
+		assert.Equal(t, imgData, data)
+	}
+
+	_, _, err = c.DownloadImage(ctx, "missing", imageURL)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -2,7 +2,6 @@ package scraper
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -42,7 +41,7 @@ func (s *jsonScraper) loadURL(ctx context.Context, url string) (string, error) {
 
 	docStr := string(doc)
 	if !gjson.Valid(docStr) {
-		return "", errors.New("not valid json")
+		return "", fmt.Errorf("%w: %s", ErrInvalidJSON, documentSnippet(docStr))
 	}
 
 	if s.definition.DebugOptions != nil && s.definition.DebugOptions.PrintHTML {
@@ -52,6 +51,19 @@ func (s *jsonScraper) loadURL(ctx context.Context, url string) (string, error) {
 	return docStr, err
 }
 
+// maxSnippetLength is the maximum length of a document included in an error message.
+const maxSnippetLength = 100
+
+// documentSnippet returns the start of the document, for use in error messages.
+func documentSnippet(doc string) string {
+	doc = strings.TrimSpace(doc)
+	if len(doc) > maxSnippetLength {
+		return strings.ToValidUTF8(doc[:maxSnippetLength], "") + "..."
+	}
+
+	return doc
+}
+
 type jsonURLScraper struct {
 	jsonScraper
 	definition ByURLDefinition
@@ -69,42 +81,33 @@ func (s *jsonURLScraper) scrapeByURL(ctx context.Context, url string, ty ScrapeC
 	}
 
 	q := s.getJsonQuery(doc, url)
-	// if these just return the return values from scraper.scrape* functions then
-	// it ends up returning ScrapedContent(nil) rather than nil
-	switch ty {
-	case ScrapeContentTypePerformer:
-		ret, err := scraper.scrapePerformer(ctx, q)
-		if err != nil || ret == nil {
-			return nil, err
-		}
-		return ret, nil
-	case ScrapeContentTypeScene:
-		ret, err := scraper.scrapeScene(ctx, q)
-		if err != nil || ret == nil {
-			return nil, err
-		}
-		return ret, nil
-	case ScrapeContentTypeGallery:
-		ret, err := scraper.scrapeGallery(ctx, q)
-		if err != nil || ret == nil {
-			return nil, err
-		}
-		return ret, nil
-	case ScrapeContentTypeImage:
-		ret, err := scraper.scrapeImage(ctx, q)
-		if err != nil || ret == nil {
-			return nil, err
-		}
-		return ret, nil
-	case ScrapeContentTypeMovie, ScrapeContentTypeGroup:
-		ret, err := scraper.scrapeGroup(ctx, q)
-		if err != nil || ret == nil {
+	if ty == ScrapeContentTypeScene && s.definition.Multiple {
+		// use the first scene from the list
+		q.setType(SearchQuery)
+		scenes, err := scraper.scrapeScenes(ctx, q)
+		if err != nil || len(scenes) == 0 {
 			return nil, err
 		}
-		return ret, nil
+		return scenes[0], nil
 	}
 
-	return nil, ErrNotSupported
+	return scraper.scrapeContent(ctx, q, ty)
+}
+
+// scrapeByURLMulti scrapes a URL which returns a list of results.
+// Only scenes are supported.
+func (s *jsonURLScraper) scrapeByURLMulti(ctx context.Context, url string, ty ScrapeContentType) ([]ScrapedContent, error) {
+	scraper, err := s.getJsonScraper(s.definition.Scraper)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := s.loadURL(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	return scraper.scrapeContentList(ctx, s.getJsonQuery(doc, url), ty)
 }
 
 type jsonNameScraper struct {
@@ -138,7 +141,7 @@ func (s *jsonNameScraper) scrapeByName(ctx context.Context, name string, ty Scra
 	var content []ScrapedContent
 	switch ty {
 	case ScrapeContentTypePerformer:
-		performers, err := scraper.scrapePerformers(ctx, q)
+		performers, err := scraper.scrapeJSONPerformers(ctx, q)
 		if err != nil {
 			return nil, err
 		}
@@ -299,6 +302,10 @@ func (q *jsonQuery) getURL() string {
 	return q.url
 }
 
+func (q *jsonQuery) getClient() *http.Client {
+	return q.scraper.client
+}
+
 func (q *jsonQuery) runQuery(selector string) ([]string, error) {
 	value := gjson.Get(q.doc, selector)
 
@@ -332,3 +339,114 @@ func (q *jsonQuery) subScrape(ctx context.Context, value string) mappedQuery {
 
 	return q.scraper.getJsonQuery(doc, value)
 }
+
+// jsonArrayPrefix splits a selector of the form prefix.#.field into the path
+// of the array and the path of the field in each element. The prefix is empty
+// for selectors of the form #.field. ok is false if the selector does not
+// query the fields of an array.
+func jsonArrayPrefix(selector string) (prefix string, field string, ok bool) {
+	if strings.HasPrefix(selector, "#.") {
+		return "", selector[2:], true
+	}
+
+	if i := strings.Index(selector, ".#."); i >= 0 {
+		return selector[:i], selector[i+3:], true
+	}
+
+	return "", "", false
+}
+
+// jsonElementQuery is a query on a single element of an array in a json
+// document. Selectors of the form prefix.#.field are run against the element,
+// while other selectors are run against the whole document.
+type jsonElementQuery struct {
+	*jsonQuery
+	prefix  string
+	element string
+}
+
+func (q *jsonElementQuery) runQuery(selector string) ([]string, error) {
+	if prefix, field, ok := jsonArrayPrefix(selector); ok && prefix == q.prefix {
+		elementQuery := *q.jsonQuery
+		elementQuery.doc = q.element
+		return elementQuery.runQuery(field)
+	}
+
+	return q.jsonQuery.runQuery(selector)
+}
+
+// performersArrayPrefix returns the path of the array that the performer
+// selectors query, if all of the selectors that query an array query the
+// same one.
+func (s mappedScraper) performersArrayPrefix(q mappedQuery) (string, bool) {
+	var ret string
+	found := false
+	for _, attrConfig := range s.Performer.mappedConfig {
+		if attrConfig.Selector == "" {
+			continue
+		}
+
+		selector := mappedConfig{}.prepareSelector(q, s.Common, attrConfig.Selector)
+		prefix, _, ok := jsonArrayPrefix(selector)
+		if !ok {
+			continue
+		}
+
+		if found && prefix != ret {
+			return "", false
+		}
+
+		ret = prefix
+		found = true
+	}
+
+	return ret, found
+}
+
+// scrapeJSONPerformers scrapes multiple performers from a json document.
+// If the performer selectors query the fields of the same array, such as
+// performers.#.name and performers.#.image, then each element of the array is
+// scraped as a separate performer. This keeps the fields of each performer
+// together when some elements are missing a field, which is not possible
+// when the fields are queried as parallel arrays.
+// Otherwise, the performers are scraped as in scrapePerformers.
+func (s mappedScraper) scrapeJSONPerformers(ctx context.Context, q *jsonQuery) ([]*models.ScrapedPerformer, error) {
+	if s.Performer == nil {
+		return nil, nil
+	}
+
+	prefix, ok := s.performersArrayPrefix(q)
+	if !ok {
+		return s.scrapePerformers(ctx, q)
+	}
+
+	if err := s.checkRequired(q); err != nil {
+		return nil, err
+	}
+
+	array := gjson.Parse(q.doc)
+	if prefix != "" {
+		array = array.Get(prefix)
+	}
+
+	if !array.IsArray() {
+		return nil, nil
+	}
+
+	var ret []*models.ScrapedPerformer
+	array.ForEach(func(_, v gjson.Result) bool {
+		elementQuery := &jsonElementQuery{
+			jsonQuery: q,
+			prefix:    prefix,
+			element:   v.Raw,
+		}
+
+		results := s.process(ctx, elementQuery, s.Performer.mappedConfig, performerIsMulti)
+		if len(results) > 0 {
+			ret = append(ret, results[0].scrapedPerformer())
+		}
+		return true
+	})
+
+	return ret, nil
+}
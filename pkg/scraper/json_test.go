@@ -2,8 +2,14 @@ package scraper
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v2"
 )
 
@@ -116,3 +122,184 @@ jsonScrapers:
 		t.Errorf("expected nil scraped performer when not found, got %v", scrapedPerformer)
 	}
 }
+
+func TestJsonScraperFailureModes(t *testing.T) {
+	const errorPage = `<html><body>Internal Server Error</body></html>`
+
+	responses := map[string]string{
+		"/invalid": errorPage,
+		"/empty":   `{}`,
+		"/valid":   `{"data": {"name": "Performer Name"}}`,
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, responses[r.URL.Path])
+	}))
+	defer ts.Close()
+
+	yamlStr := `name: Test
+performerByURL:
+  - action: scrapeJson
+    url:
+      - ` + ts.URL + `
+    scraper: performerScraper
+jsonScrapers:
+  performerScraper:
+    required:
+      - data.name
+    performer:
+      Name: data.name
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	s := scraperFromDefinition(*c, mockGlobalConfig{})
+	client := &http.Client{}
+	ctx := context.Background()
+
+	t.Run("invalid json", func(t *testing.T) {
+		_, err := s.viaURL(ctx, client, ts.URL+"/invalid", ScrapeContentTypePerformer)
+		assert.ErrorIs(t, err, ErrInvalidJSON)
+		if err != nil {
+			assert.Contains(t, err.Error(), errorPage)
+		}
+	})
+
+	t.Run("empty json", func(t *testing.T) {
+		_, err := s.viaURL(ctx, client, ts.URL+"/empty", ScrapeContentTypePerformer)
+		assert.ErrorIs(t, err, ErrRequiredNotFound)
+		if err != nil {
+			assert.Contains(t, err.Error(), "data.name")
+		}
+	})
+
+	t.Run("valid json", func(t *testing.T) {
+		content, err := s.viaURL(ctx, client, ts.URL+"/valid", ScrapeContentTypePerformer)
+		if assert.NoError(t, err) {
+			performer, ok := content.(*models.ScrapedPerformer)
+			if assert.True(t, ok) {
+				verifyField(t, "Performer Name", performer.Name, "Name")
+			}
+		}
+	})
+}
+
+func TestJsonPerformersScraper(t *testing.T) {
+	const yamlStr = `name: Test
+jsonScrapers:
+  performerSearch:
+    common:
+      $performers: data.performers
+    performer:
+      Name: $performers.#.name
+      URL:
+        selector: $performers.#.id
+        postProcess:
+          - replace:
+              - regex: ^
+                with: https://example.com/performers/
+      Image: $performers.#.image
+      Country:
+        fixed: Narnia
+`
+
+	// the second performer has no image, so the parallel image array is one
+	// element shorter than the name array
+	const json = `{
+	"data": {
+		"performers": [
+			{"id": "1", "name": "First", "image": "https://example.com/1.jpg"},
+			{"id": "2", "name": "Second"},
+			{"id": "3", "name": "Third", "image": "https://example.com/3.jpg"}
+		]
+	}
+}`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	q := &jsonQuery{
+		doc:       json,
+		queryType: SearchQuery,
+	}
+
+	performers, err := c.JsonScrapers["performerSearch"].scrapeJSONPerformers(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping performers: %s", err.Error())
+	}
+
+	if !assert.Len(t, performers, 3) {
+		return
+	}
+
+	type performer struct {
+		name  string
+		url   string
+		image *string
+	}
+
+	image1 := "https://example.com/1.jpg"
+	image3 := "https://example.com/3.jpg"
+	want := []performer{
+		{"First", "https://example.com/performers/1", &image1},
+		{"Second", "https://example.com/performers/2", nil},
+		{"Third", "https://example.com/performers/3", &image3},
+	}
+
+	for i, w := range want {
+		p := performers[i]
+		verifyField(t, w.name, p.Name, "Name")
+		verifyField(t, w.url, p.URL, "URL")
+		assert.Equal(t, w.image, p.Image, "Image")
+		// selectors not on the array apply to every performer
+		verifyField(t, "Narnia", p.Country, "Country")
+	}
+}
+
+func TestJsonPerformersScraperParallelArrays(t *testing.T) {
+	// selectors on different arrays are scraped as parallel arrays
+	const yamlStr = `name: Test
+jsonScrapers:
+  performerSearch:
+    performer:
+      Name: names.#.value
+      Image: images.#.value
+`
+
+	const json = `{
+	"names": [{"value": "First"}, {"value": "Second"}],
+	"images": [{"value": "https://example.com/1.jpg"}, {"value": "https://example.com/2.jpg"}]
+}`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	q := &jsonQuery{
+		doc:       json,
+		queryType: SearchQuery,
+	}
+
+	performers, err := c.JsonScrapers["performerSearch"].scrapeJSONPerformers(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping performers: %s", err.Error())
+	}
+
+	if assert.Len(t, performers, 2) {
+		verifyField(t, "Second", performers[1].Name, "Name")
+		verifyField(t, "https://example.com/2.jpg", performers[1].Image, "Image")
+	}
+}
+
+func TestDocumentSnippet(t *testing.T) {
+	long := strings.Repeat("a", maxSnippetLength+10)
+
+	assert.Equal(t, "short", documentSnippet("  short\n"))
+	assert.Equal(t, strings.Repeat("a", maxSnippetLength)+"...", documentSnippet(long))
+}
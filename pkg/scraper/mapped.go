@@ -2,6 +2,9 @@ package scraper
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"strings"
 
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
@@ -13,6 +16,8 @@ type mappedQuery interface {
 	setType(QueryType)
 	subScrape(ctx context.Context, value string) mappedQuery
 	getURL() string
+	// getClient returns the http client used to load the document.
+	getClient() *http.Client
 }
 
 type mappedScrapers map[string]mappedScraper
@@ -27,13 +32,116 @@ type mappedScraper struct {
 
 	// deprecated
 	Movie *mappedMovieScraperConfig `yaml:"movie"`
+
+	// Required is a list of selectors that must return a non-empty result.
+	// If any do not, then the scrape fails with ErrRequiredNotFound.
+	Required []string `yaml:"required"`
+
+	// Substitutions maps scraped values to replacement values. It is applied
+	// to every scraped value, after any per-field post-processing.
+	Substitutions map[string]string `yaml:"substitutions"`
+
+	// DedupeTags indicates that scraped tags with the same name, ignoring case
+	// and whitespace, are merged into the first such tag.
+	DedupeTags bool `yaml:"dedupeTags"`
+}
+
+// process processes the config and applies the scraper's substitutions to the results.
+func (s mappedScraper) process(ctx context.Context, q mappedQuery, c mappedConfig, isMulti isMultiFunc) mappedResults {
+	return c.process(ctx, q, s.Common, isMulti).substitute(s.Substitutions)
+}
+
+// processFields processes the config of the fields of the scraped object as
+// process does, recording their provenance if requested by the context.
+// Nested objects, such as tags and studios, are processed with process.
+func (s mappedScraper) processFields(ctx context.Context, q mappedQuery, c mappedConfig, isMulti isMultiFunc) mappedResults {
+	return c.processCapture(ctx, q, s.Common, isMulti, provenanceFromContext(ctx)).substitute(s.Substitutions)
+}
+
+// scrapedTags returns the tags of the results, merging duplicate tags if
+// DedupeTags is set.
+func (s mappedScraper) scrapedTags(r mappedResults) []*models.ScrapedTag {
+	ret := r.scrapedTags()
+	if s.DedupeTags {
+		ret = dedupeTags(ret)
+	}
+
+	return ret
+}
+
+// dedupeTags returns the tags with duplicates removed. Tags are duplicates if
+// their names are equal after lowercasing and collapsing whitespace. The first
+// tag with each name is kept.
+func dedupeTags(tags []*models.ScrapedTag) []*models.ScrapedTag {
+	seen := make(map[string]bool, len(tags))
+	var ret []*models.ScrapedTag
+	for _, t := range tags {
+		key := strings.ToLower(strings.Join(strings.Fields(t.Name), " "))
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		ret = append(ret, t)
+	}
+
+	return ret
+}
+
+// checkRequired returns an error if any of the required selectors return an
+// empty result.
+func (s mappedScraper) checkRequired(q mappedQuery) error {
+	var missing []string
+	for _, r := range s.Required {
+		selector := mappedConfig{}.prepareSelector(q, s.Common, r)
+		found, err := q.runQuery(selector)
+		if err != nil {
+			return fmt.Errorf("required selector %q: %w", r, err)
+		}
+
+		if len(found) == 0 {
+			missing = append(missing, r)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrRequiredNotFound, strings.Join(missing, ", "))
+	}
+
+	return nil
 }
 
 func urlsIsMulti(key string) bool {
 	return key == "URLs"
 }
 
+// performerIsMulti returns true for keys that may have multiple values when
+// scraping a single performer.
+func performerIsMulti(key string) bool {
+	switch key {
+	case "Aliases", "Tattoos", "Piercings":
+		return true
+	}
+	return urlsIsMulti(key)
+}
+
+// groupIsMulti returns true for keys that may have multiple values when
+// scraping a single group.
+func groupIsMulti(key string) bool {
+	return urlsIsMulti(key) || key == "Aliases"
+}
+
+// galleryIsMulti returns true for keys that may have multiple values when
+// scraping a single gallery.
+func galleryIsMulti(key string) bool {
+	return urlsIsMulti(key) || key == "Images"
+}
+
 func (s mappedScraper) scrapePerformer(ctx context.Context, q mappedQuery) (*models.ScrapedPerformer, error) {
+	if err := s.checkRequired(q); err != nil {
+		return nil, err
+	}
+
 	var ret *models.ScrapedPerformer
 
 	performerMap := s.Performer
@@ -43,14 +151,14 @@ func (s mappedScraper) scrapePerformer(ctx context.Context, q mappedQuery) (*mod
 
 	performerTagsMap := performerMap.Tags
 
-	results := performerMap.process(ctx, q, s.Common, urlsIsMulti)
+	results := s.processFields(ctx, q, performerMap.mappedConfig, performerIsMulti)
 
 	// now apply the tags
 	var tagResults mappedResults
 
 	if performerTagsMap != nil {
 		logger.Debug(`Processing performer tags:`)
-		tagResults = performerTagsMap.process(ctx, q, s.Common, nil)
+		tagResults = s.process(ctx, q, performerTagsMap, nil)
 	}
 
 	if len(results) == 0 {
@@ -59,20 +167,39 @@ func (s mappedScraper) scrapePerformer(ctx context.Context, q mappedQuery) (*mod
 
 	if len(results) > 0 {
 		ret = results[0].scrapedPerformer()
-		ret.Tags = tagResults.scrapedTags()
+		ret.Tags = s.scrapedTags(tagResults)
+
+		if performerMap.CustomFields != nil {
+			logger.Debug(`Processing performer custom fields:`)
+			ret.CustomFields = s.process(ctx, q, performerMap.CustomFields, nil).customFields(0)
+		}
+
+		if performerMap.Links != nil {
+			logger.Debug(`Processing performer links:`)
+			s.process(ctx, q, performerMap.Links, nil).setPerformerLinks(ret)
+		}
+
+		if performerMap.Scenes != nil {
+			logger.Debug(`Processing performer scenes:`)
+			ret.Scenes = s.process(ctx, q, performerMap.Scenes, nil).scrapedScenes()
+		}
 	}
 
 	return ret, nil
 }
 
 func (s mappedScraper) scrapePerformers(ctx context.Context, q mappedQuery) ([]*models.ScrapedPerformer, error) {
+	if err := s.checkRequired(q); err != nil {
+		return nil, err
+	}
+
 	performerMap := s.Performer
 	if performerMap == nil {
 		return nil, nil
 	}
 
 	// isMulti is nil because it will behave incorrect when scraping multiple performers
-	results := performerMap.process(ctx, q, s.Common, nil)
+	results := s.process(ctx, q, performerMap.mappedConfig, nil)
 	return results.scrapedPerformers(), nil
 }
 
@@ -85,37 +212,113 @@ func (s mappedScraper) processSceneRelationships(ctx context.Context, q mappedQu
 	sceneStudioMap := sceneScraperConfig.Studio
 	sceneMoviesMap := sceneScraperConfig.Movies
 	sceneGroupsMap := sceneScraperConfig.Groups
+	sceneMarkersMap := sceneScraperConfig.Markers
+	sceneCustomFieldsMap := sceneScraperConfig.CustomFields
 
 	ret.Performers = s.processPerformers(ctx, scenePerformersMap, q)
 
 	if sceneTagsMap != nil {
 		logger.Debug(`Processing scene tags:`)
 
-		ret.Tags = sceneTagsMap.process(ctx, q, s.Common, nil).scrapedTags()
+		ret.Tags = s.scrapedTags(s.process(ctx, q, sceneTagsMap, nil))
 	}
 
-	if sceneStudioMap != nil {
+	if sceneStudioMap.mappedConfig != nil {
 		logger.Debug(`Processing scene studio:`)
-		studioResults := sceneStudioMap.process(ctx, q, s.Common, nil)
-
-		if len(studioResults) > 0 && resultIndex < len(studioResults) {
+		studioResults := s.processStudios(ctx, q, sceneStudioMap)
+		parentResults := s.processStudioParents(ctx, q, sceneStudioMap)
+
+		if q.getType() != SearchQuery && len(studioResults) > 1 {
+			// a single scene may have multiple studios
+			ret.Studios = studioResults.scrapedStudios()
+			for i, studio := range ret.Studios {
+				studio.Parent = parentResults.scrapedStudioAt(i)
+			}
+			if len(ret.Studios) > 0 {
+				ret.Studio = ret.Studios[0]
+			}
+		} else if len(studioResults) > 0 && resultIndex < len(studioResults) {
 			// when doing a `search` scrape get the related studio
 			studio := studioResults[resultIndex].scrapedStudio()
+			studio.Parent = parentResults.scrapedStudioAt(resultIndex)
 			ret.Studio = studio
 		}
 	}
 
 	if sceneMoviesMap != nil {
 		logger.Debug(`Processing scene movies:`)
-		ret.Movies = sceneMoviesMap.process(ctx, q, s.Common, nil).scrapedMovies()
+		ret.Movies = s.process(ctx, q, sceneMoviesMap, nil).scrapedMovies()
 	}
 
 	if sceneGroupsMap != nil {
 		logger.Debug(`Processing scene groups:`)
-		ret.Groups = sceneGroupsMap.process(ctx, q, s.Common, nil).scrapedGroups()
+		ret.Groups = s.process(ctx, q, sceneGroupsMap, nil).scrapedGroups()
+	}
+
+	if sceneMarkersMap != nil {
+		logger.Debug(`Processing scene markers:`)
+		ret.Markers = s.process(ctx, q, sceneMarkersMap, nil).scrapedSceneMarkers()
+	}
+
+	if sceneCustomFieldsMap != nil {
+		logger.Debug(`Processing scene custom fields:`)
+		ret.CustomFields = s.process(ctx, q, sceneCustomFieldsMap, nil).customFields(resultIndex)
+	}
+
+	return len(ret.Performers) > 0 || len(ret.Tags) > 0 || ret.Studio != nil || len(ret.Movies) > 0 || len(ret.Groups) > 0 || len(ret.Markers) > 0 || len(ret.CustomFields) > 0
+}
+
+// processStudios returns the studio results of the studio config. Studios
+// with a URL but no name are sub-scraped using the SubScraper config, if set.
+func (s mappedScraper) processStudios(ctx context.Context, q mappedQuery, studioMap mappedStudioScraperConfig) mappedResults {
+	results := s.process(ctx, q, studioMap.mappedConfig, nil)
+
+	// don't sub-scrape the studio of each search result
+	if studioMap.SubScraper == nil || q.getType() == SearchQuery {
+		return results
 	}
 
-	return len(ret.Performers) > 0 || len(ret.Tags) > 0 || ret.Studio != nil || len(ret.Movies) > 0 || len(ret.Groups) > 0
+	for _, r := range results {
+		if name, _ := r.string("Name"); strings.TrimSpace(name) != "" {
+			continue
+		}
+
+		studioURL, _ := r.string("URL")
+		if studioURL == "" {
+			continue
+		}
+
+		sq := q.subScrape(ctx, studioURL)
+		if sq == nil {
+			continue
+		}
+
+		logger.Debug(`Processing studio sub-scraper:`)
+		subResults := s.process(ctx, sq, studioMap.SubScraper, nil)
+		if len(subResults) == 0 {
+			continue
+		}
+
+		// values scraped from the original page take precedence
+		for k, v := range subResults[0] {
+			if _, found := r[k]; !found {
+				r[k] = v
+			}
+		}
+	}
+
+	return results
+}
+
+// processStudioParents returns the parent studio results of the studio config.
+// The parent at each index belongs to the studio at the same index.
+func (s mappedScraper) processStudioParents(ctx context.Context, q mappedQuery, studioMap mappedStudioScraperConfig) mappedResults {
+	if studioMap.Parent == nil {
+		return nil
+	}
+
+	logger.Debug(`Processing studio parent:`)
+	return s.process(ctx, q, studioMap.Parent, nil)
 }
 
 func (s mappedScraper) processPerformers(ctx context.Context, performersMap mappedPerformerScraperConfig, q mappedQuery) []*models.ScrapedPerformer {
@@ -125,23 +328,20 @@ func (s mappedScraper) processPerformers(ctx context.Context, performersMap mapp
 	if performersMap.mappedConfig != nil {
 		logger.Debug(`Processing performers:`)
 		// isMulti is nil because it will behave incorrect when scraping multiple performers
-		performerResults := performersMap.process(ctx, q, s.Common, nil)
+		performerResults := s.process(ctx, q, performersMap.mappedConfig, nil)
 
 		scenePerformerTagsMap := performersMap.Tags
 
 		// process performer tags once
 		var performerTagResults mappedResults
 		if scenePerformerTagsMap != nil {
-			performerTagResults = scenePerformerTagsMap.process(ctx, q, s.Common, nil)
+			performerTagResults = s.process(ctx, q, scenePerformerTagsMap, nil)
 		}
 
-		for _, p := range performerResults {
+		for _, p := range performerResults.nonEmpty() {
 			performer := p.scrapedPerformer()
 
-			for _, p := range performerTagResults {
-				tag := p.scrapedTag()
-				performer.Tags = append(performer.Tags, tag)
-			}
+			performer.Tags = s.scrapedTags(performerTagResults)
 
 			ret = append(ret, performer)
 		}
@@ -151,6 +351,10 @@ func (s mappedScraper) processPerformers(ctx context.Context, performersMap mapp
 }
 
 func (s mappedScraper) scrapeScenes(ctx context.Context, q mappedQuery) ([]*models.ScrapedScene, error) {
+	if err := s.checkRequired(q); err != nil {
+		return nil, err
+	}
+
 	var ret []*models.ScrapedScene
 
 	sceneScraperConfig := s.Scene
@@ -161,7 +365,7 @@ func (s mappedScraper) scrapeScenes(ctx context.Context, q mappedQuery) ([]*mode
 
 	logger.Debug(`Processing scenes:`)
 	// urlsIsMulti is nil because it will behave incorrect when scraping multiple scenes
-	results := sceneMap.process(ctx, q, s.Common, nil)
+	results := s.process(ctx, q, sceneMap, nil)
 	for i, r := range results {
 		logger.Debug(`Processing scene:`)
 
@@ -174,6 +378,10 @@ func (s mappedScraper) scrapeScenes(ctx context.Context, q mappedQuery) ([]*mode
 }
 
 func (s mappedScraper) scrapeScene(ctx context.Context, q mappedQuery) (*models.ScrapedScene, error) {
+	if err := s.checkRequired(q); err != nil {
+		return nil, err
+	}
+
 	sceneScraperConfig := s.Scene
 	if sceneScraperConfig == nil {
 		return nil, nil
@@ -182,9 +390,9 @@ func (s mappedScraper) scrapeScene(ctx context.Context, q mappedQuery) (*models.
 	sceneMap := sceneScraperConfig.mappedConfig
 
 	logger.Debug(`Processing scene:`)
-	results := sceneMap.process(ctx, q, s.Common, urlsIsMulti)
+	results := s.processFields(ctx, q, sceneMap, urlsIsMulti)
 
-	var ret *models.ScrapedScene
+	ret := &models.ScrapedScene{}
 	if len(results) > 0 {
 		ret = results[0].scrapedScene()
 	}
@@ -201,6 +409,10 @@ func (s mappedScraper) scrapeScene(ctx context.Context, q mappedQuery) (*models.
 }
 
 func (s mappedScraper) scrapeImage(ctx context.Context, q mappedQuery) (*models.ScrapedImage, error) {
+	if err := s.checkRequired(q); err != nil {
+		return nil, err
+	}
+
 	var ret models.ScrapedImage
 
 	imageScraperConfig := s.Image
@@ -215,7 +427,7 @@ func (s mappedScraper) scrapeImage(ctx context.Context, q mappedQuery) (*models.
 	imageStudioMap := imageScraperConfig.Studio
 
 	logger.Debug(`Processing image:`)
-	results := imageMap.process(ctx, q, s.Common, urlsIsMulti)
+	results := s.processFields(ctx, q, imageMap, urlsIsMulti)
 
 	if len(results) > 0 {
 		ret = *results[0].scrapedImage()
@@ -224,20 +436,21 @@ func (s mappedScraper) scrapeImage(ctx context.Context, q mappedQuery) (*models.
 	// now apply the performers and tags
 	if imagePerformersMap != nil {
 		logger.Debug(`Processing image performers:`)
-		ret.Performers = imagePerformersMap.process(ctx, q, s.Common, nil).scrapedPerformers()
+		ret.Performers = s.process(ctx, q, imagePerformersMap, nil).scrapedPerformers()
 	}
 
 	if imageTagsMap != nil {
 		logger.Debug(`Processing image tags:`)
-		ret.Tags = imageTagsMap.process(ctx, q, s.Common, nil).scrapedTags()
+		ret.Tags = s.scrapedTags(s.process(ctx, q, imageTagsMap, nil))
 	}
 
-	if imageStudioMap != nil {
+	if imageStudioMap.mappedConfig != nil {
 		logger.Debug(`Processing image studio:`)
-		studioResults := imageStudioMap.process(ctx, q, s.Common, nil)
+		studioResults := s.processStudios(ctx, q, imageStudioMap)
 
 		if len(studioResults) > 0 {
 			ret.Studio = studioResults[0].scrapedStudio()
+			ret.Studio.Parent = s.processStudioParents(ctx, q, imageStudioMap).scrapedStudioAt(0)
 		}
 	}
 
@@ -250,6 +463,10 @@ func (s mappedScraper) scrapeImage(ctx context.Context, q mappedQuery) (*models.
 }
 
 func (s mappedScraper) scrapeGallery(ctx context.Context, q mappedQuery) (*models.ScrapedGallery, error) {
+	if err := s.checkRequired(q); err != nil {
+		return nil, err
+	}
+
 	var ret models.ScrapedGallery
 
 	galleryScraperConfig := s.Gallery
@@ -264,7 +481,7 @@ func (s mappedScraper) scrapeGallery(ctx context.Context, q mappedQuery) (*model
 	galleryStudioMap := galleryScraperConfig.Studio
 
 	logger.Debug(`Processing gallery:`)
-	results := galleryMap.process(ctx, q, s.Common, urlsIsMulti)
+	results := s.processFields(ctx, q, galleryMap, galleryIsMulti)
 
 	if len(results) > 0 {
 		ret = *results[0].scrapedGallery()
@@ -273,23 +490,25 @@ func (s mappedScraper) scrapeGallery(ctx context.Context, q mappedQuery) (*model
 	// now apply the performers and tags
 	if galleryPerformersMap != nil {
 		logger.Debug(`Processing gallery performers:`)
-		performerResults := galleryPerformersMap.process(ctx, q, s.Common, urlsIsMulti)
+		// isMulti is nil because it will behave incorrect when scraping multiple performers
+		performerResults := s.process(ctx, q, galleryPerformersMap, nil)
 
 		ret.Performers = performerResults.scrapedPerformers()
 	}
 
 	if galleryTagsMap != nil {
 		logger.Debug(`Processing gallery tags:`)
-		tagResults := galleryTagsMap.process(ctx, q, s.Common, nil)
-		ret.Tags = tagResults.scrapedTags()
+		tagResults := s.process(ctx, q, galleryTagsMap, nil)
+		ret.Tags = s.scrapedTags(tagResults)
 	}
 
-	if galleryStudioMap != nil {
+	if galleryStudioMap.mappedConfig != nil {
 		logger.Debug(`Processing gallery studio:`)
-		studioResults := galleryStudioMap.process(ctx, q, s.Common, nil)
+		studioResults := s.processStudios(ctx, q, galleryStudioMap)
 
 		if len(studioResults) > 0 {
 			ret.Studio = studioResults[0].scrapedStudio()
+			ret.Studio.Parent = s.processStudioParents(ctx, q, galleryStudioMap).scrapedStudioAt(0)
 		}
 	}
 
@@ -302,6 +521,10 @@ func (s mappedScraper) scrapeGallery(ctx context.Context, q mappedQuery) (*model
 }
 
 func (s mappedScraper) scrapeGroup(ctx context.Context, q mappedQuery) (*models.ScrapedGroup, error) {
+	if err := s.checkRequired(q); err != nil {
+		return nil, err
+	}
+
 	var ret models.ScrapedGroup
 
 	// try group scraper first, falling back to movie
@@ -319,27 +542,28 @@ func (s mappedScraper) scrapeGroup(ctx context.Context, q mappedQuery) (*models.
 	groupStudioMap := groupScraperConfig.Studio
 	groupTagsMap := groupScraperConfig.Tags
 
-	results := groupMap.process(ctx, q, s.Common, urlsIsMulti)
+	results := s.processFields(ctx, q, groupMap, groupIsMulti)
 
 	if len(results) > 0 {
 		ret = *results[0].scrapedGroup()
 	}
 
-	if groupStudioMap != nil {
+	if groupStudioMap.mappedConfig != nil {
 		logger.Debug(`Processing group studio:`)
-		studioResults := groupStudioMap.process(ctx, q, s.Common, nil)
+		studioResults := s.processStudios(ctx, q, groupStudioMap)
 
 		if len(studioResults) > 0 {
 			ret.Studio = studioResults[0].scrapedStudio()
+			ret.Studio.Parent = s.processStudioParents(ctx, q, groupStudioMap).scrapedStudioAt(0)
 		}
 	}
 
 	// now apply the tags
 	if groupTagsMap != nil {
 		logger.Debug(`Processing group tags:`)
-		tagResults := groupTagsMap.process(ctx, q, s.Common, nil)
+		tagResults := s.process(ctx, q, groupTagsMap, nil)
 
-		ret.Tags = tagResults.scrapedTags()
+		ret.Tags = s.scrapedTags(tagResults)
 	}
 
 	if len(results) == 0 && ret.Studio == nil && len(ret.Tags) == 0 {
@@ -348,3 +572,66 @@ func (s mappedScraper) scrapeGroup(ctx context.Context, q mappedQuery) (*models.
 
 	return &ret, nil
 }
+
+// scrapeContent scrapes a single result of the given content type.
+func (s mappedScraper) scrapeContent(ctx context.Context, q mappedQuery, ty ScrapeContentType) (ScrapedContent, error) {
+	// if these just return the return values from scraper.scrape* functions then
+	// it ends up returning ScrapedContent(nil) rather than nil
+	switch ty {
+	case ScrapeContentTypePerformer:
+		ret, err := s.scrapePerformer(ctx, q)
+		if err != nil || ret == nil {
+			return nil, err
+		}
+		return ret, nil
+	case ScrapeContentTypeScene:
+		ret, err := s.scrapeScene(ctx, q)
+		if err != nil || ret == nil {
+			return nil, err
+		}
+		return ret, nil
+	case ScrapeContentTypeGallery:
+		ret, err := s.scrapeGallery(ctx, q)
+		if err != nil || ret == nil {
+			return nil, err
+		}
+		return ret, nil
+	case ScrapeContentTypeImage:
+		ret, err := s.scrapeImage(ctx, q)
+		if err != nil || ret == nil {
+			return nil, err
+		}
+		return ret, nil
+	case ScrapeContentTypeMovie, ScrapeContentTypeGroup:
+		ret, err := s.scrapeGroup(ctx, q)
+		if err != nil || ret == nil {
+			return nil, err
+		}
+		return ret, nil
+	}
+
+	return nil, ErrNotSupported
+}
+
+// scrapeContentList scrapes a page which returns a list of results, such as a
+// listing page. The results are processed in the same way as search results.
+// Only scenes are supported.
+func (s mappedScraper) scrapeContentList(ctx context.Context, q mappedQuery, ty ScrapeContentType) ([]ScrapedContent, error) {
+	if ty != ScrapeContentTypeScene {
+		return nil, fmt.Errorf("%w: cannot scrape multiple %v results by URL", ErrNotSupported, ty)
+	}
+
+	q.setType(SearchQuery)
+
+	scenes, err := s.scrapeScenes(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	var content []ScrapedContent
+	for _, scene := range scenes {
+		content = append(content, scene)
+	}
+
+	return content, nil
+}
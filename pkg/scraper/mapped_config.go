@@ -3,11 +3,16 @@ package scraper
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/url"
+	"regexp"
+	"sort"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/sliceutil"
+	"github.com/stashapp/stash/pkg/sliceutil/stringslice"
 	"gopkg.in/yaml.v2"
 )
 
@@ -46,57 +51,238 @@ func extractHostname(urlStr string) string {
 
 type isMultiFunc func(key string) bool
 
+// prepareSelector applies the common fragments, input URL and environment
+// variable placeholders to the selector.
+func (s mappedConfig) prepareSelector(q mappedQuery, common commonMappedConfig, selector string) string {
+	selector = s.applyCommon(common, selector)
+	// Support {inputURL} and {inputHostname} placeholders in selectors
+	selector = strings.ReplaceAll(selector, "{inputURL}", q.getURL())
+	selector = strings.ReplaceAll(selector, "{inputHostname}", extractHostname(q.getURL()))
+	return substituteEnv(selector)
+}
+
+// guardQuery is implemented by queries that evaluate when selectors
+// themselves, such as xpath queries, where a selector may return a scalar
+// value such as false or 0 instead of a node set.
+type guardQuery interface {
+	evaluateGuard(selector string) (bool, error)
+}
+
+// guardPasses returns true if the when selector returns a non-empty result.
+// Queries implementing guardQuery determine whether the result is empty.
+func (s mappedConfig) guardPasses(q mappedQuery, common commonMappedConfig, when string) bool {
+	selector := s.prepareSelector(q, common, when)
+
+	var passes bool
+	var err error
+	if gq, ok := q.(guardQuery); ok {
+		passes, err = gq.evaluateGuard(selector)
+	} else {
+		var found []string
+		found, err = q.runQuery(selector)
+		passes = len(found) > 0
+	}
+
+	if err != nil {
+		logger.Warnf("when '%v': %v", when, redactSecrets(err.Error()))
+		return false
+	}
+
+	return passes
+}
+
+// FieldProvenance records where the value of a scraped field came from.
+type FieldProvenance struct {
+	// Selector is the prepared selector that was queried. It is empty for
+	// fixed values and values extracted from the page URL.
+	Selector string
+	// Raw holds the values before post-processing.
+	Raw []string
+}
+
+// mappedProvenance maps result keys to the provenance of their values.
+type mappedProvenance map[string]FieldProvenance
+
+// record sets the provenance of key. It does nothing if p is nil, so that
+// provenance is only captured when requested.
+func (p mappedProvenance) record(key string, selector string, raw []string) {
+	if p == nil {
+		return
+	}
+
+	p[key] = FieldProvenance{
+		Selector: selector,
+		Raw:      raw,
+	}
+}
+
 func (s mappedConfig) process(ctx context.Context, q mappedQuery, common commonMappedConfig, isMulti isMultiFunc) mappedResults {
+	return s.processCapture(ctx, q, common, isMulti, nil)
+}
+
+type mappedProvenanceKey struct{}
+
+// withProvenance returns a context in which the provenance of the fields of
+// the scraped object is recorded in prov.
+func withProvenance(ctx context.Context, prov mappedProvenance) context.Context {
+	return context.WithValue(ctx, mappedProvenanceKey{}, prov)
+}
+
+// provenanceFromContext returns the provenance set by withProvenance, or nil
+// if provenance is not being recorded.
+func provenanceFromContext(ctx context.Context) mappedProvenance {
+	p, _ := ctx.Value(mappedProvenanceKey{}).(mappedProvenance)
+	return p
+}
+
+// processCapture processes the config, recording the provenance of each key
+// in prov if it is not nil.
+func (s mappedConfig) processCapture(ctx context.Context, q mappedQuery, common commonMappedConfig, isMulti isMultiFunc, prov mappedProvenance) mappedResults {
 	var ret mappedResults
+	var deferred []string
 
 	for k, attrConfig := range s {
+		if attrConfig.When != "" && !s.guardPasses(q, common, attrConfig.When) {
+			logger.Debugf("key '%v': when guard not matched, skipping", k)
+			continue
+		}
+
+		if attrConfig.Fixed == "" && attrConfig.hasFromField() {
+			// process after the other keys, so that the referenced fields are set
+			deferred = append(deferred, k)
+			continue
+		}
+
+		if attrConfig.Fixed == "" && attrConfig.Selector == "" && attrConfig.hasExtractFromURL() {
+			// the value is taken from the page URL rather than the document
+			if value := attrConfig.postProcess(ctx, "", q); value != "" {
+				ret = ret.setSingleValue(0, k, value)
+				prov.record(k, "", []string{q.getURL()})
+			}
+			continue
+		}
 
 		if attrConfig.Fixed != "" {
 			// TODO - not sure if this needs to set _all_ indexes for the key
 			const i = 0
-			// Support {inputURL} and {inputHostname} placeholders in fixed values
+			// Support {inputURL}, {inputHostname} and {env:VAR} placeholders in fixed values
 			value := strings.ReplaceAll(attrConfig.Fixed, "{inputURL}", q.getURL())
 			value = strings.ReplaceAll(value, "{inputHostname}", extractHostname(q.getURL()))
+			value = substituteEnv(value)
 			ret = ret.setSingleValue(i, k, value)
+			prov.record(k, "", []string{value})
 		} else {
-			selector := attrConfig.Selector
-			selector = s.applyCommon(common, selector)
-			// Support {inputURL} and {inputHostname} placeholders in selectors
-			selector = strings.ReplaceAll(selector, "{inputURL}", q.getURL())
-			selector = strings.ReplaceAll(selector, "{inputHostname}", extractHostname(q.getURL()))
+			selector := s.prepareSelector(q, common, attrConfig.Selector)
 
 			found, err := q.runQuery(selector)
 			if err != nil {
-				logger.Warnf("key '%v': %v", k, err)
+				logger.Warnf("key '%v': %v", k, redactSecrets(err.Error()))
 			}
 
-			if len(found) > 0 {
-				result := s.postProcess(ctx, q, attrConfig, found)
+			if len(found) > 0 && attrConfig.hasColumns() {
+				// each found value is a row, which sets the values of multiple keys
+				if attrConfig.Coalesce {
+					found = attrConfig.coalesceResults(found)
+				}
 
-				// HACK - if the key is URLs, then we need to set the value as a multi-value
-				isMulti := isMulti != nil && isMulti(k)
-				if isMulti {
-					ret = ret.setMultiValue(0, k, result)
-				} else {
-					for i, text := range result {
-						ret = ret.setSingleValue(i, k, text)
+				for i, text := range found {
+					text = attrConfig.postProcess(ctx, text, q)
+					for key, value := range attrConfig.splitColumns(text) {
+						ret = ret.setSingleValue(i, key, value)
 					}
 				}
+
+				for _, key := range attrConfig.Columns {
+					if key != "" {
+						prov.record(key, selector, found)
+					}
+				}
+			} else if len(found) > 0 {
+				result := s.postProcess(ctx, q, attrConfig, found)
+				result = attrConfig.validateResults(k, result)
+				ret = ret.setValues(k, result, isMulti)
+				prov.record(k, selector, found)
+
+				for _, alsoKey := range attrConfig.alsoKeys() {
+					alsoConfig := attrConfig.Also[alsoKey]
+					result := s.postProcess(ctx, q, alsoConfig, found)
+					result = alsoConfig.validateResults(alsoKey, result)
+					ret = ret.setValues(alsoKey, result, isMulti)
+					prov.record(alsoKey, selector, found)
+				}
+			}
+		}
+	}
+
+	sort.Strings(deferred)
+	for _, k := range deferred {
+		ret = s.processFromField(ctx, q, common, k, s[k], ret, prov)
+	}
+
+	return ret
+}
+
+// processFromField processes an attribute that references other fields of the
+// results. Each value is post-processed with the result at the same index. If
+// the attribute has no selector, then an empty value is post-processed for
+// each existing result.
+func (s mappedConfig) processFromField(ctx context.Context, q mappedQuery, common commonMappedConfig, k string, attrConfig mappedScraperAttrConfig, ret mappedResults, prov mappedProvenance) mappedResults {
+	var found []string
+	var selector string
+	if attrConfig.Selector != "" {
+		selector = s.prepareSelector(q, common, attrConfig.Selector)
+
+		var err error
+		found, err = q.runQuery(selector)
+		if err != nil {
+			logger.Warnf("key '%v': %v", k, redactSecrets(err.Error()))
+		}
+
+		if attrConfig.Coalesce {
+			found = attrConfig.coalesceResults(found)
+		}
+	} else {
+		found = make([]string, len(ret))
+	}
+
+	var rawValues []string
+	set := false
+	for i, text := range found {
+		var r mappedResult
+		if i < len(ret) {
+			r = ret[i]
+		}
+
+		raw := text
+		text = attrConfig.postProcess(withMappedResult(ctx, r), text, q)
+		if text != "" && len(attrConfig.validateResults(k, []string{text})) > 0 {
+			ret = ret.setSingleValue(i, k, text)
+			if selector != "" {
+				rawValues = append(rawValues, raw)
 			}
+			set = true
 		}
 	}
 
+	if set {
+		prov.record(k, selector, rawValues)
+	}
+
 	return ret
 }
 
 func (s mappedConfig) postProcess(ctx context.Context, q mappedQuery, attrConfig mappedScraperAttrConfig, found []string) []string {
+	if attrConfig.Coalesce {
+		found = attrConfig.coalesceResults(found)
+	}
+
 	// check if we're concatenating the results into a single result
 	var ret []string
 	if attrConfig.hasConcat() {
 		result := attrConfig.concatenateResults(found)
-		result = attrConfig.postProcess(ctx, result, q)
+		results := attrConfig.postProcessMulti(ctx, result, q)
 		if attrConfig.hasSplit() {
-			results := attrConfig.splitString(result)
+			results = attrConfig.splitStrings(results)
 			// skip cleaning when the query is used for searching
 			if q.getType() == SearchQuery {
 				return results
@@ -105,15 +291,32 @@ func (s mappedConfig) postProcess(ctx context.Context, q mappedQuery, attrConfig
 			return results
 		}
 
-		ret = []string{result}
+		ret = results
+	} else if attrConfig.hasJoin() {
+		// post-process each value, then join them into a single result
+		for _, text := range found {
+			ret = append(ret, attrConfig.postProcessMulti(ctx, text, q)...)
+		}
+		ret = attrConfig.cleanResults(ret)
+		if len(ret) > 0 {
+			ret = []string{strings.Join(ret, attrConfig.Join)}
+		}
+	} else if len(found) > 1 && attrConfig.hasSubScraper() && !attrConfig.hasSplit() {
+		// sub-scrape multiple values concurrently
+		ret = attrConfig.postProcessConcurrent(ctx, found, q)
+		// skip cleaning when the query is used for searching
+		if q.getType() == SearchQuery {
+			return ret
+		}
+		ret = attrConfig.cleanResults(ret)
 	} else {
 		for _, text := range found {
-			text = attrConfig.postProcess(ctx, text, q)
+			results := attrConfig.postProcessMulti(ctx, text, q)
 			if attrConfig.hasSplit() {
-				return attrConfig.splitString(text)
+				return attrConfig.splitStrings(results)
 			}
 
-			ret = append(ret, text)
+			ret = append(ret, results...)
 		}
 		// skip cleaning when the query is used for searching
 		if q.getType() == SearchQuery {
@@ -131,9 +334,13 @@ type mappedSceneScraperConfig struct {
 
 	Tags       mappedConfig                 `yaml:"Tags"`
 	Performers mappedPerformerScraperConfig `yaml:"Performers"`
-	Studio     mappedConfig                 `yaml:"Studio"`
+	Studio     mappedStudioScraperConfig    `yaml:"Studio"`
 	Movies     mappedConfig                 `yaml:"Movies"`
 	Groups     mappedConfig                 `yaml:"Groups"`
+	Markers    mappedConfig                 `yaml:"Markers"`
+
+	// CustomFields maps custom field names to the selectors used to populate them.
+	CustomFields mappedConfig `yaml:"CustomFields"`
 }
 type _mappedSceneScraperConfig mappedSceneScraperConfig
 
@@ -143,6 +350,9 @@ const (
 	mappedScraperConfigSceneStudio     = "Studio"
 	mappedScraperConfigSceneMovies     = "Movies"
 	mappedScraperConfigSceneGroups     = "Groups"
+	mappedScraperConfigSceneMarkers    = "Markers"
+
+	mappedScraperConfigSceneCustomFields = "CustomFields"
 )
 
 func (s *mappedSceneScraperConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
@@ -161,12 +371,16 @@ func (s *mappedSceneScraperConfig) UnmarshalYAML(unmarshal func(interface{}) err
 	thisMap[mappedScraperConfigSceneStudio] = parentMap[mappedScraperConfigSceneStudio]
 	thisMap[mappedScraperConfigSceneMovies] = parentMap[mappedScraperConfigSceneMovies]
 	thisMap[mappedScraperConfigSceneGroups] = parentMap[mappedScraperConfigSceneGroups]
+	thisMap[mappedScraperConfigSceneMarkers] = parentMap[mappedScraperConfigSceneMarkers]
+	thisMap[mappedScraperConfigSceneCustomFields] = parentMap[mappedScraperConfigSceneCustomFields]
 
 	delete(parentMap, mappedScraperConfigSceneTags)
 	delete(parentMap, mappedScraperConfigScenePerformers)
 	delete(parentMap, mappedScraperConfigSceneStudio)
 	delete(parentMap, mappedScraperConfigSceneMovies)
 	delete(parentMap, mappedScraperConfigSceneGroups)
+	delete(parentMap, mappedScraperConfigSceneMarkers)
+	delete(parentMap, mappedScraperConfigSceneCustomFields)
 
 	// re-unmarshal the sub-fields
 	yml, err := yaml.Marshal(thisMap)
@@ -197,9 +411,9 @@ func (s *mappedSceneScraperConfig) UnmarshalYAML(unmarshal func(interface{}) err
 type mappedGalleryScraperConfig struct {
 	mappedConfig
 
-	Tags       mappedConfig `yaml:"Tags"`
-	Performers mappedConfig `yaml:"Performers"`
-	Studio     mappedConfig `yaml:"Studio"`
+	Tags       mappedConfig              `yaml:"Tags"`
+	Performers mappedConfig              `yaml:"Performers"`
+	Studio     mappedStudioScraperConfig `yaml:"Studio"`
 }
 
 type _mappedGalleryScraperConfig mappedGalleryScraperConfig
@@ -252,9 +466,9 @@ func (s *mappedGalleryScraperConfig) UnmarshalYAML(unmarshal func(interface{}) e
 type mappedImageScraperConfig struct {
 	mappedConfig
 
-	Tags       mappedConfig `yaml:"Tags"`
-	Performers mappedConfig `yaml:"Performers"`
-	Studio     mappedConfig `yaml:"Studio"`
+	Tags       mappedConfig              `yaml:"Tags"`
+	Performers mappedConfig              `yaml:"Performers"`
+	Studio     mappedStudioScraperConfig `yaml:"Studio"`
 }
 type _mappedImageScraperConfig mappedImageScraperConfig
 
@@ -307,11 +521,23 @@ type mappedPerformerScraperConfig struct {
 	mappedConfig
 
 	Tags mappedConfig `yaml:"Tags"`
+
+	// CustomFields maps custom field names to the selectors used to populate them.
+	CustomFields mappedConfig `yaml:"CustomFields"`
+
+	// Links scrapes labelled links, using the Label and URL keys.
+	Links mappedConfig `yaml:"Links"`
+
+	// Scenes scrapes the scenes listed for the performer, one per result.
+	Scenes mappedConfig `yaml:"Scenes"`
 }
 type _mappedPerformerScraperConfig mappedPerformerScraperConfig
 
 const (
-	mappedScraperConfigPerformerTags = "Tags"
+	mappedScraperConfigPerformerTags         = "Tags"
+	mappedScraperConfigPerformerCustomFields = "CustomFields"
+	mappedScraperConfigPerformerLinks        = "Links"
+	mappedScraperConfigPerformerScenes       = "Scenes"
 )
 
 func (s *mappedPerformerScraperConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
@@ -326,8 +552,14 @@ func (s *mappedPerformerScraperConfig) UnmarshalYAML(unmarshal func(interface{})
 	thisMap := make(map[string]interface{})
 
 	thisMap[mappedScraperConfigPerformerTags] = parentMap[mappedScraperConfigPerformerTags]
+	thisMap[mappedScraperConfigPerformerCustomFields] = parentMap[mappedScraperConfigPerformerCustomFields]
+	thisMap[mappedScraperConfigPerformerLinks] = parentMap[mappedScraperConfigPerformerLinks]
+	thisMap[mappedScraperConfigPerformerScenes] = parentMap[mappedScraperConfigPerformerScenes]
 
 	delete(parentMap, mappedScraperConfigPerformerTags)
+	delete(parentMap, mappedScraperConfigPerformerCustomFields)
+	delete(parentMap, mappedScraperConfigPerformerLinks)
+	delete(parentMap, mappedScraperConfigPerformerScenes)
 
 	// re-unmarshal the sub-fields
 	yml, err := yaml.Marshal(thisMap)
@@ -355,11 +587,71 @@ func (s *mappedPerformerScraperConfig) UnmarshalYAML(unmarshal func(interface{})
 	return nil
 }
 
+type mappedStudioScraperConfig struct {
+	mappedConfig
+
+	// Parent scrapes the parent studio, such as the network of the studio.
+	Parent mappedConfig `yaml:"Parent"`
+
+	// SubScraper scrapes the studio from its URL, for studios that are scraped
+	// with a URL but no name.
+	SubScraper mappedConfig `yaml:"SubScraper"`
+}
+type _mappedStudioScraperConfig mappedStudioScraperConfig
+
+const (
+	mappedScraperConfigStudioParent     = "Parent"
+	mappedScraperConfigStudioSubScraper = "SubScraper"
+)
+
+func (s *mappedStudioScraperConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// HACK - unmarshal to map first, then remove known studio sub-fields, then
+	// remarshal to yaml and pass that down to the base map
+	parentMap := make(map[string]interface{})
+	if err := unmarshal(parentMap); err != nil {
+		return err
+	}
+
+	// move the known sub-fields to a separate map
+	thisMap := make(map[string]interface{})
+
+	thisMap[mappedScraperConfigStudioParent] = parentMap[mappedScraperConfigStudioParent]
+	delete(parentMap, mappedScraperConfigStudioParent)
+
+	thisMap[mappedScraperConfigStudioSubScraper] = parentMap[mappedScraperConfigStudioSubScraper]
+	delete(parentMap, mappedScraperConfigStudioSubScraper)
+
+	// re-unmarshal the sub-fields
+	yml, err := yaml.Marshal(thisMap)
+	if err != nil {
+		return err
+	}
+
+	// needs to be a different type to prevent infinite recursion
+	c := _mappedStudioScraperConfig{}
+	if err := yaml.Unmarshal(yml, &c); err != nil {
+		return err
+	}
+
+	*s = mappedStudioScraperConfig(c)
+
+	yml, err = yaml.Marshal(parentMap)
+	if err != nil {
+		return err
+	}
+
+	if err := yaml.Unmarshal(yml, &s.mappedConfig); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 type mappedMovieScraperConfig struct {
 	mappedConfig
 
-	Studio mappedConfig `yaml:"Studio"`
-	Tags   mappedConfig `yaml:"Tags"`
+	Studio mappedStudioScraperConfig `yaml:"Studio"`
+	Tags   mappedConfig              `yaml:"Tags"`
 }
 type _mappedMovieScraperConfig mappedMovieScraperConfig
 
@@ -416,9 +708,39 @@ type mappedScraperAttrConfig struct {
 	Fixed       string                    `yaml:"fixed"`
 	PostProcess []mappedPostProcessAction `yaml:"postProcess"`
 	Concat      string                    `yaml:"concat"`
-	Split       string                    `yaml:"split"`
+	// Join is a separator to join the found values with after they have been
+	// post-processed. Unlike Concat, post-processing is applied to each value.
+	Join string `yaml:"join"`
+	// Split is a separator, or a list of separators, to split each value on.
+	Split mappedSplitConfig `yaml:"split"`
+	// Coalesce indicates that only the first non-empty found value is used.
+	Coalesce bool `yaml:"coalesce"`
+	// DedupeIgnoreCase indicates that values differing only in case are treated
+	// as duplicates. The first value found is kept, with its original casing.
+	DedupeIgnoreCase bool `yaml:"dedupeIgnoreCase"`
+	// When is a guard selector. If set, the config is only applied if the
+	// selector returns a non-empty result.
+	When string `yaml:"when"`
+	// Columns is a list of keys to assign the parts of each value to, after
+	// splitting it on Split into at most len(Columns) parts. Empty keys skip
+	// their column. The attribute's own key is not set when Columns is used.
+	Columns []string `yaml:"columns"`
+	// Also assigns the values found by the selector to other keys. Each key's
+	// config is applied to the found values in place of this config, so that
+	// one selector may produce differently post-processed values. These
+	// configs must not have their own selector or fixed value.
+	Also map[string]mappedScraperAttrConfig `yaml:"also"`
+	// Multiple indicates that a sub-scraper returns all of the values found on
+	// the sub-page, rather than the first value or their concatenation. It only
+	// applies to subScraper configs.
+	Multiple bool `yaml:"multiple"`
+	// Validate rejects post-processed values that are unlikely to be valid,
+	// such as those captured by a broken selector.
+	Validate *mappedValidateConfig `yaml:"validate"`
 
 	postProcessActions []postProcessAction
+	// splitRegex matches any of the separators when Split has more than one.
+	splitRegex *regexp.Regexp
 
 	// Deprecated: use PostProcess instead
 	ParseDate  string                   `yaml:"parseDate"`
@@ -447,9 +769,130 @@ func (c *mappedScraperAttrConfig) UnmarshalYAML(unmarshal func(interface{}) erro
 		*c = mappedScraperAttrConfig(t)
 	}
 
+	if c.hasColumns() && !c.hasSplit() {
+		return errors.New("columns requires split to be set")
+	}
+
+	if c.Multiple && c.hasConcat() {
+		return errors.New("multiple and concat cannot both be set")
+	}
+
+	if c.hasJoin() && (c.hasConcat() || c.hasSplit()) {
+		return errors.New("join cannot be set with concat or split")
+	}
+
+	for k, also := range c.Also {
+		if also.Selector != "" || also.Fixed != "" || also.When != "" || also.hasColumns() || len(also.Also) > 0 {
+			return fmt.Errorf("also %s: only post-processing fields may be set", k)
+		}
+	}
+
+	c.splitRegex = c.Split.regex()
+
+	if c.Validate != nil {
+		if err := c.Validate.compile(); err != nil {
+			return fmt.Errorf("validate: %w", err)
+		}
+	}
+
 	return c.convertPostProcessActions()
 }
 
+// mappedSplitConfig is a list of separators. It may be configured as a single
+// string or as a list of strings.
+type mappedSplitConfig []string
+
+func (s *mappedSplitConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var separators []string
+
+	var separator string
+	if err := unmarshal(&separator); err != nil {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return err
+		}
+
+		if err := unmarshal(&separators); err != nil {
+			return err
+		}
+	} else {
+		separators = []string{separator}
+	}
+
+	// empty separators are ignored
+	*s = sliceutil.Delete(separators, "")
+	return nil
+}
+
+// regex returns a regular expression matching any of the separators, or nil
+// if there is at most one separator. Longer separators are preferred, so that
+// a separator is not partially matched by a shorter separator it contains.
+func (s mappedSplitConfig) regex() *regexp.Regexp {
+	if len(s) <= 1 {
+		return nil
+	}
+
+	quoted := make([]string, len(s))
+	for i, sep := range s {
+		quoted[i] = regexp.QuoteMeta(sep)
+	}
+
+	sort.SliceStable(quoted, func(i, j int) bool {
+		return len(quoted[i]) > len(quoted[j])
+	})
+
+	return regexp.MustCompile(strings.Join(quoted, "|"))
+}
+
+// mappedValidateConfig configures the checks that a value must pass to be
+// kept. Values that fail any check are dropped.
+type mappedValidateConfig struct {
+	// MaxLength is the maximum length of a value, in characters. Zero means no limit.
+	MaxLength int `yaml:"maxLength"`
+	// Match is a regex that values must match.
+	Match string `yaml:"match"`
+	// NotContains is a list of strings that values must not contain.
+	// Strings are matched case-insensitively.
+	NotContains []string `yaml:"notContains"`
+
+	matchRegex *regexp.Regexp
+}
+
+func (v *mappedValidateConfig) compile() error {
+	if v.Match == "" {
+		return nil
+	}
+
+	re, err := regexp.Compile(v.Match)
+	if err != nil {
+		return fmt.Errorf("compiling match regex %q: %w", v.Match, err)
+	}
+
+	v.matchRegex = re
+	return nil
+}
+
+// check returns the reason that value fails validation, or an empty string
+// if it is valid.
+func (v *mappedValidateConfig) check(value string) string {
+	if v.MaxLength > 0 && utf8.RuneCountInString(value) > v.MaxLength {
+		return fmt.Sprintf("longer than %d characters", v.MaxLength)
+	}
+
+	if v.matchRegex != nil && !v.matchRegex.MatchString(value) {
+		return fmt.Sprintf("does not match %q", v.Match)
+	}
+
+	lower := strings.ToLower(value)
+	for _, str := range v.NotContains {
+		if strings.Contains(lower, strings.ToLower(str)) {
+			return fmt.Sprintf("contains %q", str)
+		}
+	}
+
+	return ""
+}
+
 func (c *mappedScraperAttrConfig) convertPostProcessActions() error {
 	// ensure we don't have the old deprecated fields and the new post process field
 	if len(c.PostProcess) > 0 {
@@ -496,8 +939,65 @@ func (c mappedScraperAttrConfig) hasConcat() bool {
 	return c.Concat != ""
 }
 
+func (c mappedScraperAttrConfig) hasJoin() bool {
+	return c.Join != ""
+}
+
 func (c mappedScraperAttrConfig) hasSplit() bool {
-	return c.Split != ""
+	return len(c.Split) > 0
+}
+
+func (c mappedScraperAttrConfig) hasColumns() bool {
+	return len(c.Columns) > 0
+}
+
+// hasFromField returns true if any of the post-process actions reference
+// another field.
+func (c mappedScraperAttrConfig) hasFromField() bool {
+	for _, a := range c.postProcessActions {
+		if _, ok := a.(*postProcessFromField); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasExtractFromURL returns true if any of the post-process actions extract
+// the value from the page URL.
+func (c mappedScraperAttrConfig) hasExtractFromURL() bool {
+	for _, a := range c.postProcessActions {
+		if _, ok := a.(*postProcessExtractFromURL); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// alsoKeys returns the keys of Also in sorted order.
+func (c mappedScraperAttrConfig) alsoKeys() []string {
+	keys := make([]string, 0, len(c.Also))
+	for k := range c.Also {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// splitColumns splits value into at most len(Columns) parts, returning a map
+// of column keys to the trimmed parts. Empty keys and values are omitted.
+func (c mappedScraperAttrConfig) splitColumns(value string) map[string]string {
+	ret := make(map[string]string)
+	for i, part := range c.splitN(value, len(c.Columns)) {
+		key := c.Columns[i]
+		part = strings.TrimSpace(part)
+		if key != "" && part != "" {
+			ret[key] = part
+		}
+	}
+
+	return ret
 }
 
 func (c mappedScraperAttrConfig) concatenateResults(nodes []string) string {
@@ -505,21 +1005,57 @@ func (c mappedScraperAttrConfig) concatenateResults(nodes []string) string {
 	return strings.Join(nodes, separator)
 }
 
+// coalesceResults returns the first non-empty node, or nil if all nodes are empty.
+func (c mappedScraperAttrConfig) coalesceResults(nodes []string) []string {
+	for _, n := range nodes {
+		if strings.TrimSpace(n) != "" {
+			return []string{n}
+		}
+	}
+
+	return nil
+}
+
 func (c mappedScraperAttrConfig) cleanResults(nodes []string) []string {
-	cleaned := sliceutil.Unique(nodes)      // remove duplicate values
+	var cleaned []string
+	// remove duplicate values
+	if c.DedupeIgnoreCase {
+		cleaned = stringslice.UniqueFold(nodes)
+	} else {
+		cleaned = sliceutil.Unique(nodes)
+	}
 	cleaned = sliceutil.Delete(cleaned, "") // remove empty values
 	return cleaned
 }
 
+// splitN splits value on any of the Split separators into at most n parts.
+// If n is negative, all parts are returned.
+func (c mappedScraperAttrConfig) splitN(value string, n int) []string {
+	if c.splitRegex != nil {
+		return c.splitRegex.Split(value, n)
+	}
+
+	return strings.SplitN(value, c.Split[0], n)
+}
+
+// splitStrings splits each of the values, returning all of the results.
+func (c mappedScraperAttrConfig) splitStrings(values []string) []string {
+	var res []string
+	for _, v := range values {
+		res = append(res, c.splitString(v)...)
+	}
+
+	return res
+}
+
 func (c mappedScraperAttrConfig) splitString(value string) []string {
-	separator := c.Split
 	var res []string
 
-	if separator == "" {
+	if !c.hasSplit() {
 		return []string{value}
 	}
 
-	for _, str := range strings.Split(value, separator) {
+	for _, str := range c.splitN(value, -1) {
 		if str != "" {
 			res = append(res, str)
 		}
@@ -528,6 +1064,25 @@ func (c mappedScraperAttrConfig) splitString(value string) []string {
 	return res
 }
 
+// validateResults returns the values that pass validation. Dropped values are logged.
+func (c mappedScraperAttrConfig) validateResults(key string, values []string) []string {
+	if c.Validate == nil {
+		return values
+	}
+
+	var ret []string
+	for _, v := range values {
+		if reason := c.Validate.check(v); reason != "" {
+			logger.Warnf("key '%v': dropping value: %s", key, reason)
+			continue
+		}
+
+		ret = append(ret, v)
+	}
+
+	return ret
+}
+
 func (c mappedScraperAttrConfig) postProcess(ctx context.Context, value string, q mappedQuery) string {
 	for _, action := range c.postProcessActions {
 		value = action.Apply(ctx, value, q)
@@ -535,3 +1090,23 @@ func (c mappedScraperAttrConfig) postProcess(ctx context.Context, value string,
 
 	return value
 }
+
+// postProcessMulti applies the post-process actions to value. Actions that
+// produce multiple values, such as extractAll, result in the subsequent actions
+// being applied to each value.
+func (c mappedScraperAttrConfig) postProcessMulti(ctx context.Context, value string, q mappedQuery) []string {
+	values := []string{value}
+	for _, action := range c.postProcessActions {
+		var next []string
+		for _, v := range values {
+			if multi, ok := action.(multiPostProcessAction); ok {
+				next = append(next, multi.ApplyMulti(ctx, v, q)...)
+			} else {
+				next = append(next, action.Apply(ctx, v, q))
+			}
+		}
+		values = next
+	}
+
+	return values
+}
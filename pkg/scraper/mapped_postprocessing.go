@@ -5,13 +5,16 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"net/url"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/stashapp/stash/pkg/javascript"
 	"github.com/stashapp/stash/pkg/logger"
+	"github.com/tidwall/gjson"
 )
 
 type mappedRegexConfig struct {
@@ -58,20 +61,42 @@ type postProcessAction interface {
 	Apply(ctx context.Context, value string, q mappedQuery) string
 }
 
+// multiPostProcessAction is a postProcessAction that may produce multiple
+// values from a single value. Subsequent actions are applied to each value.
+type multiPostProcessAction interface {
+	postProcessAction
+	ApplyMulti(ctx context.Context, value string, q mappedQuery) []string
+}
+
+// internalDateFormat is the date layout used for scraped dates unless
+// otherwise specified.
+const internalDateFormat = "2006-01-02"
+
 type postProcessParseDate string
 
 func (p *postProcessParseDate) Apply(ctx context.Context, value string, q mappedQuery) string {
-	parseDate := string(*p)
+	return parseDateValue(value, string(*p), internalDateFormat)
+}
+
+// postProcessParseDateFormat parses the date using ParseDate, and outputs it
+// using OutputFormat rather than the internal date format.
+type postProcessParseDateFormat struct {
+	ParseDate    string
+	OutputFormat string
+}
 
-	const internalDateFormat = "2006-01-02"
+func (p *postProcessParseDateFormat) Apply(ctx context.Context, value string, q mappedQuery) string {
+	return parseDateValue(value, p.ParseDate, p.OutputFormat)
+}
 
+func parseDateValue(value string, parseDate string, outputFormat string) string {
 	valueLower := strings.ToLower(value)
 	if valueLower == "today" || valueLower == "yesterday" { // handle today, yesterday
 		dt := time.Now()
 		if valueLower == "yesterday" { // subtract 1 day from now
 			dt = dt.AddDate(0, 0, -1)
 		}
-		return dt.Format(internalDateFormat)
+		return dt.Format(outputFormat)
 	}
 
 	if parseDate == "" {
@@ -88,7 +113,7 @@ func (p *postProcessParseDate) Apply(ctx context.Context, value string, q mapped
 		}
 		parsedValue := time.Unix(timeAsInt, 0)
 
-		return parsedValue.Format(internalDateFormat)
+		return parsedValue.Format(outputFormat)
 	}
 
 	// try to parse the date using the pattern
@@ -99,15 +124,13 @@ func (p *postProcessParseDate) Apply(ctx context.Context, value string, q mapped
 		return value
 	}
 
-	// convert it into our date format
-	return parsedValue.Format(internalDateFormat)
+	// convert it into the output date format
+	return parsedValue.Format(outputFormat)
 }
 
 type postProcessSubtractDays bool
 
 func (p *postProcessSubtractDays) Apply(ctx context.Context, value string, q mappedQuery) string {
-	const internalDateFormat = "2006-01-02"
-
 	i, err := strconv.Atoi(value)
 	if err != nil {
 		logger.Warnf("Error parsing day string %s: %s", value, err)
@@ -119,6 +142,45 @@ func (p *postProcessSubtractDays) Apply(ctx context.Context, value string, q map
 	return dt.Format(internalDateFormat)
 }
 
+const (
+	ageToBirthYearDate = "date"
+	ageToBirthYearYear = "year"
+)
+
+var ageRE = regexp.MustCompile(`\d+`)
+
+// postProcessAgeToBirthYear converts an age in years into an approximate birth
+// year, by subtracting the age from the current year. The result may be one
+// year later than the actual birth year, if the birthday has not yet occurred
+// in the current year. Format is either ageToBirthYearDate, which returns the
+// first day of the year, or ageToBirthYearYear, which returns only the year.
+type postProcessAgeToBirthYear struct {
+	Format string
+
+	// now returns the current time. Uses time.Now if nil.
+	now func() time.Time
+}
+
+func (p *postProcessAgeToBirthYear) Apply(ctx context.Context, value string, q mappedQuery) string {
+	age, err := strconv.Atoi(ageRE.FindString(value))
+	if err != nil {
+		logger.Warnf("Error parsing age string %s: %s", value, err)
+		return value
+	}
+
+	now := time.Now
+	if p.now != nil {
+		now = p.now
+	}
+
+	year := now().Year() - age
+	if p.Format == ageToBirthYearYear {
+		return strconv.Itoa(year)
+	}
+
+	return time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC).Format(internalDateFormat)
+}
+
 type postProcessReplace mappedRegexConfigs
 
 func (c *postProcessReplace) Apply(ctx context.Context, value string, q mappedQuery) string {
@@ -131,30 +193,64 @@ type postProcessSubScraper mappedScraperAttrConfig
 func (p *postProcessSubScraper) Apply(ctx context.Context, value string, q mappedQuery) string {
 	subScrapeConfig := mappedScraperAttrConfig(*p)
 
+	found, ss := p.subScrape(ctx, value, q)
+	if len(found) > 0 {
+		// check if we're concatenating the results into a single result
+		var result string
+		if subScrapeConfig.hasConcat() {
+			result = subScrapeConfig.concatenateResults(found)
+		} else {
+			result = found[0]
+		}
+
+		result = subScrapeConfig.postProcess(ctx, result, ss)
+		return result
+	}
+
+	return ""
+}
+
+// ApplyMulti returns each of the values found on the sub-page if Multiple
+// is set. Otherwise it returns the single value returned by Apply.
+func (p *postProcessSubScraper) ApplyMulti(ctx context.Context, value string, q mappedQuery) []string {
+	subScrapeConfig := mappedScraperAttrConfig(*p)
+	if !subScrapeConfig.Multiple {
+		return []string{p.Apply(ctx, value, q)}
+	}
+
+	found, ss := p.subScrape(ctx, value, q)
+
+	var ret []string
+	for _, text := range found {
+		ret = append(ret, subScrapeConfig.postProcessMulti(ctx, text, ss)...)
+	}
+
+	return ret
+}
+
+// subScrape loads the sub-page at value, returning the values found by the
+// selector and the query used to find them.
+func (p *postProcessSubScraper) subScrape(ctx context.Context, value string, q mappedQuery) ([]string, mappedQuery) {
+	release, err := clientSubScrapeLimiter(q.getClient()).acquire(ctx, extractHostname(value))
+	if err != nil {
+		logger.Warnf("subscrape for '%v': %v", value, err)
+		return nil, nil
+	}
+
 	logger.Debugf("Sub-scraping for: %s", value)
 	ss := q.subScrape(ctx, value)
+	release()
 
-	if ss != nil {
-		found, err := ss.runQuery(subScrapeConfig.Selector)
-		if err != nil {
-			logger.Warnf("subscrape for '%v': %v", value, err)
-		}
-
-		if len(found) > 0 {
-			// check if we're concatenating the results into a single result
-			var result string
-			if subScrapeConfig.hasConcat() {
-				result = subScrapeConfig.concatenateResults(found)
-			} else {
-				result = found[0]
-			}
+	if ss == nil {
+		return nil, nil
+	}
 
-			result = subScrapeConfig.postProcess(ctx, result, ss)
-			return result
-		}
+	found, err := ss.runQuery(p.Selector)
+	if err != nil {
+		logger.Warnf("subscrape for '%v': %v", value, err)
 	}
 
-	return ""
+	return found, ss
 }
 
 type postProcessMap map[string]string
@@ -195,6 +291,27 @@ func (p *postProcessFeetToCm) Apply(ctx context.Context, value string, q mappedQ
 	return strconv.Itoa(int(math.Round(centimeters)))
 }
 
+var measurementsNumberRE = regexp.MustCompile(`\d+(?:\.\d+)?`)
+
+// postProcessMeasurementsToCm converts the numbers of a measurements string,
+// such as 34D-24-36, from inches to centimeters. Other characters, such as the
+// cup size, are left unchanged. Values that are already in centimeters are
+// returned unchanged.
+type postProcessMeasurementsToCm bool
+
+func (p *postProcessMeasurementsToCm) Apply(ctx context.Context, value string, q mappedQuery) string {
+	const inch_in_cm = 2.54
+
+	if unitCmRE.MatchString(strings.ToLower(value)) {
+		return value
+	}
+
+	return measurementsNumberRE.ReplaceAllStringFunc(value, func(n string) string {
+		inches, _ := strconv.ParseFloat(n, 64)
+		return strconv.Itoa(int(math.Round(inches * inch_in_cm)))
+	})
+}
+
 type postProcessLbToKg bool
 
 func (p *postProcessLbToKg) Apply(ctx context.Context, value string, q mappedQuery) string {
@@ -207,6 +324,107 @@ func (p *postProcessLbToKg) Apply(ctx context.Context, value string, q mappedQue
 	return value
 }
 
+var (
+	unitKgRE     = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*(?:kg|kilo)`)
+	unitLbRE     = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*(?:lb|pound)`)
+	unitCmRE     = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*(?:cm|centimet)`)
+	unitFeetRE   = regexp.MustCompile(`(\d+)\s*(?:'|’|ft|feet|foot)\s*(?:(\d+(?:\.\d+)?)\s*(?:"|”|''|in\b|inch)?)?`)
+	unitInchesRE = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*(?:"|”|in\b|inch)`)
+)
+
+// postProcessConvertUnits detects the unit of a height or weight value and
+// converts it to centimeters or kilograms. Values without a recognised unit
+// are returned unchanged.
+type postProcessConvertUnits bool
+
+func (p *postProcessConvertUnits) Apply(ctx context.Context, value string, q mappedQuery) string {
+	const (
+		lb_in_kg   = 0.45359237
+		foot_in_cm = 30.48
+		inch_in_cm = 2.54
+	)
+
+	lower := strings.ToLower(value)
+	round := func(v float64) string {
+		return strconv.Itoa(int(math.Round(v)))
+	}
+
+	if m := unitKgRE.FindStringSubmatch(lower); m != nil {
+		kg, _ := strconv.ParseFloat(m[1], 64)
+		return round(kg)
+	}
+	if m := unitLbRE.FindStringSubmatch(lower); m != nil {
+		lb, _ := strconv.ParseFloat(m[1], 64)
+		return round(lb * lb_in_kg)
+	}
+	if m := unitCmRE.FindStringSubmatch(lower); m != nil {
+		cm, _ := strconv.ParseFloat(m[1], 64)
+		return round(cm)
+	}
+	if m := unitFeetRE.FindStringSubmatch(lower); m != nil {
+		feet, _ := strconv.ParseFloat(m[1], 64)
+		inches, _ := strconv.ParseFloat(m[2], 64)
+		return round(feet*foot_in_cm + inches*inch_in_cm)
+	}
+	if m := unitInchesRE.FindStringSubmatch(lower); m != nil {
+		inches, _ := strconv.ParseFloat(m[1], 64)
+		return round(inches * inch_in_cm)
+	}
+
+	return value
+}
+
+type mappedResultKey struct{}
+
+// withMappedResult returns a context holding the result that is being
+// populated, so that post-process actions can reference its other fields.
+func withMappedResult(ctx context.Context, r mappedResult) context.Context {
+	return context.WithValue(ctx, mappedResultKey{}, r)
+}
+
+// mappedResultFromContext returns the result set by withMappedResult, or nil
+// if not set.
+func mappedResultFromContext(ctx context.Context) mappedResult {
+	r, _ := ctx.Value(mappedResultKey{}).(mappedResult)
+	return r
+}
+
+// postProcessFromField replaces the value with the value of another field of
+// the same result. Returns an empty string if the field is not set.
+type postProcessFromField string
+
+func (p *postProcessFromField) Apply(ctx context.Context, value string, q mappedQuery) string {
+	values := mappedResultFromContext(ctx).stringSlice(string(*p))
+	if len(values) == 0 {
+		logger.Debugf("fromField: field %s not set", string(*p))
+		return ""
+	}
+
+	return values[0]
+}
+
+// postProcessExtractFromURL replaces the value with the first capture group of
+// the regex matched against the URL of the scraped page, or the whole match if
+// the regex has no capture groups. Returns an empty string if the URL does not
+// match.
+type postProcessExtractFromURL struct {
+	regex *regexp.Regexp
+}
+
+func (p *postProcessExtractFromURL) Apply(ctx context.Context, value string, q mappedQuery) string {
+	m := p.regex.FindStringSubmatch(q.getURL())
+	if m == nil {
+		logger.Debugf("extractFromURL: '%s' does not match '%s'", p.regex, q.getURL())
+		return ""
+	}
+
+	if len(m) > 1 {
+		return m[1]
+	}
+
+	return m[0]
+}
+
 type postProcessJavascript string
 
 func (p *postProcessJavascript) Apply(ctx context.Context, value string, q mappedQuery) string {
@@ -247,8 +465,448 @@ func (p *postProcessJavascript) Apply(ctx context.Context, value string, q mappe
 	return output.String()
 }
 
+// postProcessJson parses the value as JSON and applies the gjson selector to it.
+// If the selector matches an array, the values are joined with ", ".
+type postProcessJson string
+
+func (p *postProcessJson) Apply(ctx context.Context, value string, q mappedQuery) string {
+	selector := string(*p)
+
+	if !gjson.Valid(value) {
+		logger.Warnf("json post-process: value is not valid json: %s", value)
+		return ""
+	}
+
+	result := gjson.Get(value, selector)
+	if !result.Exists() {
+		return ""
+	}
+
+	if result.IsArray() {
+		var values []string
+		result.ForEach(func(k, v gjson.Result) bool {
+			values = append(values, v.String())
+			return true
+		})
+		return strings.Join(values, ", ")
+	}
+
+	return result.String()
+}
+
+// postProcessTrimPrefix removes the prefix from the value, if present.
+type postProcessTrimPrefix string
+
+func (p *postProcessTrimPrefix) Apply(ctx context.Context, value string, q mappedQuery) string {
+	return strings.TrimPrefix(value, string(*p))
+}
+
+// postProcessTrimSuffix removes the suffix from the value, if present.
+type postProcessTrimSuffix string
+
+func (p *postProcessTrimSuffix) Apply(ctx context.Context, value string, q mappedQuery) string {
+	return strings.TrimSuffix(value, string(*p))
+}
+
+// postProcessCanonicalizeURL converts a url into a canonical form. The host is
+// lowercased, default ports and trailing slashes are removed, and the query
+// parameters in DropParams are removed. A parameter ending in * drops all
+// parameters with that prefix. The remaining query parameters are sorted.
+// The original value is returned if it is not an absolute url.
+type postProcessCanonicalizeURL struct {
+	DropParams []string `yaml:"dropParams"`
+}
+
+func (p *postProcessCanonicalizeURL) dropParam(param string) bool {
+	for _, d := range p.DropParams {
+		if prefix, ok := strings.CutSuffix(d, "*"); ok {
+			if strings.HasPrefix(param, prefix) {
+				return true
+			}
+		} else if param == d {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *postProcessCanonicalizeURL) Apply(ctx context.Context, value string, q mappedQuery) string {
+	u, err := url.Parse(strings.TrimSpace(value))
+	if err != nil || u.Host == "" {
+		logger.Warnf("Error parsing url %s for canonicalizeURL", value)
+		return value
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if strings.Contains(host, ":") {
+		// ipv6 address
+		host = "[" + host + "]"
+	}
+
+	port := u.Port()
+	if port != "" && !(u.Scheme == "http" && port == "80") && !(u.Scheme == "https" && port == "443") {
+		host += ":" + port
+	}
+	u.Host = host
+
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = strings.TrimRight(u.RawPath, "/")
+
+	query := u.Query()
+	for param := range query {
+		if p.dropParam(param) {
+			query.Del(param)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// postProcessPick splits the value using Delimiter and returns the element at
+// Index. Negative indexes count back from the last element. An empty value is
+// returned if the index is out of range.
+type postProcessPick struct {
+	Delimiter string `yaml:"delimiter"`
+	Index     int    `yaml:"index"`
+}
+
+func (p *postProcessPick) Apply(ctx context.Context, value string, q mappedQuery) string {
+	parts := strings.Split(value, p.Delimiter)
+
+	i := p.Index
+	if i < 0 {
+		i += len(parts)
+	}
+
+	if i < 0 || i >= len(parts) {
+		return ""
+	}
+
+	return strings.TrimSpace(parts[i])
+}
+
+// defaultSeparatorMatch are the separators replaced by normalizeSeparators if
+// none are configured: the hyphen, the Unicode dashes and the minus sign.
+var defaultSeparatorMatch = []string{"-", "\u2010", "\u2011", "\u2012", "\u2013", "\u2014", "\u2015", "\u2212"}
+
+// postProcessNormalizeSeparators replaces each of the separators in Match, along
+// with any surrounding whitespace, with Separator. Separator defaults to - and
+// Match defaults to defaultSeparatorMatch. If CollapseSpaces is true, other runs
+// of whitespace are replaced with a single space.
+type postProcessNormalizeSeparators struct {
+	Separator      string   `yaml:"separator"`
+	Match          []string `yaml:"match"`
+	CollapseSpaces bool     `yaml:"collapseSpaces"`
+
+	regex *regexp.Regexp
+}
+
+func (p *postProcessNormalizeSeparators) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var enabled bool
+	if err := unmarshal(&enabled); err == nil {
+		if !enabled {
+			return errors.New("must be true or a map of options")
+		}
+		*p = postProcessNormalizeSeparators{}
+		return nil
+	}
+
+	type options postProcessNormalizeSeparators
+	var o options
+	if err := unmarshal(&o); err != nil {
+		return err
+	}
+
+	*p = postProcessNormalizeSeparators(o)
+	return nil
+}
+
+func (p *postProcessNormalizeSeparators) compile() error {
+	if p.Separator == "" {
+		p.Separator = "-"
+	}
+
+	match := p.Match
+	if len(match) == 0 {
+		match = defaultSeparatorMatch
+	}
+
+	// match longer separators first, so that a separator is not partially
+	// replaced by a shorter one that it contains
+	quoted := make([]string, 0, len(match))
+	for _, m := range match {
+		if m == "" {
+			return errors.New("normalizeSeparators: match must not contain empty separators")
+		}
+		quoted = append(quoted, regexp.QuoteMeta(m))
+	}
+	sort.SliceStable(quoted, func(i, j int) bool {
+		return len(quoted[i]) > len(quoted[j])
+	})
+
+	p.regex = regexp.MustCompile(`\s*(?:` + strings.Join(quoted, "|") + `)\s*`)
+	return nil
+}
+
+func (p *postProcessNormalizeSeparators) Apply(ctx context.Context, value string, q mappedQuery) string {
+	ret := p.regex.ReplaceAllLiteralString(strings.TrimSpace(value), p.Separator)
+
+	if p.CollapseSpaces {
+		ret = strings.Join(strings.Fields(ret), " ")
+	}
+
+	return ret
+}
+
+const (
+	pickDateEarliest = "earliest"
+	pickDateLatest   = "latest"
+)
+
+// mappedOverridesConfig is the config of a post-process action that maps values
+// using a built-in table. It is true to use the built-in table only, or a map of
+// additional values, which take precedence over the built-in table.
+type mappedOverridesConfig map[string]string
+
+func (c *mappedOverridesConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var enabled bool
+	if err := unmarshal(&enabled); err == nil {
+		if !enabled {
+			return errors.New("must be true or a map of values")
+		}
+		*c = mappedOverridesConfig{}
+		return nil
+	}
+
+	m := make(map[string]string)
+	if err := unmarshal(&m); err != nil {
+		return err
+	}
+
+	*c = m
+	return nil
+}
+
+// lookup returns the value mapped to v, matching case-insensitively.
+func (c mappedOverridesConfig) lookup(v string) (string, bool) {
+	for k, mapped := range c {
+		if strings.EqualFold(k, v) {
+			return mapped, true
+		}
+	}
+
+	return "", false
+}
+
+// postProcessCountryCode converts country names to ISO 3166-1 alpha-2 codes,
+// using the built-in country names. The map holds additional names, which take
+// precedence over the built-in names. Names are matched case-insensitively. Two
+// letter values are validated as codes, and known aliases such as UK are mapped
+// to their ISO code. The original value is returned if it is not recognised.
+type postProcessCountryCode mappedOverridesConfig
+
+func (p *postProcessCountryCode) Apply(ctx context.Context, value string, q mappedQuery) string {
+	trimmed := strings.TrimSpace(value)
+
+	if code, ok := mappedOverridesConfig(*p).lookup(trimmed); ok {
+		return code
+	}
+
+	if len(trimmed) == 2 {
+		if code, ok := normalizeCountryCode(trimmed); ok {
+			return code
+		}
+	}
+
+	if code, ok := lookupCountryCode(trimmed); ok {
+		return code
+	}
+
+	logger.Debugf("Country %q was not recognized", trimmed)
+	return value
+}
+
+// postProcessExtractAll returns every match of Regex in the value as a
+// separate value. Each value is the capture group at Group, or if Group is not
+// set, the first capture group, or the whole match if the regex has no capture
+// groups. Empty values are omitted.
+type postProcessExtractAll struct {
+	Regex string `yaml:"regex"`
+	Group *int   `yaml:"group"`
+
+	regex *regexp.Regexp
+}
+
+func (p *postProcessExtractAll) compile() error {
+	if p.Regex == "" {
+		return errors.New("extractAll requires a regex")
+	}
+
+	re, err := regexp.Compile(p.Regex)
+	if err != nil {
+		return fmt.Errorf("extractAll: %w", err)
+	}
+
+	if p.Group != nil && (*p.Group < 0 || *p.Group > re.NumSubexp()) {
+		return fmt.Errorf("extractAll: group %d out of range, regex has %d groups", *p.Group, re.NumSubexp())
+	}
+
+	p.regex = re
+	return nil
+}
+
+func (p *postProcessExtractAll) group() int {
+	switch {
+	case p.Group != nil:
+		return *p.Group
+	case p.regex.NumSubexp() > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (p *postProcessExtractAll) ApplyMulti(ctx context.Context, value string, q mappedQuery) []string {
+	group := p.group()
+
+	var ret []string
+	for _, m := range p.regex.FindAllStringSubmatch(value, -1) {
+		if v := strings.TrimSpace(m[group]); v != "" {
+			ret = append(ret, v)
+		}
+	}
+
+	return ret
+}
+
+// Apply returns the first value, for attributes that only accept a single
+// value, such as those using columns.
+func (p *postProcessExtractAll) Apply(ctx context.Context, value string, q mappedQuery) string {
+	values := p.ApplyMulti(ctx, value, q)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+// postProcessPickDate splits the value using Delimiter and parses each element
+// as a date using Format, returning the earliest or latest date in the internal
+// date format. Elements that cannot be parsed are ignored. An empty value is
+// returned if no element can be parsed.
+type postProcessPickDate struct {
+	Delimiter string `yaml:"delimiter"`
+	// Format is the layout used to parse the dates. Defaults to the internal date format.
+	Format string `yaml:"format"`
+	// Pick is either "earliest" or "latest".
+	Pick string `yaml:"pick"`
+}
+
+func (p *postProcessPickDate) validate() error {
+	if p.Delimiter == "" {
+		return errors.New("pickDate requires a delimiter")
+	}
+	if p.Pick != pickDateEarliest && p.Pick != pickDateLatest {
+		return fmt.Errorf("pickDate pick must be %q or %q", pickDateEarliest, pickDateLatest)
+	}
+
+	return nil
+}
+
+func (p *postProcessPickDate) Apply(ctx context.Context, value string, q mappedQuery) string {
+	format := p.Format
+	if format == "" {
+		format = internalDateFormat
+	}
+
+	var ret time.Time
+	found := false
+	for _, part := range strings.Split(value, p.Delimiter) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		t, err := time.Parse(format, part)
+		if err != nil {
+			logger.Debugf("Ignoring date string '%s' not in format '%s'", part, format)
+			continue
+		}
+
+		if !found || (p.Pick == pickDateEarliest && t.Before(ret)) || (p.Pick == pickDateLatest && t.After(ret)) {
+			ret = t
+			found = true
+		}
+	}
+
+	if !found {
+		logger.Warnf("No dates in '%s' could be parsed using format '%s'", value, format)
+		return ""
+	}
+
+	return ret.Format(internalDateFormat)
+}
+
+// postProcessMath parses the value as a number and applies a single arithmetic
+// operation to it using a constant. The original value is returned if it cannot
+// be parsed.
+type postProcessMath struct {
+	Multiply *float64 `yaml:"multiply"`
+	Divide   *float64 `yaml:"divide"`
+	Add      *float64 `yaml:"add"`
+	Subtract *float64 `yaml:"subtract"`
+}
+
+func (p *postProcessMath) validate() error {
+	n := 0
+	for _, v := range []*float64{p.Multiply, p.Divide, p.Add, p.Subtract} {
+		if v != nil {
+			n++
+		}
+	}
+
+	if n != 1 {
+		return errors.New("math requires exactly one of multiply, divide, add or subtract")
+	}
+
+	if p.Divide != nil && *p.Divide == 0 {
+		return errors.New("math cannot divide by zero")
+	}
+
+	return nil
+}
+
+func (p *postProcessMath) Apply(ctx context.Context, value string, q mappedQuery) string {
+	v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		logger.Warnf("Error parsing number string %s: %s", value, err)
+		return value
+	}
+
+	switch {
+	case p.Multiply != nil:
+		v *= *p.Multiply
+	case p.Divide != nil:
+		v /= *p.Divide
+	case p.Add != nil:
+		v += *p.Add
+	case p.Subtract != nil:
+		v -= *p.Subtract
+	}
+
+	// output whole numbers as integers
+	if v == math.Trunc(v) && math.Abs(v) < math.MaxInt64 {
+		return strconv.FormatInt(int64(v), 10)
+	}
+
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
 type mappedPostProcessAction struct {
 	ParseDate    string                   `yaml:"parseDate"`
+	OutputFormat string                   `yaml:"outputFormat"`
 	SubtractDays bool                     `yaml:"subtractDays"`
 	Replace      mappedRegexConfigs       `yaml:"replace"`
 	SubScraper   *mappedScraperAttrConfig `yaml:"subScraper"`
@@ -256,6 +914,29 @@ type mappedPostProcessAction struct {
 	FeetToCm     bool                     `yaml:"feetToCm"`
 	LbToKg       bool                     `yaml:"lbToKg"`
 	Javascript   string                   `yaml:"javascript"`
+	Json         string                   `yaml:"json"`
+	Pick         *postProcessPick         `yaml:"pick"`
+	Math         *postProcessMath         `yaml:"math"`
+	TrimPrefix   string                   `yaml:"trimPrefix"`
+	TrimSuffix   string                   `yaml:"trimSuffix"`
+
+	CanonicalizeURL  *postProcessCanonicalizeURL `yaml:"canonicalizeURL"`
+	AgeToBirthYear   string                      `yaml:"ageToBirthYear"`
+	ConvertUnits     bool                        `yaml:"convertUnits"`
+	FromField        string                      `yaml:"fromField"`
+	PickDate         *postProcessPickDate        `yaml:"pickDate"`
+	ExtractFromURL   string                      `yaml:"extractFromURL"`
+	MeasurementsToCm bool                        `yaml:"measurementsToCm"`
+	ExtractAll       *postProcessExtractAll      `yaml:"extractAll"`
+	CountryCode      *mappedOverridesConfig      `yaml:"countryCode"`
+	HairColor        *mappedOverridesConfig      `yaml:"hairColor"`
+	EyeColor         *mappedOverridesConfig      `yaml:"eyeColor"`
+	Gender           *mappedOverridesConfig      `yaml:"gender"`
+
+	NormalizeSeparators *postProcessNormalizeSeparators `yaml:"normalizeSeparators"`
+
+	// Custom is the name of an action registered with RegisterPostProcessAction.
+	Custom string `yaml:"custom"`
 }
 
 func (a mappedPostProcessAction) ToPostProcessAction() (postProcessAction, error) {
@@ -270,10 +951,21 @@ func (a mappedPostProcessAction) ToPostProcessAction() (postProcessAction, error
 		return nil
 	}
 
+	if a.OutputFormat != "" && a.ParseDate == "" {
+		return nil, errors.New("outputFormat may only be used with parseDate")
+	}
+
 	if a.ParseDate != "" {
 		found = "parseDate"
-		action := postProcessParseDate(a.ParseDate)
-		ret = &action
+		if a.OutputFormat != "" {
+			ret = &postProcessParseDateFormat{
+				ParseDate:    a.ParseDate,
+				OutputFormat: a.OutputFormat,
+			}
+		} else {
+			action := postProcessParseDate(a.ParseDate)
+			ret = &action
+		}
 	}
 	if len(a.Replace) > 0 {
 		if err := ensureOnly("replace"); err != nil {
@@ -310,6 +1002,37 @@ func (a mappedPostProcessAction) ToPostProcessAction() (postProcessAction, error
 		action := postProcessLbToKg(a.LbToKg)
 		ret = &action
 	}
+	if a.MeasurementsToCm {
+		if err := ensureOnly("measurementsToCm"); err != nil {
+			return nil, err
+		}
+		action := postProcessMeasurementsToCm(a.MeasurementsToCm)
+		ret = &action
+	}
+	if a.ConvertUnits {
+		if err := ensureOnly("convertUnits"); err != nil {
+			return nil, err
+		}
+		action := postProcessConvertUnits(a.ConvertUnits)
+		ret = &action
+	}
+	if a.FromField != "" {
+		if err := ensureOnly("fromField"); err != nil {
+			return nil, err
+		}
+		action := postProcessFromField(a.FromField)
+		ret = &action
+	}
+	if a.ExtractFromURL != "" {
+		if err := ensureOnly("extractFromURL"); err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile(a.ExtractFromURL)
+		if err != nil {
+			return nil, fmt.Errorf("extractFromURL: %w", err)
+		}
+		ret = &postProcessExtractFromURL{regex: re}
+	}
 	if a.SubtractDays {
 		if err := ensureOnly("subtractDays"); err != nil {
 			return nil, err
@@ -317,6 +1040,17 @@ func (a mappedPostProcessAction) ToPostProcessAction() (postProcessAction, error
 		action := postProcessSubtractDays(a.SubtractDays)
 		ret = &action
 	}
+	if a.AgeToBirthYear != "" {
+		if err := ensureOnly("ageToBirthYear"); err != nil {
+			return nil, err
+		}
+		if a.AgeToBirthYear != ageToBirthYearDate && a.AgeToBirthYear != ageToBirthYearYear {
+			return nil, fmt.Errorf("ageToBirthYear must be %q or %q", ageToBirthYearDate, ageToBirthYearYear)
+		}
+		ret = &postProcessAgeToBirthYear{
+			Format: a.AgeToBirthYear,
+		}
+	}
 	if a.Javascript != "" {
 		if err := ensureOnly("javascript"); err != nil {
 			return nil, err
@@ -325,6 +1059,134 @@ func (a mappedPostProcessAction) ToPostProcessAction() (postProcessAction, error
 		ret = &action
 	}
 
+	if a.Json != "" {
+		if err := ensureOnly("json"); err != nil {
+			return nil, err
+		}
+		action := postProcessJson(a.Json)
+		ret = &action
+	}
+
+	if a.Pick != nil {
+		if err := ensureOnly("pick"); err != nil {
+			return nil, err
+		}
+		if a.Pick.Delimiter == "" {
+			return nil, errors.New("pick requires a delimiter")
+		}
+		action := *a.Pick
+		ret = &action
+	}
+
+	if a.ExtractAll != nil {
+		if err := ensureOnly("extractAll"); err != nil {
+			return nil, err
+		}
+		action := *a.ExtractAll
+		if err := action.compile(); err != nil {
+			return nil, err
+		}
+		ret = &action
+	}
+	if a.CountryCode != nil {
+		if err := ensureOnly("countryCode"); err != nil {
+			return nil, err
+		}
+		action := postProcessCountryCode(*a.CountryCode)
+		ret = &action
+	}
+	if a.Gender != nil {
+		if err := ensureOnly("gender"); err != nil {
+			return nil, err
+		}
+		action := postProcessGender(*a.Gender)
+		ret = &action
+	}
+	if a.HairColor != nil {
+		if err := ensureOnly("hairColor"); err != nil {
+			return nil, err
+		}
+		ret = &postProcessColor{
+			vocabulary: hairColorVocabulary,
+			overrides:  *a.HairColor,
+		}
+	}
+	if a.EyeColor != nil {
+		if err := ensureOnly("eyeColor"); err != nil {
+			return nil, err
+		}
+		ret = &postProcessColor{
+			vocabulary: eyeColorVocabulary,
+			overrides:  *a.EyeColor,
+		}
+	}
+	if a.NormalizeSeparators != nil {
+		if err := ensureOnly("normalizeSeparators"); err != nil {
+			return nil, err
+		}
+		action := *a.NormalizeSeparators
+		if err := action.compile(); err != nil {
+			return nil, err
+		}
+		ret = &action
+	}
+	if a.PickDate != nil {
+		if err := ensureOnly("pickDate"); err != nil {
+			return nil, err
+		}
+		if err := a.PickDate.validate(); err != nil {
+			return nil, err
+		}
+		action := *a.PickDate
+		ret = &action
+	}
+
+	if a.Math != nil {
+		if err := ensureOnly("math"); err != nil {
+			return nil, err
+		}
+		if err := a.Math.validate(); err != nil {
+			return nil, err
+		}
+		action := *a.Math
+		ret = &action
+	}
+
+	if a.TrimPrefix != "" {
+		if err := ensureOnly("trimPrefix"); err != nil {
+			return nil, err
+		}
+		action := postProcessTrimPrefix(a.TrimPrefix)
+		ret = &action
+	}
+
+	if a.TrimSuffix != "" {
+		if err := ensureOnly("trimSuffix"); err != nil {
+			return nil, err
+		}
+		action := postProcessTrimSuffix(a.TrimSuffix)
+		ret = &action
+	}
+
+	if a.CanonicalizeURL != nil {
+		if err := ensureOnly("canonicalizeURL"); err != nil {
+			return nil, err
+		}
+		action := *a.CanonicalizeURL
+		ret = &action
+	}
+
+	if a.Custom != "" {
+		if err := ensureOnly("custom"); err != nil {
+			return nil, err
+		}
+		fn, err := getCustomAction(a.Custom)
+		if err != nil {
+			return nil, err
+		}
+		ret = &postProcessCustom{fn: fn}
+	}
+
 	if ret == nil {
 		return nil, errors.New("invalid post-process action")
 	}
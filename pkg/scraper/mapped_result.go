@@ -1,8 +1,16 @@
 package scraper
 
 import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/sliceutil"
+	"github.com/stashapp/stash/pkg/sliceutil/stringslice"
 )
 
 type mappedResult map[string]interface{}
@@ -68,6 +76,50 @@ func (r mappedResult) stringSlice(key string) []string {
 	return []string{singleVal}
 }
 
+// aliasSeparatorRE matches the separators between aliases in a single value.
+var aliasSeparatorRE = regexp.MustCompile(`[,\r\n]`)
+
+// aliases returns the value of the key as a comma-delimited string.
+// The value may be a single string or a list of aliases, and each value may
+// itself contain comma- or newline-delimited aliases. Aliases are trimmed, and
+// empty aliases and aliases that differ only in case from an earlier alias are
+// removed. Returns nil if the key is not set or there are no aliases.
+func (r mappedResult) aliases(key string) *string {
+	v := r.stringSlice(key)
+
+	var aliases []string
+	for _, value := range v {
+		for _, alias := range aliasSeparatorRE.Split(value, -1) {
+			if alias = strings.TrimSpace(alias); alias != "" {
+				aliases = append(aliases, alias)
+			}
+		}
+	}
+
+	if len(aliases) == 0 {
+		return nil
+	}
+
+	ret := strings.Join(stringslice.UniqueFold(aliases), ", ")
+	return &ret
+}
+
+// joinedString returns the value of the key as a single string, joining the
+// values with separator if the value is a list.
+func (r mappedResult) joinedString(key string, separator string) *string {
+	v := r.stringSlice(key)
+	if v == nil {
+		return nil
+	}
+
+	ret := strings.Join(v, separator)
+	return &ret
+}
+
+// bodyModSeparator separates the individual tattoos or piercings of a performer.
+// Descriptions may contain commas, so a semicolon is used.
+const bodyModSeparator = "; "
+
 func (r mappedResult) IntPtr(key string) *int {
 	v, ok := r[key]
 	if !ok {
@@ -83,12 +135,90 @@ func (r mappedResult) IntPtr(key string) *int {
 	return &val
 }
 
+// parseIntPtr returns the value of the key parsed as an integer. Returns nil
+// if the key is not set or cannot be parsed.
+func (r mappedResult) parseIntPtr(key string) *int {
+	v := r.stringPtr(key)
+	if v == nil {
+		return nil
+	}
+
+	i, err := strconv.Atoi(strings.TrimSpace(*v))
+	if err != nil {
+		logger.Warnf("Error parsing %s value %q as an integer: %v", key, *v, err)
+		return nil
+	}
+
+	return &i
+}
+
+// isEmpty returns true if the result has no non-empty values.
+func (r mappedResult) isEmpty() bool {
+	for _, v := range r {
+		switch v := v.(type) {
+		case string:
+			if v != "" {
+				return false
+			}
+		case []string:
+			for _, vv := range v {
+				if vv != "" {
+					return false
+				}
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// substitute replaces values that exactly match a key in substitutions with
+// the corresponding value. Results are modified in place.
+func (r mappedResults) substitute(substitutions map[string]string) mappedResults {
+	if len(substitutions) == 0 {
+		return r
+	}
+
+	for _, result := range r {
+		for k, v := range result {
+			switch v := v.(type) {
+			case string:
+				if sub, ok := substitutions[v]; ok {
+					result[k] = sub
+				}
+			case []string:
+				for i, vv := range v {
+					if sub, ok := substitutions[vv]; ok {
+						v[i] = sub
+					}
+				}
+			}
+		}
+	}
+
+	return r
+}
+
+// nonEmpty returns the results, excluding results that have no non-empty values.
+func (r mappedResults) nonEmpty() mappedResults {
+	var ret mappedResults
+	for _, result := range r {
+		if !result.isEmpty() {
+			ret = append(ret, result)
+		}
+	}
+
+	return ret
+}
+
 func (r mappedResults) setSingleValue(index int, key string, value string) mappedResults {
 	if index >= len(r) {
 		r = append(r, make(mappedResult))
 	}
 
-	logger.Debugf(`[%d][%s] = %s`, index, key, value)
+	logger.Debugf(`[%d][%s] = %s`, index, key, redactSecrets(value))
 	r[index][key] = value
 	return r
 }
@@ -98,11 +228,26 @@ func (r mappedResults) setMultiValue(index int, key string, value []string) mapp
 		r = append(r, make(mappedResult))
 	}
 
-	logger.Debugf(`[%d][%s] = %s`, index, key, value)
+	logger.Debugf(`[%d][%s] = %s`, index, key, redactSecrets(fmt.Sprint(value)))
 	r[index][key] = value
 	return r
 }
 
+// setValues sets the values of key. If isMulti returns true for the key, then
+// the values are set as a multi-value of the first result. Otherwise, each
+// value is set on the result at the same index.
+func (r mappedResults) setValues(key string, values []string, isMulti isMultiFunc) mappedResults {
+	// HACK - if the key is URLs, then we need to set the value as a multi-value
+	if isMulti != nil && isMulti(key) {
+		return r.setMultiValue(0, key, values)
+	}
+
+	for i, text := range values {
+		r = r.setSingleValue(i, key, text)
+	}
+	return r
+}
+
 func (r mappedResults) scrapedTags() []*models.ScrapedTag {
 	if len(r) == 0 {
 		return nil
@@ -122,6 +267,29 @@ func (r mappedResult) scrapedTag() *models.ScrapedTag {
 	}
 }
 
+// customFields returns the values of the result at the provided index as a
+// map of custom field names to values. Returns nil if there are no values.
+func (r mappedResults) customFields(index int) map[string]interface{} {
+	if index >= len(r) {
+		return nil
+	}
+
+	var ret map[string]interface{}
+	for k := range r[index] {
+		v, _ := r[index].string(k)
+		if v == "" {
+			continue
+		}
+
+		if ret == nil {
+			ret = make(map[string]interface{})
+		}
+		ret[k] = v
+	}
+
+	return ret
+}
+
 func (r mappedResult) scrapedPerformer() *models.ScrapedPerformer {
 	ret := &models.ScrapedPerformer{
 		Name:           r.stringPtr("Name"),
@@ -140,9 +308,9 @@ func (r mappedResult) scrapedPerformer() *models.ScrapedPerformer {
 		PenisLength:    r.stringPtr("PenisLength"),
 		Circumcised:    r.stringPtr("Circumcised"),
 		CareerLength:   r.stringPtr("CareerLength"),
-		Tattoos:        r.stringPtr("Tattoos"),
-		Piercings:      r.stringPtr("Piercings"),
-		Aliases:        r.stringPtr("Aliases"),
+		Tattoos:        r.joinedString("Tattoos", bodyModSeparator),
+		Piercings:      r.joinedString("Piercings", bodyModSeparator),
+		Aliases:        r.aliases("Aliases"),
 		Image:          r.stringPtr("Image"),
 		Images:         r.stringSlice("Images"),
 		Details:        r.stringPtr("Details"),
@@ -153,7 +321,35 @@ func (r mappedResult) scrapedPerformer() *models.ScrapedPerformer {
 	return ret
 }
 
+// setPerformerLinks sets the fields of the performer from the labelled links
+// in the results. Links with a known label set the matching field, if it is
+// not already set. All links are added to the performer's URLs.
+func (r mappedResults) setPerformerLinks(p *models.ScrapedPerformer) {
+	for _, result := range r.nonEmpty() {
+		u, _ := result.string("URL")
+		u = strings.TrimSpace(u)
+		if u == "" {
+			continue
+		}
+
+		label, _ := result.string("Label")
+		switch strings.ToLower(strings.TrimSpace(label)) {
+		case "twitter", "x":
+			if p.Twitter == nil {
+				p.Twitter = &u
+			}
+		case "instagram":
+			if p.Instagram == nil {
+				p.Instagram = &u
+			}
+		}
+
+		p.URLs = sliceutil.AppendUnique(p.URLs, u)
+	}
+}
+
 func (r mappedResults) scrapedPerformers() []*models.ScrapedPerformer {
+	r = r.nonEmpty()
 	if len(r) == 0 {
 		return nil
 	}
@@ -181,6 +377,20 @@ func (r mappedResult) scrapedScene() *models.ScrapedScene {
 	return ret
 }
 
+func (r mappedResults) scrapedScenes() []*models.ScrapedScene {
+	r = r.nonEmpty()
+	if len(r) == 0 {
+		return nil
+	}
+
+	ret := make([]*models.ScrapedScene, len(r))
+	for i, result := range r {
+		ret[i] = result.scrapedScene()
+	}
+
+	return ret
+}
+
 func (r mappedResult) scrapedImage() *models.ScrapedImage {
 	ret := &models.ScrapedImage{
 		Title:        r.stringPtr("Title"),
@@ -202,6 +412,7 @@ func (r mappedResult) scrapedGallery() *models.ScrapedGallery {
 		URL:          r.stringPtr("URL"),
 		URLs:         r.stringSlice("URLs"),
 		Date:         r.stringPtr("Date"),
+		Images:       r.stringSlice("Images"),
 	}
 	return ret
 }
@@ -213,7 +424,7 @@ func (r mappedResult) scrapedStudio() *models.ScrapedStudio {
 		URLs:    r.stringSlice("URLs"),
 		Image:   r.stringPtr("Image"),
 		Details: r.stringPtr("Details"),
-		Aliases: r.stringPtr("Aliases"),
+		Aliases: r.aliases("Aliases"),
 	}
 	return ret
 }
@@ -221,7 +432,7 @@ func (r mappedResult) scrapedStudio() *models.ScrapedStudio {
 func (r mappedResult) scrapedMovie() *models.ScrapedMovie {
 	ret := &models.ScrapedMovie{
 		Name:       r.stringPtr("Name"),
-		Aliases:    r.stringPtr("Aliases"),
+		Aliases:    r.aliases("Aliases"),
 		URLs:       r.stringSlice("URLs"),
 		Duration:   r.stringPtr("Duration"),
 		Date:       r.stringPtr("Date"),
@@ -237,7 +448,7 @@ func (r mappedResult) scrapedMovie() *models.ScrapedMovie {
 func (r mappedResult) scrapedGroup() *models.ScrapedGroup {
 	ret := &models.ScrapedGroup{
 		Name:       r.stringPtr("Name"),
-		Aliases:    r.stringPtr("Aliases"),
+		Aliases:    r.aliases("Aliases"),
 		URL:        r.stringPtr("URL"),
 		URLs:       r.stringSlice("URLs"),
 		Duration:   r.stringPtr("Duration"),
@@ -246,15 +457,107 @@ func (r mappedResult) scrapedGroup() *models.ScrapedGroup {
 		Synopsis:   r.stringPtr("Synopsis"),
 		FrontImage: r.stringPtr("FrontImage"),
 		BackImage:  r.stringPtr("BackImage"),
+		SceneIndex: r.parseIntPtr("SceneIndex"),
+	}
+
+	return ret
+}
+
+func (r mappedResults) scrapedStudios() []*models.ScrapedStudio {
+	r = r.nonEmpty()
+	if len(r) == 0 {
+		return nil
+	}
+
+	ret := make([]*models.ScrapedStudio, len(r))
+	for i, result := range r {
+		ret[i] = result.scrapedStudio()
 	}
 
 	return ret
 }
 
+// scrapedStudioAt returns the studio of the result at index i.
+// Returns nil if there is no such result, or if it has no name.
+func (r mappedResults) scrapedStudioAt(i int) *models.ScrapedStudio {
+	if i >= len(r) {
+		return nil
+	}
+
+	if name, _ := r[i]["Name"].(string); name == "" {
+		return nil
+	}
+
+	return r[i].scrapedStudio()
+}
+
+func (r mappedResults) scrapedSceneMarkers() []*models.ScrapedSceneMarker {
+	var ret []*models.ScrapedSceneMarker
+	for _, result := range r.nonEmpty() {
+		if m := result.scrapedSceneMarker(); m != nil {
+			ret = append(ret, m)
+		}
+	}
+
+	return ret
+}
+
+// scrapedSceneMarker returns the marker for the result, or nil if the
+// result does not have a valid Seconds value.
+func (r mappedResult) scrapedSceneMarker() *models.ScrapedSceneMarker {
+	seconds, _ := r.string("Seconds")
+	parsed, err := parseDuration(seconds)
+	if err != nil {
+		logger.Warnf("Ignoring scene marker with invalid time %q: %v", seconds, err)
+		return nil
+	}
+
+	title, _ := r.string("Title")
+	ret := &models.ScrapedSceneMarker{
+		Title:   title,
+		Seconds: parsed,
+	}
+
+	if tag, _ := r.string("Tag"); tag != "" {
+		ret.Tag = &models.ScrapedTag{
+			Name: tag,
+		}
+	}
+
+	return ret
+}
+
+// parseDuration parses a duration in the form [[hh:]mm:]ss[.fff] into seconds.
+func parseDuration(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, errors.New("empty duration")
+	}
+
+	parts := strings.Split(value, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("too many components in duration %q", value)
+	}
+
+	var ret float64
+	for _, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+
+		ret = ret*60 + v
+	}
+
+	return ret, nil
+}
+
 func (r mappedResults) scrapedMovies() []*models.ScrapedMovie {
+	r = r.nonEmpty()
 	if len(r) == 0 {
 		return nil
 	}
+
 	ret := make([]*models.ScrapedMovie, len(r))
 	for i, result := range r {
 		ret[i] = result.scrapedMovie()
@@ -264,9 +567,11 @@ func (r mappedResults) scrapedMovies() []*models.ScrapedMovie {
 }
 
 func (r mappedResults) scrapedGroups() []*models.ScrapedGroup {
+	r = r.nonEmpty()
 	if len(r) == 0 {
 		return nil
 	}
+
 	ret := make([]*models.ScrapedGroup, len(r))
 	for i, result := range r {
 		ret[i] = result.scrapedGroup()
@@ -173,6 +173,57 @@ func TestMappedResultStringSlice(t *testing.T) {
 	}
 }
 
+// Test aliases method
+func TestMappedResultAliases(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     mappedResult
+		expected *string
+	}{
+		{
+			name:     "missing key",
+			data:     mappedResult{},
+			expected: nil,
+		},
+		{
+			name:     "single alias",
+			data:     mappedResult{"Aliases": "Alias"},
+			expected: strPtr("Alias"),
+		},
+		{
+			name:     "comma-delimited",
+			data:     mappedResult{"Aliases": "Alias One,Alias Two ,  Alias Three"},
+			expected: strPtr("Alias One, Alias Two, Alias Three"),
+		},
+		{
+			name:     "newline-delimited",
+			data:     mappedResult{"Aliases": "\n  Alias One\r\n  Alias Two\n\n"},
+			expected: strPtr("Alias One, Alias Two"),
+		},
+		{
+			name:     "list of delimited values",
+			data:     mappedResult{"Aliases": []string{"Alias One, Alias Two", "Alias Three"}},
+			expected: strPtr("Alias One, Alias Two, Alias Three"),
+		},
+		{
+			name:     "duplicates removed ignoring case",
+			data:     mappedResult{"Aliases": "Alias One, alias one\nALIAS ONE, Alias Two"},
+			expected: strPtr("Alias One, Alias Two"),
+		},
+		{
+			name:     "only separators",
+			data:     mappedResult{"Aliases": " , \n"},
+			expected: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.data.aliases("Aliases"))
+		})
+	}
+}
+
 // Test IntPtr method
 func TestMappedResultIntPtr(t *testing.T) {
 	tests := []struct {
@@ -457,6 +508,47 @@ func TestMappedResultScrapedPerformer(t *testing.T) {
 				assert.Nil(t, p.Name)
 				assert.Nil(t, p.Gender)
 				assert.Empty(t, p.URLs)
+				assert.Nil(t, p.Aliases)
+			},
+		},
+		{
+			name: "single alias",
+			data: mappedResult{
+				"Aliases": "Jane Smith",
+			},
+			validate: func(t *testing.T, p *models.ScrapedPerformer) {
+				assert.Equal(t, "Jane Smith", *p.Aliases)
+			},
+		},
+		{
+			name: "multiple aliases",
+			data: mappedResult{
+				"Aliases": []string{"Jane Smith", "JD"},
+			},
+			validate: func(t *testing.T, p *models.ScrapedPerformer) {
+				assert.Equal(t, "Jane Smith, JD", *p.Aliases)
+			},
+		},
+		{
+			name: "single tattoo and piercing",
+			data: mappedResult{
+				"Tattoos":   "Rose, left arm",
+				"Piercings": "Navel",
+			},
+			validate: func(t *testing.T, p *models.ScrapedPerformer) {
+				assert.Equal(t, "Rose, left arm", *p.Tattoos)
+				assert.Equal(t, "Navel", *p.Piercings)
+			},
+		},
+		{
+			name: "multiple tattoos and piercings",
+			data: mappedResult{
+				"Tattoos":   []string{"Rose, left arm", "Star, ankle"},
+				"Piercings": []string{"Navel", "Tongue"},
+			},
+			validate: func(t *testing.T, p *models.ScrapedPerformer) {
+				assert.Equal(t, "Rose, left arm; Star, ankle", *p.Tattoos)
+				assert.Equal(t, "Navel; Tongue", *p.Piercings)
 			},
 		},
 	}
@@ -497,6 +589,24 @@ func TestMappedResultsScrapedPerformers(t *testing.T) {
 			},
 			expectedCount: 3,
 		},
+		{
+			name: "empty entries dropped",
+			data: mappedResults{
+				mappedResult{"Name": "Jane Doe"},
+				mappedResult{"Name": "", "URLs": []string{""}},
+				mappedResult{},
+				mappedResult{"Name": "", "URLs": []string{"url1"}},
+			},
+			expectedCount: 2,
+		},
+		{
+			name: "all entries empty",
+			data: mappedResults{
+				mappedResult{"Name": ""},
+				mappedResult{},
+			},
+			expectedCount: 0,
+		},
 	}
 
 	for _, test := range tests {
@@ -786,6 +896,24 @@ func TestMappedResultsScrapedMovies(t *testing.T) {
 			},
 			expectedCount: 3,
 		},
+		{
+			name: "empty entries dropped",
+			data: mappedResults{
+				mappedResult{"Name": "Movie 1"},
+				mappedResult{"Name": "", "URLs": []string{""}},
+				mappedResult{},
+				mappedResult{"Name": "", "URLs": []string{"url1"}},
+			},
+			expectedCount: 2,
+		},
+		{
+			name: "all entries empty",
+			data: mappedResults{
+				mappedResult{"Name": ""},
+				mappedResult{},
+			},
+			expectedCount: 0,
+		},
 	}
 
 	for _, test := range tests {
@@ -843,6 +971,30 @@ func TestMappedResultScrapedGroup(t *testing.T) {
 				assert.NotNil(t, g)
 				assert.Nil(t, g.Name)
 				assert.Empty(t, g.URLs)
+				assert.Nil(t, g.SceneIndex)
+			},
+		},
+		{
+			name: "scene index",
+			data: mappedResult{
+				"Name":       "Series",
+				"SceneIndex": " 3 ",
+			},
+			validate: func(t *testing.T, g *models.ScrapedGroup) {
+				if assert.NotNil(t, g.SceneIndex) {
+					assert.Equal(t, 3, *g.SceneIndex)
+				}
+			},
+		},
+		{
+			name: "invalid scene index",
+			data: mappedResult{
+				"Name":       "Series",
+				"SceneIndex": "Episode Three",
+			},
+			validate: func(t *testing.T, g *models.ScrapedGroup) {
+				assert.Equal(t, "Series", *g.Name)
+				assert.Nil(t, g.SceneIndex)
 			},
 		},
 	}
@@ -883,6 +1035,24 @@ func TestMappedResultsScrapedGroups(t *testing.T) {
 			},
 			expectedCount: 3,
 		},
+		{
+			name: "empty entries dropped",
+			data: mappedResults{
+				mappedResult{"Name": "Group 1"},
+				mappedResult{"Name": "", "URLs": []string{""}},
+				mappedResult{},
+				mappedResult{"Name": "", "URLs": []string{"url1"}},
+			},
+			expectedCount: 2,
+		},
+		{
+			name: "all entries empty",
+			data: mappedResults{
+				mappedResult{"Name": ""},
+				mappedResult{},
+			},
+			expectedCount: 0,
+		},
 	}
 
 	for _, test := range tests {
@@ -62,6 +62,37 @@ func TestFeetToCM(t *testing.T) {
 	}
 }
 
+func Test_postProcessConvertUnits_Apply(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"5'10\"", "178"},
+		{"5 ft 10 in", "178"},
+		{"6ft", "183"},
+		{"70 in", "178"},
+		{"178 cm", "178"},
+		{"178cm (5'10\")", "178"},
+		{"177.8 cm", "178"},
+		{"70 kg", "70"},
+		{"154 lb", "70"},
+		{"154lbs", "70"},
+		{"154 lbs (70 kg)", "70"},
+		{"178", "178"},
+		{"", ""},
+		{"unknown", "unknown"},
+	}
+
+	pp := postProcessConvertUnits(true)
+	ctx := context.Background()
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.want, pp.Apply(ctx, tt.value, nil))
+		})
+	}
+}
+
 func Test_postProcessParseDate_Apply(t *testing.T) {
 	const internalDateFormat = "2006-01-02"
 
@@ -115,3 +146,702 @@ func Test_postProcessParseDate_Apply(t *testing.T) {
 		})
 	}
 }
+
+func Test_postProcessParseDateFormat_Apply(t *testing.T) {
+	tests := []struct {
+		name  string
+		arg   postProcessParseDateFormat
+		value string
+		want  string
+	}{
+		{
+			"alternate output",
+			postProcessParseDateFormat{ParseDate: "January 2, 2006", OutputFormat: "2006/01/02"},
+			"March 23, 2001",
+			"2001/03/23",
+		},
+		{
+			"default output",
+			postProcessParseDateFormat{ParseDate: "January 2, 2006", OutputFormat: internalDateFormat},
+			"March 23, 2001",
+			"2001-03-23",
+		},
+		{
+			"today",
+			postProcessParseDateFormat{OutputFormat: "2006/01/02"},
+			"today",
+			time.Now().Format("2006/01/02"),
+		},
+		{
+			"invalid",
+			postProcessParseDateFormat{ParseDate: "January 2, 2006", OutputFormat: "2006/01/02"},
+			"2001=03=23",
+			"2001=03=23",
+		},
+	}
+
+	ctx := context.Background()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.arg.Apply(ctx, tt.value, nil); got != tt.want {
+				t.Errorf("postProcessParseDateFormat.Apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDateOutputFormatYAML(t *testing.T) {
+	yamlStr := `name: Test
+sceneByURL:
+  - action: scrapeXPath
+    url:
+      - test.com
+    scraper: sceneScraper
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Date:
+        selector: //span
+        postProcess:
+          - parseDate: January 2, 2006
+            outputFormat: 2006/01/02
+      Code:
+        selector: //code
+        postProcess:
+          - parseDate: January 2, 2006
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	ctx := context.Background()
+	sceneConfig := c.XPathScrapers["sceneScraper"].Scene
+
+	got := sceneConfig.mappedConfig["Date"].postProcess(ctx, "March 23, 2001", nil)
+	assert.Equal(t, "2001/03/23", got)
+
+	got = sceneConfig.mappedConfig["Code"].postProcess(ctx, "March 23, 2001", nil)
+	assert.Equal(t, "2001-03-23", got)
+
+	invalidStr := `name: Test
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Date:
+        selector: //span
+        postProcess:
+          - outputFormat: 2006/01/02
+`
+
+	c = &Definition{}
+	if err := yaml.Unmarshal([]byte(invalidStr), &c); err == nil {
+		t.Error("expected error unmarshalling outputFormat without parseDate")
+	}
+}
+
+func Test_postProcessPick_Apply(t *testing.T) {
+	tests := []struct {
+		name  string
+		arg   postProcessPick
+		value string
+		want  string
+	}{
+		{
+			"first",
+			postProcessPick{Delimiter: "-", Index: 0},
+			"170-175 cm",
+			"170",
+		},
+		{
+			"last",
+			postProcessPick{Delimiter: "-", Index: 2},
+			"36-24-36",
+			"36",
+		},
+		{
+			"negative",
+			postProcessPick{Delimiter: "-", Index: -1},
+			"170-175 cm",
+			"175 cm",
+		},
+		{
+			"out of range",
+			postProcessPick{Delimiter: "-", Index: 3},
+			"36-24-36",
+			"",
+		},
+		{
+			"negative out of range",
+			postProcessPick{Delimiter: "-", Index: -4},
+			"36-24-36",
+			"",
+		},
+	}
+
+	ctx := context.Background()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.arg.Apply(ctx, tt.value, nil); got != tt.want {
+				t.Errorf("postProcessPick.Apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_postProcessPickDate_Apply(t *testing.T) {
+	tests := []struct {
+		name  string
+		arg   postProcessPickDate
+		value string
+		want  string
+	}{
+		{"earliest", postProcessPickDate{Delimiter: "|", Pick: pickDateEarliest}, "2021-03-04|2020-01-02|2022-05-06", "2020-01-02"},
+		{"latest", postProcessPickDate{Delimiter: "|", Pick: pickDateLatest}, "2021-03-04|2020-01-02|2022-05-06", "2022-05-06"},
+		{"earliest malformed", postProcessPickDate{Delimiter: "|", Pick: pickDateEarliest}, "2021-03-04 | unknown | 2020-01-02", "2020-01-02"},
+		{"latest malformed", postProcessPickDate{Delimiter: "|", Pick: pickDateLatest}, "2021-03-04 | 2023-13-45 | 2020-01-02", "2021-03-04"},
+		{"format", postProcessPickDate{Delimiter: ";", Format: "January 2, 2006", Pick: pickDateLatest}, "March 4, 2021; May 6, 2019", "2021-03-04"},
+		{"none parsed", postProcessPickDate{Delimiter: "|", Pick: pickDateEarliest}, "unknown|tbd", ""},
+	}
+
+	ctx := context.Background()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.arg.Apply(ctx, tt.value, nil); got != tt.want {
+				t.Errorf("postProcessPickDate.Apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPickDateYAML(t *testing.T) {
+	valid := mappedPostProcessAction{PickDate: &postProcessPickDate{Delimiter: "|", Pick: pickDateLatest}}
+	action, err := valid.ToPostProcessAction()
+	if assert.NoError(t, err) {
+		assert.Equal(t, &postProcessPickDate{Delimiter: "|", Pick: pickDateLatest}, action)
+	}
+
+	noDelimiter := mappedPostProcessAction{PickDate: &postProcessPickDate{Pick: pickDateLatest}}
+	_, err = noDelimiter.ToPostProcessAction()
+	assert.Error(t, err)
+
+	invalidPick := mappedPostProcessAction{PickDate: &postProcessPickDate{Delimiter: "|", Pick: "first"}}
+	_, err = invalidPick.ToPostProcessAction()
+	assert.Error(t, err)
+}
+
+func Test_postProcessMath_Apply(t *testing.T) {
+	float := func(v float64) *float64 {
+		return &v
+	}
+
+	tests := []struct {
+		name  string
+		arg   postProcessMath
+		value string
+		want  string
+	}{
+		{"minutes to seconds", postProcessMath{Multiply: float(60)}, "25", "1500"},
+		{"scale", postProcessMath{Multiply: float(100)}, "0.85", "85"},
+		{"fractional", postProcessMath{Divide: float(4)}, "5", "1.25"},
+		{"add", postProcessMath{Add: float(1)}, " 2 ", "3"},
+		{"subtract", postProcessMath{Subtract: float(2.5)}, "1", "-1.5"},
+		{"invalid", postProcessMath{Multiply: float(60)}, "25 min", "25 min"},
+	}
+
+	ctx := context.Background()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.arg.Apply(ctx, tt.value, nil); got != tt.want {
+				t.Errorf("postProcessMath.Apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMathYAML(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Duration:
+        selector: //span
+        postProcess:
+          - math:
+              multiply: 60
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	sceneConfig := c.XPathScrapers["sceneScraper"].Scene
+	got := sceneConfig.mappedConfig["Duration"].postProcess(context.Background(), "25", nil)
+	assert.Equal(t, "1500", got)
+
+	invalid := []string{
+		"math: {}",
+		"math: {multiply: 2, add: 1}",
+		"math: {divide: 0}",
+	}
+
+	for _, v := range invalid {
+		invalidStr := `name: Test
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Duration:
+        selector: //span
+        postProcess:
+          - ` + v + "\n"
+
+		c = &Definition{}
+		if err := yaml.Unmarshal([]byte(invalidStr), &c); err == nil {
+			t.Errorf("expected error unmarshalling %s", v)
+		}
+	}
+}
+
+func TestTrimAffixYAML(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Title:
+        selector: //h1
+        postProcess:
+          - trimPrefix: "Title: "
+          - trimSuffix: " - Studio"
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	title := c.XPathScrapers["sceneScraper"].Scene.mappedConfig["Title"]
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"both present", "Title: Scene Name - Studio", "Scene Name"},
+		{"prefix only", "Title: Scene Name", "Scene Name"},
+		{"suffix only", "Scene Name - Studio", "Scene Name"},
+		{"absent", "Scene Name", "Scene Name"},
+		{"not at start or end", "Scene Title: Name - Studio Cut", "Scene Title: Name - Studio Cut"},
+	}
+
+	ctx := context.Background()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, title.postProcess(ctx, tt.value, nil))
+		})
+	}
+}
+
+func Test_postProcessCanonicalizeURL_Apply(t *testing.T) {
+	pp := postProcessCanonicalizeURL{
+		DropParams: []string{"utm_*", "ref"},
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"tracking params", "https://example.com/scene/1?utm_source=x&utm_medium=y&ref=abc", "https://example.com/scene/1"},
+		{"kept params sorted", "https://example.com/scene?page=2&id=1&ref=abc", "https://example.com/scene?id=1&page=2"},
+		{"mixed case host", "https://WWW.Example.COM/Scene/1", "https://www.example.com/Scene/1"},
+		{"default port", "https://example.com:443/scene/", "https://example.com/scene"},
+		{"http default port", "http://example.com:80/", "http://example.com"},
+		{"non-default port", "http://Example.com:8080/scene", "http://example.com:8080/scene"},
+		{"not a url", "scene title", "scene title"},
+	}
+
+	ctx := context.Background()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pp.Apply(ctx, tt.value, nil); got != tt.want {
+				t.Errorf("postProcessCanonicalizeURL.Apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_postProcessAgeToBirthYear_Apply(t *testing.T) {
+	now := func() time.Time {
+		return time.Date(2024, time.June, 15, 12, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		value  string
+		want   string
+	}{
+		{"date", ageToBirthYearDate, "34", "1990-01-01"},
+		{"year", ageToBirthYearYear, "34", "1990"},
+		{"with label", ageToBirthYearDate, "Age: 34 years", "1990-01-01"},
+		{"invalid", ageToBirthYearDate, "unknown", "unknown"},
+	}
+
+	ctx := context.Background()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &postProcessAgeToBirthYear{
+				Format: tt.format,
+				now:    now,
+			}
+			if got := p.Apply(ctx, tt.value, nil); got != tt.want {
+				t.Errorf("postProcessAgeToBirthYear.Apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAgeToBirthYearYAML(t *testing.T) {
+	valid := mappedPostProcessAction{AgeToBirthYear: ageToBirthYearYear}
+	action, err := valid.ToPostProcessAction()
+	if assert.NoError(t, err) {
+		assert.Equal(t, &postProcessAgeToBirthYear{Format: ageToBirthYearYear}, action)
+	}
+
+	invalid := mappedPostProcessAction{AgeToBirthYear: "month"}
+	_, err = invalid.ToPostProcessAction()
+	assert.Error(t, err)
+}
+
+func TestExtractFromURLYAML(t *testing.T) {
+	valid := mappedPostProcessAction{ExtractFromURL: `/videos/(\d+)`}
+	action, err := valid.ToPostProcessAction()
+	if assert.NoError(t, err) {
+		assert.IsType(t, &postProcessExtractFromURL{}, action)
+	}
+
+	invalid := mappedPostProcessAction{ExtractFromURL: `/videos/(\d+`}
+	_, err = invalid.ToPostProcessAction()
+	assert.Error(t, err)
+}
+
+func Test_postProcessMeasurementsToCm_Apply(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"bust waist hips", "34D-24-36", "86D-61-91"},
+		{"cup range", "32DD-25-35", "81DD-64-89"},
+		{"decimal", "34.5-24-36", "88-61-91"},
+		{"already cm", "86-61-91 cm", "86-61-91 cm"},
+		{"no numbers", "unknown", "unknown"},
+	}
+
+	ctx := context.Background()
+	p := postProcessMeasurementsToCm(true)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Apply(ctx, tt.value, nil); got != tt.want {
+				t.Errorf("postProcessMeasurementsToCm.Apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlsoYAML(t *testing.T) {
+	const valid = `
+selector: //span
+also:
+  Other:
+    postProcess:
+      - measurementsToCm: true
+`
+	var c mappedScraperAttrConfig
+	if assert.NoError(t, yaml.Unmarshal([]byte(valid), &c)) {
+		assert.Equal(t, []string{"Other"}, c.alsoKeys())
+		assert.Len(t, c.Also["Other"].postProcessActions, 1)
+	}
+
+	// the other keys must use the values found by the selector
+	const withSelector = `
+selector: //span
+also:
+  Other: //div
+`
+	c = mappedScraperAttrConfig{}
+	assert.Error(t, yaml.Unmarshal([]byte(withSelector), &c))
+}
+
+func Test_postProcessExtractAll_ApplyMulti(t *testing.T) {
+	group := func(i int) *int { return &i }
+
+	tests := []struct {
+		name  string
+		arg   postProcessExtractAll
+		value string
+		want  []string
+	}{
+		{"first group by default", postProcessExtractAll{Regex: `#(\w+)`}, "#one and #two, #three", []string{"one", "two", "three"}},
+		{"whole match without groups", postProcessExtractAll{Regex: `#\w+`}, "#one and #two", []string{"#one", "#two"}},
+		{"explicit group", postProcessExtractAll{Regex: `(\w+)=(\w+)`, Group: group(2)}, "a=1;b=2", []string{"1", "2"}},
+		{"group zero", postProcessExtractAll{Regex: `(\w+)=(\w+)`, Group: group(0)}, "a=1;b=2", []string{"a=1", "b=2"}},
+		{"empty groups omitted", postProcessExtractAll{Regex: `\[(\w*)\]`}, "[a][][b]", []string{"a", "b"}},
+		{"no match", postProcessExtractAll{Regex: `#(\w+)`}, "none", nil},
+	}
+
+	ctx := context.Background()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.arg.compile(); err != nil {
+				t.Fatalf("compile() error = %v", err)
+			}
+			assert.Equal(t, tt.want, tt.arg.ApplyMulti(ctx, tt.value, nil))
+		})
+	}
+}
+
+func TestExtractAllYAML(t *testing.T) {
+	group := func(i int) *int { return &i }
+
+	valid := mappedPostProcessAction{ExtractAll: &postProcessExtractAll{Regex: `#(\w+)`}}
+	action, err := valid.ToPostProcessAction()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "two", action.Apply(context.Background(), "#two #three", nil))
+	}
+
+	invalid := []postProcessExtractAll{
+		{},
+		{Regex: `#(\w+`},
+		{Regex: `#(\w+)`, Group: group(2)},
+		{Regex: `#(\w+)`, Group: group(-1)},
+	}
+	for _, p := range invalid {
+		a := mappedPostProcessAction{ExtractAll: &p}
+		_, err := a.ToPostProcessAction()
+		assert.Error(t, err)
+	}
+}
+
+func Test_postProcessCountryCode_Apply(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"english name", "United States", "US"},
+		{"native name", "Deutschland", "DE"},
+		{"case insensitive", "  united STATES ", "US"},
+		{"code", "fr", "FR"},
+		{"code alias", "UK", "GB"},
+		{"unknown code", "ZZ", "ZZ"},
+		{"override", "Holland", "XX"},
+		{"unknown", "Atlantis", "Atlantis"},
+	}
+
+	ctx := context.Background()
+	p := postProcessCountryCode{"holland": "XX"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Apply(ctx, tt.value, nil); got != tt.want {
+				t.Errorf("postProcessCountryCode.Apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountryCodeYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		want    postProcessCountryCode
+		wantErr bool
+	}{
+		{"enabled", "countryCode: true", postProcessCountryCode{}, false},
+		{"overrides", "countryCode:\n  Ivory Coast: CI", postProcessCountryCode{"Ivory Coast": "CI"}, false},
+		{"disabled", "countryCode: false", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var a mappedPostProcessAction
+			err := yaml.Unmarshal([]byte(tt.yaml), &a)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			action, err := a.ToPostProcessAction()
+			if assert.NoError(t, err) {
+				assert.Equal(t, &tt.want, action)
+			}
+		})
+	}
+}
+
+func Test_postProcessColor_Apply(t *testing.T) {
+	hair := &postProcessColor{
+		vocabulary: hairColorVocabulary,
+		overrides:  mappedOverridesConfig{"Salt and Pepper": "Grey"},
+	}
+	eye := &postProcessColor{
+		vocabulary: eyeColorVocabulary,
+	}
+
+	tests := []struct {
+		name  string
+		p     *postProcessColor
+		value string
+		want  string
+	}{
+		{"hair canonical", hair, "Blonde", "Blonde"},
+		{"hair spelling", hair, "blond", "Blonde"},
+		{"hair modifier", hair, "Lt. Brown", "Brunette"},
+		{"hair hyphenated", hair, "dark-brown hair", "Brunette"},
+		{"hair gray", hair, "GRAY", "Grey"},
+		{"hair override", hair, "salt and pepper", "Grey"},
+		{"hair unknown", hair, "Teal", "Teal"},
+		{"eye abbreviated", eye, "lt. brown", "Brown"},
+		{"eye light", eye, "Light Brown", "Brown"},
+		{"eye suffix", eye, "Blue Eyes", "Blue"},
+		{"eye unknown", eye, "Blue-Green", "Blue-Green"},
+	}
+
+	ctx := context.Background()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.p.Apply(ctx, tt.value, nil); got != tt.want {
+				t.Errorf("postProcessColor.Apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_postProcessGender_Apply(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"letter", "F", "FEMALE"},
+		{"lowercase", "female", "FEMALE"},
+		{"noun", "Woman", "FEMALE"},
+		{"male", " Male ", "MALE"},
+		{"trans female", "Trans Female", "TRANSGENDER_FEMALE"},
+		{"trans male hyphenated", "trans-man", "TRANSGENDER_MALE"},
+		{"non binary", "Non-Binary", "NON_BINARY"},
+		{"canonical", "TRANSGENDER_FEMALE", "TRANSGENDER_FEMALE"},
+		{"override", "Femme", "FEMALE"},
+		{"unknown", "Robot", "Robot"},
+		{"empty", "", ""},
+	}
+
+	ctx := context.Background()
+	p := postProcessGender{"femme": "FEMALE"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Apply(ctx, tt.value, nil); got != tt.want {
+				t.Errorf("postProcessGender.Apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_postProcessNormalizeSeparators_Apply(t *testing.T) {
+	tests := []struct {
+		name  string
+		arg   postProcessNormalizeSeparators
+		value string
+		want  string
+	}{
+		{"spaces around hyphens", postProcessNormalizeSeparators{}, "36 - 24 - 36", "36-24-36"},
+		{"dashes", postProcessNormalizeSeparators{}, "34D–24—36", "34D-24-36"},
+		{"unchanged", postProcessNormalizeSeparators{}, "36-24-36", "36-24-36"},
+		{"target separator", postProcessNormalizeSeparators{Separator: "/"}, "36 - 24 – 36", "36/24/36"},
+		{"custom match", postProcessNormalizeSeparators{Match: []string{".", " "}}, " 555.123  4567 ", "555-123-4567"},
+		{"longer match first", postProcessNormalizeSeparators{Match: []string{"-", "--"}, Separator: "x"}, "36--24", "36x24"},
+		{"spaces kept", postProcessNormalizeSeparators{}, "size  36 - 24", "size  36-24"},
+		{"collapse spaces", postProcessNormalizeSeparators{CollapseSpaces: true}, "size  36 - 24", "size 36-24"},
+	}
+
+	ctx := context.Background()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.arg.compile(); err != nil {
+				t.Fatalf("compile() error = %v", err)
+			}
+			assert.Equal(t, tt.want, tt.arg.Apply(ctx, tt.value, nil))
+		})
+	}
+}
+
+func TestNormalizeSeparatorsYAML(t *testing.T) {
+	yamlStr := `name: Test
+xPathScrapers:
+  performerScraper:
+    performer:
+      Measurements:
+        selector: //span
+        postProcess:
+          - normalizeSeparators: true
+      Tattoos:
+        selector: //span
+        postProcess:
+          - normalizeSeparators:
+              separator: ", "
+              match: [";", "/"]
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	ctx := context.Background()
+	performerConfig := c.XPathScrapers["performerScraper"].Performer
+
+	got := performerConfig.mappedConfig["Measurements"].postProcess(ctx, "36 - 24 - 36", nil)
+	assert.Equal(t, "36-24-36", got)
+
+	got = performerConfig.mappedConfig["Tattoos"].postProcess(ctx, "arm / back;leg", nil)
+	assert.Equal(t, "arm, back, leg", got)
+
+	invalid := []string{
+		"normalizeSeparators: false",
+		`normalizeSeparators: {match: [""]}`,
+	}
+	for _, action := range invalid {
+		invalidStr := `name: Test
+xPathScrapers:
+  performerScraper:
+    performer:
+      Measurements:
+        selector: //span
+        postProcess:
+          - ` + action + "\n"
+
+		c = &Definition{}
+		if err := yaml.Unmarshal([]byte(invalidStr), &c); err == nil {
+			t.Errorf("expected error unmarshalling %q", action)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package scraper
+
+import (
+	"strings"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/sliceutil"
+)
+
+// MergeScene fills the empty fields of dest with the values from src.
+// Fields that are already set in dest are not changed. URLs, tags, performers
+// and groups are merged with union semantics, preserving the order of dest.
+func MergeScene(dest *models.ScrapedScene, src *models.ScrapedScene) {
+	if dest == nil || src == nil {
+		return
+	}
+
+	fillString(&dest.Title, src.Title)
+	fillString(&dest.Code, src.Code)
+	fillString(&dest.Details, src.Details)
+	fillString(&dest.Director, src.Director)
+	fillString(&dest.URL, src.URL)
+	fillString(&dest.Date, src.Date)
+	fillString(&dest.Image, src.Image)
+	fillString(&dest.RemoteSiteID, src.RemoteSiteID)
+
+	if dest.Duration == nil {
+		dest.Duration = src.Duration
+	}
+	if dest.File == nil {
+		dest.File = src.File
+	}
+	if dest.Studio == nil {
+		dest.Studio = src.Studio
+	}
+	if len(dest.Studios) == 0 {
+		dest.Studios = src.Studios
+	}
+	if len(dest.Markers) == 0 {
+		dest.Markers = src.Markers
+	}
+	if len(dest.Fingerprints) == 0 {
+		dest.Fingerprints = src.Fingerprints
+	}
+
+	dest.URLs = sliceutil.AppendUniques(dest.URLs, src.URLs)
+	dest.Tags = mergeTags(dest.Tags, src.Tags)
+	dest.Performers = mergePerformers(dest.Performers, src.Performers)
+	dest.Groups = mergeByName(dest.Groups, src.Groups, func(g *models.ScrapedGroup) string {
+		return derefString(g.Name)
+	})
+	dest.Movies = mergeByName(dest.Movies, src.Movies, func(m *models.ScrapedMovie) string {
+		return derefString(m.Name)
+	})
+	dest.CustomFields = mergeCustomFields(dest.CustomFields, src.CustomFields)
+}
+
+// MergeGallery fills the empty fields of dest with the values from src.
+// Fields that are already set in dest are not changed. URLs, images, tags
+// and performers are merged with union semantics, preserving the order of dest.
+func MergeGallery(dest *models.ScrapedGallery, src *models.ScrapedGallery) {
+	if dest == nil || src == nil {
+		return
+	}
+
+	fillString(&dest.Title, src.Title)
+	fillString(&dest.Code, src.Code)
+	fillString(&dest.Details, src.Details)
+	fillString(&dest.Photographer, src.Photographer)
+	fillString(&dest.Date, src.Date)
+	fillString(&dest.URL, src.URL)
+
+	if dest.Studio == nil {
+		dest.Studio = src.Studio
+	}
+
+	dest.URLs = sliceutil.AppendUniques(dest.URLs, src.URLs)
+	dest.Images = sliceutil.AppendUniques(dest.Images, src.Images)
+	dest.Tags = mergeTags(dest.Tags, src.Tags)
+	dest.Performers = mergePerformers(dest.Performers, src.Performers)
+}
+
+// MergePerformer fills the empty fields of dest with the values from src.
+// Fields that are already set in dest are not changed. URLs, images and tags
+// are merged with union semantics, preserving the order of dest. Scenes are
+// taken from src only if dest has none.
+func MergePerformer(dest *models.ScrapedPerformer, src *models.ScrapedPerformer) {
+	if dest == nil || src == nil {
+		return
+	}
+
+	fillString(&dest.StoredID, src.StoredID)
+	fillString(&dest.Name, src.Name)
+	fillString(&dest.Disambiguation, src.Disambiguation)
+	fillString(&dest.Gender, src.Gender)
+	fillString(&dest.URL, src.URL)
+	fillString(&dest.Twitter, src.Twitter)
+	fillString(&dest.Instagram, src.Instagram)
+	fillString(&dest.Birthdate, src.Birthdate)
+	fillString(&dest.Ethnicity, src.Ethnicity)
+	fillString(&dest.Country, src.Country)
+	fillString(&dest.EyeColor, src.EyeColor)
+	fillString(&dest.Height, src.Height)
+	fillString(&dest.Measurements, src.Measurements)
+	fillString(&dest.FakeTits, src.FakeTits)
+	fillString(&dest.PenisLength, src.PenisLength)
+	fillString(&dest.Circumcised, src.Circumcised)
+	fillString(&dest.CareerLength, src.CareerLength)
+	fillString(&dest.Tattoos, src.Tattoos)
+	fillString(&dest.Piercings, src.Piercings)
+	fillString(&dest.Aliases, src.Aliases)
+	fillString(&dest.Image, src.Image)
+	fillString(&dest.Details, src.Details)
+	fillString(&dest.DeathDate, src.DeathDate)
+	fillString(&dest.HairColor, src.HairColor)
+	fillString(&dest.Weight, src.Weight)
+	fillString(&dest.RemoteSiteID, src.RemoteSiteID)
+	fillString(&dest.RemoteMergedIntoId, src.RemoteMergedIntoId)
+
+	if len(dest.Scenes) == 0 {
+		dest.Scenes = src.Scenes
+	}
+
+	dest.URLs = sliceutil.AppendUniques(dest.URLs, src.URLs)
+	dest.Images = sliceutil.AppendUniques(dest.Images, src.Images)
+	dest.Tags = mergeTags(dest.Tags, src.Tags)
+	dest.CustomFields = mergeCustomFields(dest.CustomFields, src.CustomFields)
+}
+
+// MergeImage fills the empty fields of dest with the values from src.
+// Fields that are already set in dest are not changed. URLs, tags and
+// performers are merged with union semantics, preserving the order of dest.
+func MergeImage(dest *models.ScrapedImage, src *models.ScrapedImage) {
+	if dest == nil || src == nil {
+		return
+	}
+
+	fillString(&dest.Title, src.Title)
+	fillString(&dest.Code, src.Code)
+	fillString(&dest.Details, src.Details)
+	fillString(&dest.Photographer, src.Photographer)
+	fillString(&dest.Date, src.Date)
+
+	if dest.Studio == nil {
+		dest.Studio = src.Studio
+	}
+
+	dest.URLs = sliceutil.AppendUniques(dest.URLs, src.URLs)
+	dest.Tags = mergeTags(dest.Tags, src.Tags)
+	dest.Performers = mergePerformers(dest.Performers, src.Performers)
+}
+
+// MergeGroup fills the empty fields of dest with the values from src.
+// Fields that are already set in dest are not changed. URLs and tags are
+// merged with union semantics, preserving the order of dest.
+func MergeGroup(dest *models.ScrapedGroup, src *models.ScrapedGroup) {
+	if dest == nil || src == nil {
+		return
+	}
+
+	fillString(&dest.StoredID, src.StoredID)
+	fillString(&dest.Name, src.Name)
+	fillString(&dest.Aliases, src.Aliases)
+	fillString(&dest.Duration, src.Duration)
+	fillString(&dest.Date, src.Date)
+	fillString(&dest.Rating, src.Rating)
+	fillString(&dest.Director, src.Director)
+	fillString(&dest.URL, src.URL)
+	fillString(&dest.Synopsis, src.Synopsis)
+	fillString(&dest.FrontImage, src.FrontImage)
+	fillString(&dest.BackImage, src.BackImage)
+
+	if dest.Studio == nil {
+		dest.Studio = src.Studio
+	}
+	if dest.SceneIndex == nil {
+		dest.SceneIndex = src.SceneIndex
+	}
+
+	dest.URLs = sliceutil.AppendUniques(dest.URLs, src.URLs)
+	dest.Tags = mergeTags(dest.Tags, src.Tags)
+}
+
+// fillString sets dest to src if dest is nil or empty.
+func fillString(dest **string, src *string) {
+	if derefString(*dest) == "" && derefString(src) != "" {
+		*dest = src
+	}
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return strings.TrimSpace(*s)
+}
+
+// mergeByName appends the elements of src to dest, excluding those with a
+// name that matches an element of dest, case-insensitively.
+func mergeByName[T any](dest []T, src []T, name func(T) string) []T {
+	for _, s := range src {
+		found := false
+		for _, d := range dest {
+			if strings.EqualFold(name(d), name(s)) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			dest = append(dest, s)
+		}
+	}
+
+	return dest
+}
+
+func mergeTags(dest []*models.ScrapedTag, src []*models.ScrapedTag) []*models.ScrapedTag {
+	return mergeByName(dest, src, func(t *models.ScrapedTag) string {
+		return t.Name
+	})
+}
+
+func mergePerformers(dest []*models.ScrapedPerformer, src []*models.ScrapedPerformer) []*models.ScrapedPerformer {
+	return mergeByName(dest, src, func(p *models.ScrapedPerformer) string {
+		return derefString(p.Name)
+	})
+}
+
+// mergeCustomFields adds the fields of src that are not set in dest.
+func mergeCustomFields(dest map[string]interface{}, src map[string]interface{}) map[string]interface{} {
+	for k, v := range src {
+		if existing, found := dest[k]; found && existing != "" {
+			continue
+		}
+
+		if dest == nil {
+			dest = make(map[string]interface{})
+		}
+		dest[k] = v
+	}
+
+	return dest
+}
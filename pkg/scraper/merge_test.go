@@ -0,0 +1,161 @@
+package scraper
+
+import (
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeScene(t *testing.T) {
+	duration := 120
+	dest := &models.ScrapedScene{
+		Title:   strPtr("Edited Title"),
+		Details: strPtr(""),
+		URLs:    []string{"https://a.example/1"},
+		Tags: []*models.ScrapedTag{
+			{Name: "Tag A"},
+		},
+		CustomFields: map[string]interface{}{
+			"kept": "edited",
+		},
+	}
+
+	studio := &models.ScrapedStudio{Name: "Studio"}
+	src := &models.ScrapedScene{
+		Title:    strPtr("Scraped Title"),
+		Details:  strPtr("Scraped Details"),
+		Date:     strPtr("2024-01-02"),
+		Duration: &duration,
+		Studio:   studio,
+		URLs:     []string{"https://b.example/1", "https://a.example/1"},
+		Tags: []*models.ScrapedTag{
+			{Name: "tag a"},
+			{Name: "Tag B"},
+		},
+		Performers: []*models.ScrapedPerformer{
+			{Name: strPtr("Performer")},
+		},
+		CustomFields: map[string]interface{}{
+			"kept":  "scraped",
+			"added": "scraped",
+		},
+	}
+
+	MergeScene(dest, src)
+
+	// scalar gaps are filled, existing values kept
+	assert.Equal(t, "Edited Title", *dest.Title)
+	assert.Equal(t, "Scraped Details", *dest.Details)
+	assert.Equal(t, "2024-01-02", *dest.Date)
+	assert.Equal(t, &duration, dest.Duration)
+	assert.Same(t, studio, dest.Studio)
+	assert.Nil(t, dest.Code)
+
+	// slices are unioned, preserving order
+	assert.Equal(t, []string{"https://a.example/1", "https://b.example/1"}, dest.URLs)
+	assert.Equal(t, []*models.ScrapedTag{{Name: "Tag A"}, {Name: "Tag B"}}, dest.Tags)
+	if assert.Len(t, dest.Performers, 1) {
+		assert.Equal(t, "Performer", *dest.Performers[0].Name)
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"kept":  "edited",
+		"added": "scraped",
+	}, dest.CustomFields)
+}
+
+func TestMergePerformer(t *testing.T) {
+	dest := &models.ScrapedPerformer{
+		Name:    strPtr("Name"),
+		Country: strPtr("  "),
+		URLs:    []string{"https://a.example"},
+		Images:  []string{"image1"},
+	}
+
+	src := &models.ScrapedPerformer{
+		Name:      strPtr("Other Name"),
+		Country:   strPtr("USA"),
+		Birthdate: strPtr("1990-01-01"),
+		URLs:      []string{"https://b.example"},
+		Images:    []string{"image1", "image2"},
+		Tags:      []*models.ScrapedTag{{Name: "Tag"}},
+	}
+
+	MergePerformer(dest, src)
+
+	assert.Equal(t, "Name", *dest.Name)
+	assert.Equal(t, "USA", *dest.Country)
+	assert.Equal(t, "1990-01-01", *dest.Birthdate)
+	assert.Equal(t, []string{"https://a.example", "https://b.example"}, dest.URLs)
+	assert.Equal(t, []string{"image1", "image2"}, dest.Images)
+	assert.Equal(t, []*models.ScrapedTag{{Name: "Tag"}}, dest.Tags)
+
+	// nil src is a no-op
+	MergePerformer(dest, nil)
+	assert.Equal(t, "Name", *dest.Name)
+}
+
+func TestMergeGallery(t *testing.T) {
+	dest := &models.ScrapedGallery{
+		Photographer: strPtr("Edited"),
+		Images:       []string{"https://a.example/1.jpg"},
+	}
+
+	src := &models.ScrapedGallery{
+		Title:        strPtr("Title"),
+		Photographer: strPtr("Scraped"),
+		URLs:         []string{"https://a.example"},
+		Images:       []string{"https://a.example/1.jpg", "https://a.example/2.jpg"},
+	}
+
+	MergeGallery(dest, src)
+
+	assert.Equal(t, "Title", *dest.Title)
+	assert.Equal(t, "Edited", *dest.Photographer)
+	assert.Equal(t, []string{"https://a.example"}, dest.URLs)
+	assert.Equal(t, []string{"https://a.example/1.jpg", "https://a.example/2.jpg"}, dest.Images)
+}
+
+func TestMergeImage(t *testing.T) {
+	dest := &models.ScrapedImage{
+		Title: strPtr("Edited"),
+		Tags:  []*models.ScrapedTag{{Name: "Tag A"}},
+	}
+
+	src := &models.ScrapedImage{
+		Title:   strPtr("Scraped"),
+		Details: strPtr("Details"),
+		Studio:  &models.ScrapedStudio{Name: "Studio"},
+		Tags:    []*models.ScrapedTag{{Name: "tag a"}, {Name: "Tag B"}},
+	}
+
+	MergeImage(dest, src)
+
+	assert.Equal(t, "Edited", *dest.Title)
+	assert.Equal(t, "Details", *dest.Details)
+	assert.Equal(t, "Studio", dest.Studio.Name)
+	assert.Len(t, dest.Tags, 2)
+}
+
+func TestMergeGroup(t *testing.T) {
+	dest := &models.ScrapedGroup{
+		Name:     strPtr("Edited"),
+		Synopsis: strPtr(" "),
+		URLs:     []string{"https://a.example"},
+	}
+
+	src := &models.ScrapedGroup{
+		Name:       strPtr("Scraped"),
+		Synopsis:   strPtr("Synopsis"),
+		FrontImage: strPtr("front"),
+		URLs:       []string{"https://b.example", "https://a.example"},
+	}
+
+	MergeGroup(dest, src)
+
+	assert.Equal(t, "Edited", *dest.Name)
+	assert.Equal(t, "Synopsis", *dest.Synopsis)
+	assert.Equal(t, "front", *dest.FrontImage)
+	assert.Equal(t, []string{"https://a.example", "https://b.example"}, dest.URLs)
+}
@@ -2,6 +2,7 @@ package scraper
 
 import (
 	"context"
+	"net/http"
 	"regexp"
 
 	"github.com/stashapp/stash/pkg/logger"
@@ -15,6 +16,10 @@ type postScraper struct {
 	Cache
 	excludeTagRE []*regexp.Regexp
 
+	// client is the http client of the scraper that returned the content.
+	// It is used to download images, and shadows the client of the Cache.
+	client *http.Client
+
 	// ignoredTags is a list of tags that were ignored during post-processing
 	ignoredTags []string
 }
@@ -339,7 +344,8 @@ func (c *postScraper) postScrapeRelatedStudio(ctx context.Context, s *models.Scr
 		return err
 	}
 
-	return nil
+	// match the parent studios, so that the hierarchy can be created
+	return c.postScrapeRelatedStudio(ctx, s.Parent)
 }
 
 func (c *postScraper) postScrapeScene(ctx context.Context, scene models.ScrapedScene) (_ ScrapedContent, err error) {
@@ -386,10 +392,31 @@ func (c *postScraper) postScrapeScene(ctx context.Context, scene models.ScrapedS
 	}
 	scene.Tags = c.filterTags(tags)
 
+	for _, m := range scene.Markers {
+		if m.Tag == nil {
+			continue
+		}
+
+		if err := match.ScrapedTag(ctx, tqb, m.Tag, ""); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := c.postScrapeRelatedStudio(ctx, scene.Studio); err != nil {
 		return nil, err
 	}
 
+	for _, s := range scene.Studios {
+		// Studio is usually the first of Studios, and has already been processed
+		if s == scene.Studio {
+			continue
+		}
+
+		if err := c.postScrapeRelatedStudio(ctx, s); err != nil {
+			return nil, err
+		}
+	}
+
 	// post-process - set the image if applicable
 	if err := processImageField(ctx, scene.Image, c.client, c.globalConfig); err != nil {
 		logger.Warnf("Could not set image using URL %s: %v", *scene.Image, err)
@@ -451,10 +478,11 @@ func (c *postScraper) postScrapeImage(ctx context.Context, image models.ScrapedI
 
 // postScrapeSingle handles post-processing of a single scraped content item.
 // This is a convenience function that includes logging the ignored tags, as opposed to logging them in the caller.
-func (c Cache) postScrapeSingle(ctx context.Context, content ScrapedContent) (ret ScrapedContent, err error) {
+func (c Cache) postScrapeSingle(ctx context.Context, s scraper, content ScrapedContent) (ret ScrapedContent, err error) {
 	pp := postScraper{
 		Cache:        c,
 		excludeTagRE: c.compileExcludeTagPatterns(),
+		client:       c.scraperClient(s),
 	}
 
 	if err := c.repository.WithReadTxn(ctx, func(ctx context.Context) error {
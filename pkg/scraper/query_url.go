@@ -1,9 +1,11 @@
 package scraper
 
 import (
+	"net/url"
 	"path/filepath"
-	"strings"
+	"regexp"
 
+	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
 )
 
@@ -20,6 +22,15 @@ func queryURLParametersFromScene(scene *models.Scene) queryURLParameters {
 	if scene.Title != "" {
 		ret["title"] = scene.Title
 	}
+	if scene.Code != "" {
+		ret["code"] = scene.Code
+	}
+	if scene.Director != "" {
+		ret["director"] = scene.Director
+	}
+	if scene.Date != nil {
+		ret["date"] = scene.Date.String()
+	}
 	if len(scene.URLs.List()) > 0 {
 		ret["url"] = scene.URLs.List()[0]
 	}
@@ -65,6 +76,15 @@ func queryURLParametersFromGallery(gallery *models.Gallery) queryURLParameters {
 	if gallery.Title != "" {
 		ret["title"] = gallery.Title
 	}
+	if gallery.Code != "" {
+		ret["code"] = gallery.Code
+	}
+	if gallery.Photographer != "" {
+		ret["photographer"] = gallery.Photographer
+	}
+	if gallery.Date != nil {
+		ret["date"] = gallery.Date.String()
+	}
 
 	if len(gallery.URLs.List()) > 0 {
 		ret["url"] = gallery.URLs.List()[0]
@@ -83,6 +103,15 @@ func queryURLParametersFromImage(image *models.Image) queryURLParameters {
 	if image.Title != "" {
 		ret["title"] = image.Title
 	}
+	if image.Code != "" {
+		ret["code"] = image.Code
+	}
+	if image.Photographer != "" {
+		ret["photographer"] = image.Photographer
+	}
+	if image.Date != nil {
+		ret["date"] = image.Date.String()
+	}
 
 	if len(image.URLs.List()) > 0 {
 		ret["url"] = image.URLs.List()[0]
@@ -100,13 +129,41 @@ func (p queryURLParameters) applyReplacements(r queryURLReplacements) {
 	}
 }
 
-func (p queryURLParameters) constructURL(url string) string {
-	ret := url
-	for k, v := range p {
-		ret = strings.ReplaceAll(ret, "{"+k+"}", v)
-	}
+// queryURLPlaceholderRE matches placeholders such as {title}, and placeholders
+// with an escape function such as {title|query}.
+var queryURLPlaceholderRE = regexp.MustCompile(`\{([a-z_]+)(?:\|([a-z]+))?\}`)
 
-	return ret
+// queryURLEscapers are the escape functions that may be applied to placeholder values.
+var queryURLEscapers = map[string]func(string) string{
+	"path":  url.PathEscape,
+	"query": url.QueryEscape,
+}
+
+// constructURL replaces the placeholders in u with the parameter values.
+// A placeholder may name an escape function after a | character, such as
+// {title|query}, in which case the value is escaped for that part of the URL.
+// Values are otherwise inserted unchanged. Placeholders for parameters that are
+// not set, or with an unknown escape function, are left unchanged.
+func (p queryURLParameters) constructURL(u string) string {
+	return queryURLPlaceholderRE.ReplaceAllStringFunc(u, func(placeholder string) string {
+		m := queryURLPlaceholderRE.FindStringSubmatch(placeholder)
+		v, found := p[m[1]]
+		if !found {
+			return placeholder
+		}
+
+		if m[2] == "" {
+			return v
+		}
+
+		escape, found := queryURLEscapers[m[2]]
+		if !found {
+			logger.Warnf("Unknown escape function %q in query URL placeholder %s", m[2], placeholder)
+			return placeholder
+		}
+
+		return escape(v)
+	})
 }
 
 // replaceURL does a partial URL Replace ( only url parameter is used)
@@ -0,0 +1,79 @@
+package scraper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func Test_queryURLParameters_constructURL(t *testing.T) {
+	date := models.Date{Time: time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)}
+	scene := &models.Scene{
+		Title: "Hello & Goodbye/Part 1",
+		Date:  &date,
+		Path:  "/videos/scene.mp4",
+		URLs:  models.NewRelatedStrings([]string{}),
+	}
+
+	tests := []struct {
+		name         string
+		queryURL     string
+		replacements queryURLReplacements
+		want         string
+	}{
+		{
+			"unescaped",
+			"https://example.com/search?q={title}&date={date}",
+			nil,
+			"https://example.com/search?q=Hello & Goodbye/Part 1&date=2021-03-04",
+		},
+		{
+			"query escaped",
+			"https://example.com/search?q={title|query}&date={date|query}",
+			nil,
+			"https://example.com/search?q=Hello+%26+Goodbye%2FPart+1&date=2021-03-04",
+		},
+		{
+			"path escaped",
+			"https://example.com/{date}/{title|path}",
+			nil,
+			"https://example.com/2021-03-04/Hello%20&%20Goodbye%2FPart%201",
+		},
+		{
+			"replacements applied before escaping",
+			"https://example.com/{date|path}/{filename|path}",
+			queryURLReplacements{
+				"date": mappedRegexConfigs{
+					{Regex: "-", With: "/"},
+				},
+			},
+			"https://example.com/2021%2F03%2F04/scene.mp4",
+		},
+		{
+			"unset field unchanged",
+			"https://example.com/{code}?q={code|query}",
+			nil,
+			"https://example.com/{code}?q={code|query}",
+		},
+		{
+			"unknown escape unchanged",
+			"https://example.com/{title|html}",
+			nil,
+			"https://example.com/{title|html}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := queryURLParametersFromScene(scene)
+			if tt.replacements != nil {
+				p.applyReplacements(tt.replacements)
+			}
+
+			if got := p.constructURL(tt.queryURL); got != tt.want {
+				t.Errorf("queryURLParameters.constructURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
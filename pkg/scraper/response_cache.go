@@ -0,0 +1,138 @@
+package scraper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// maxCachedResponses is the maximum number of responses kept by the
+// response cache. The oldest response is evicted when the limit is reached.
+const maxCachedResponses = 64
+
+// cachedResponse is a response body along with the validators used to
+// make conditional requests for it.
+type cachedResponse struct {
+	etag         string
+	lastModified string
+	contentType  string
+	body         []byte
+}
+
+// setConditionalHeaders sets the headers on req to request the resource only
+// if it has changed since it was cached.
+func (r *cachedResponse) setConditionalHeaders(req *http.Request) {
+	if r.etag != "" {
+		req.Header.Set("If-None-Match", r.etag)
+	}
+	if r.lastModified != "" {
+		req.Header.Set("If-Modified-Since", r.lastModified)
+	}
+}
+
+// responseCache stores the responses of scraped urls that have an ETag or
+// Last-Modified header, so that they can be revalidated with a conditional
+// request instead of being downloaded again. Each Cache has its own response
+// cache, which is used by the clients created from its transport pool.
+// Scrapers with DisableResponseCache set do not use the cache.
+type responseCache struct {
+	limit int
+
+	mutex     sync.Mutex
+	responses map[string]*cachedResponse
+	keys      []string
+}
+
+func newResponseCache() *responseCache {
+	return &responseCache{
+		limit: maxCachedResponses,
+	}
+}
+
+// responseCacheKey returns the key of the response to req. Responses may
+// differ by the request headers, such as the cookies, user agent and
+// configured authorization headers, so a hash of the headers is included in
+// the key. Responses to requests with different credentials are not shared.
+func responseCacheKey(req *http.Request) string {
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		for _, v := range req.Header[name] {
+			fmt.Fprintf(h, "%s: %s\n", name, v)
+		}
+	}
+
+	return req.URL.String() + "\n" + hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *responseCache) get(key string) *cachedResponse {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.responses[key]
+}
+
+// set stores the body of resp if it has validators, otherwise it removes
+// any existing response for the key.
+func (c *responseCache) set(key string, resp *http.Response, body []byte) {
+	etag := resp.Header.Get("ETag")
+	lastModified := resp.Header.Get("Last-Modified")
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if etag == "" && lastModified == "" {
+		c.remove(key)
+		return
+	}
+
+	if c.responses == nil {
+		c.responses = make(map[string]*cachedResponse)
+	}
+
+	if _, found := c.responses[key]; !found {
+		if len(c.keys) >= c.limit {
+			c.remove(c.keys[0])
+		}
+		c.keys = append(c.keys, key)
+	}
+
+	c.responses[key] = &cachedResponse{
+		etag:         etag,
+		lastModified: lastModified,
+		contentType:  resp.Header.Get("Content-Type"),
+		body:         body,
+	}
+}
+
+// clear removes all cached responses.
+func (c *responseCache) clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.responses = nil
+	c.keys = nil
+}
+
+// remove removes the response for key. The mutex must be held.
+func (c *responseCache) remove(key string) {
+	if _, found := c.responses[key]; !found {
+		return
+	}
+
+	delete(c.responses, key)
+	for i, k := range c.keys {
+		if k == key {
+			c.keys = append(c.keys[:i], c.keys[i+1:]...)
+			break
+		}
+	}
+}
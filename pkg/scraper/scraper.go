@@ -156,6 +156,20 @@ var (
 	// ErrNotSupported is returned when a given invocation isn't supported, and there
 	// is a guard function which should be able to guard against it.
 	ErrNotSupported = errors.New("scraper operation not supported")
+
+	// ErrAmbiguousURL is returned when a url matches scrapers of more than one
+	// content type equally well, so the content type cannot be determined.
+	ErrAmbiguousURL = errors.New("url matches more than one content type")
+
+	// ErrInvalidJSON is returned when a json scraper loads a document that is not valid json.
+	ErrInvalidJSON = errors.New("document is not valid json")
+
+	// ErrRequiredNotFound is returned when a required selector of a scraper
+	// returns no results.
+	ErrRequiredNotFound = errors.New("required selector returned no results")
+
+	// ErrNotImage is returned when downloaded image data is not an image.
+	ErrNotImage = errors.New("data is not an image")
 )
 
 // Input coalesces inputs of different types into a single structure.
@@ -208,6 +222,24 @@ type urlScraper interface {
 	viaURL(ctx context.Context, client *http.Client, url string, ty ScrapeContentType) (ScrapedContent, error)
 }
 
+// multiURLScraper is the interface of scrapers supporting url loads returning
+// multiple results
+type multiURLScraper interface {
+	scraper
+
+	viaURLMulti(ctx context.Context, client *http.Client, url string, ty ScrapeContentType) ([]ScrapedContent, error)
+}
+
+// urlMatcher is the interface of scrapers that can report how specifically
+// they match a url
+type urlMatcher interface {
+	scraper
+
+	// urlMatchLength returns the length of the longest url pattern matching url
+	// for the given content type, or 0 if none match.
+	urlMatchLength(url string, ty ScrapeContentType) int
+}
+
 // nameScraper is the interface of scrapers supporting name loads
 type nameScraper interface {
 	scraper
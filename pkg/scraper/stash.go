@@ -7,10 +7,12 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/corona10/goimagehash"
 	graphql "github.com/hasura/go-graphql-client"
 	"github.com/jinzhu/copier"
 
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
 )
 
 type stashScraper struct {
@@ -299,6 +301,11 @@ func (s *stashScraper) scrapeByName(ctx context.Context, name string, ty ScrapeC
 	return nil, ErrNotSupported
 }
 
+type stashFingerprint struct {
+	Type  string `graphql:"type" json:"type"`
+	Value string `graphql:"value" json:"value"`
+}
+
 type stashVideoFile struct {
 	Size       int64   `graphql:"size" json:"size"`
 	Duration   float64 `graphql:"duration" json:"duration"`
@@ -308,6 +315,8 @@ type stashVideoFile struct {
 	Height     int     `graphql:"height" json:"height"`
 	Framerate  float64 `graphql:"frame_rate" json:"frame_rate"`
 	Bitrate    int     `graphql:"bit_rate" json:"bit_rate"`
+
+	Fingerprints []stashFingerprint `graphql:"fingerprints" json:"fingerprints"`
 }
 
 func (f stashVideoFile) SceneFileType() models.SceneFileType {
@@ -339,7 +348,71 @@ type scrapedSceneStash struct {
 	Performers []*scrapedPerformerStash `graphql:"performers" json:"performers"`
 }
 
+// phashDistance returns the smallest distance between phash and the phashes of
+// the files of the scene. Returns false if none of the files have a phash.
+func (s scrapedSceneStash) phashDistance(phash int64) (int, bool) {
+	hash := goimagehash.NewImageHash(uint64(phash), goimagehash.PHash)
+
+	ret := -1
+	for _, f := range s.Files {
+		for _, fp := range f.Fingerprints {
+			if fp.Type != models.FingerprintTypePhash {
+				continue
+			}
+
+			v, err := utils.StringToPhash(fp.Value)
+			if err != nil {
+				continue
+			}
+
+			distance, err := hash.Distance(goimagehash.NewImageHash(uint64(v), goimagehash.PHash))
+			if err == nil && (ret == -1 || distance < ret) {
+				ret = distance
+			}
+		}
+	}
+
+	return ret, ret != -1
+}
+
 func (s *stashScraper) scrapeSceneByScene(ctx context.Context, scene *models.Scene) (*models.ScrapedScene, error) {
+	found, err := s.findSceneByHash(ctx, scene)
+	if err != nil {
+		return nil, err
+	}
+
+	// checksum and oshash matches are exact, so phashes are only compared
+	// if there is no such match
+	if found == nil && s.config.StashServer.MaxPhashDistance != nil {
+		found, err = s.findSceneByPhash(ctx, scene, *s.config.StashServer.MaxPhashDistance)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if found == nil {
+		return nil, nil
+	}
+
+	// need to copy back to a scraped scene
+	ret, err := s.scrapedStashSceneToScrapedScene(ctx, found)
+	if err != nil {
+		return nil, err
+	}
+
+	// get the scene image directly
+	ig := s.imageGetter()
+	ret.Image, err = getStashSceneImage(ctx, s.config.StashServer.URL, found.ID, ig)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// findSceneByHash finds the scene on the stash server with the same checksum
+// or oshash as scene.
+func (s *stashScraper) findSceneByHash(ctx context.Context, scene *models.Scene) (*scrapedSceneStash, error) {
 	// query by MD5
 	var q struct {
 		FindScene *scrapedSceneStash `graphql:"findSceneByHash(input: $c)"`
@@ -367,72 +440,166 @@ func (s *stashScraper) scrapeSceneByScene(ctx context.Context, scene *models.Sce
 		return nil, convertGraphqlError(err)
 	}
 
-	if q.FindScene == nil {
+	return q.FindScene, nil
+}
+
+// findSceneByPhash finds the scene on the stash server with the phash closest
+// to the phash of the primary file of scene, if the distance between them is
+// no more than maxDistance.
+func (s *stashScraper) findSceneByPhash(ctx context.Context, scene *models.Scene, maxDistance int) (*scrapedSceneStash, error) {
+	if !scene.Files.PrimaryLoaded() || scene.Files.Primary() == nil {
 		return nil, nil
 	}
 
-	// need to copy back to a scraped scene
-	ret, err := s.scrapedStashSceneToScrapedScene(ctx, q.FindScene)
-	if err != nil {
-		return nil, err
+	fp := scene.Files.Primary().Fingerprints.For(models.FingerprintTypePhash)
+	if fp == nil {
+		return nil, nil
 	}
 
-	// get the scene image directly
-	ig := s.imageGetter()
-	ret.Image, err = getStashSceneImage(ctx, s.config.StashServer.URL, q.FindScene.ID, ig)
-	if err != nil {
-		return nil, err
+	phash := fp.Int64()
+
+	var q struct {
+		FindScenes stashFindSceneNamesResultType `graphql:"findScenes(scene_filter: $f)"`
+	}
+
+	type SceneFilterType struct {
+		PhashDistance *models.PhashDistanceCriterionInput `graphql:"phash_distance" json:"phash_distance"`
+	}
+
+	vars := map[string]interface{}{
+		"f": SceneFilterType{
+			PhashDistance: &models.PhashDistanceCriterionInput{
+				Value:    utils.PhashToString(phash),
+				Modifier: models.CriterionModifierEquals,
+				Distance: &maxDistance,
+			},
+		},
+	}
+
+	client := s.getStashClient()
+	if err := client.Query(ctx, &q, vars); err != nil {
+		return nil, convertGraphqlError(err)
+	}
+
+	// the distance is checked again, since older servers do not filter by it
+	var ret *scrapedSceneStash
+	best := maxDistance + 1
+	for _, found := range q.FindScenes.Scenes {
+		if distance, ok := found.phashDistance(phash); ok && distance < best {
+			ret = found
+			best = distance
+		}
 	}
 
 	return ret, nil
 }
 
 type scrapedGalleryStash struct {
-	ID         string                   `graphql:"id" json:"id"`
-	Title      *string                  `graphql:"title" json:"title"`
-	Details    *string                  `graphql:"details" json:"details"`
-	URL        *string                  `graphql:"url" json:"url"`
-	Date       *string                  `graphql:"date" json:"date"`
-	File       *models.SceneFileType    `graphql:"file" json:"file"`
-	Studio     *scrapedStudioStash      `graphql:"studio" json:"studio"`
-	Tags       []*scrapedTagStash       `graphql:"tags" json:"tags"`
-	Performers []*scrapedPerformerStash `graphql:"performers" json:"performers"`
+	ID           string                   `graphql:"id" json:"id"`
+	Title        *string                  `graphql:"title" json:"title"`
+	Code         *string                  `graphql:"code" json:"code"`
+	Details      *string                  `graphql:"details" json:"details"`
+	Photographer *string                  `graphql:"photographer" json:"photographer"`
+	URLs         []string                 `graphql:"urls" json:"urls"`
+	Date         *string                  `graphql:"date" json:"date"`
+	Studio       *scrapedStudioStash      `graphql:"studio" json:"studio"`
+	Tags         []*scrapedTagStash       `graphql:"tags" json:"tags"`
+	Performers   []*scrapedPerformerStash `graphql:"performers" json:"performers"`
 }
 
+type stashFindGalleriesResultType struct {
+	Count     int                    `graphql:"count"`
+	Galleries []*scrapedGalleryStash `graphql:"galleries"`
+}
+
+// scrapeGalleryByGallery finds the gallery on the stash server with the same
+// checksum as the primary file of the gallery.
 func (s *stashScraper) scrapeGalleryByGallery(ctx context.Context, gallery *models.Gallery) (*models.ScrapedGallery, error) {
-	var q struct {
-		FindGallery *scrapedGalleryStash `graphql:"findGalleryByHash(input: $c)"`
+	checksum := gallery.PrimaryChecksum()
+	if checksum == "" {
+		// folder-based galleries have no checksum to match against
+		return nil, nil
 	}
 
-	type GalleryHashInput struct {
-		Checksum *string `graphql:"checksum" json:"checksum"`
+	var q struct {
+		FindGalleries stashFindGalleriesResultType `graphql:"findGalleries(gallery_filter: $f)"`
 	}
 
-	checksum := gallery.PrimaryChecksum()
-	input := GalleryHashInput{
-		Checksum: &checksum,
+	type GalleryFilterType struct {
+		Checksum *models.StringCriterionInput `graphql:"checksum" json:"checksum"`
 	}
 
 	vars := map[string]interface{}{
-		"c": &input,
+		"f": GalleryFilterType{
+			Checksum: &models.StringCriterionInput{
+				Value:    checksum,
+				Modifier: models.CriterionModifierEquals,
+			},
+		},
 	}
 
 	client := s.getStashClient()
 	if err := client.Query(ctx, &q, vars); err != nil {
-		return nil, err
+		return nil, convertGraphqlError(err)
 	}
 
-	// need to copy back to a scraped scene
+	if len(q.FindGalleries.Galleries) == 0 {
+		return nil, nil
+	}
+
+	// need to copy back to a scraped gallery
 	ret := models.ScrapedGallery{}
-	if err := copier.Copy(&ret, q.FindGallery); err != nil {
+	if err := copier.Copy(&ret, q.FindGalleries.Galleries[0]); err != nil {
 		return nil, err
 	}
 
 	return &ret, nil
 }
 
+type scrapedImageStash struct {
+	ID           string                   `graphql:"id" json:"id"`
+	Title        *string                  `graphql:"title" json:"title"`
+	Code         *string                  `graphql:"code" json:"code"`
+	Details      *string                  `graphql:"details" json:"details"`
+	Photographer *string                  `graphql:"photographer" json:"photographer"`
+	URLs         []string                 `graphql:"urls" json:"urls"`
+	Date         *string                  `graphql:"date" json:"date"`
+	Studio       *scrapedStudioStash      `graphql:"studio" json:"studio"`
+	Tags         []*scrapedTagStash       `graphql:"tags" json:"tags"`
+	Performers   []*scrapedPerformerStash `graphql:"performers" json:"performers"`
+}
+
+// scrapeImageByImage finds the image on the stash server with the same
+// checksum as the primary file of the image.
 func (s *stashScraper) scrapeImageByImage(ctx context.Context, image *models.Image) (*models.ScrapedImage, error) {
-	return nil, ErrNotSupported
+	if image.Checksum == "" {
+		return nil, nil
+	}
+
+	var q struct {
+		FindImage *scrapedImageStash `graphql:"findImage(checksum: $c)"`
+	}
+
+	vars := map[string]interface{}{
+		"c": image.Checksum,
+	}
+
+	client := s.getStashClient()
+	if err := client.Query(ctx, &q, vars); err != nil {
+		return nil, convertGraphqlError(err)
+	}
+
+	if q.FindImage == nil {
+		return nil, nil
+	}
+
+	// need to copy back to a scraped image
+	ret := models.ScrapedImage{}
+	if err := copier.Copy(&ret, q.FindImage); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
 }
 
 func (s *stashScraper) scrapeByURL(_ context.Context, _ string, _ ScrapeContentType) (ScrapedContent, error) {
@@ -0,0 +1,298 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+type graphqlRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// newStashTestServer returns a server that records the graphql request and
+// responds with the provided data.
+func newStashTestServer(t *testing.T, data string, got *graphqlRequest) *httptest.Server {
+	t.Helper()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/graphql" {
+			http.NotFound(w, r)
+			return
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":` + data + `}`))
+	}))
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+func newTestStashScraper(url string) *stashScraper {
+	return newStashScraper(&http.Client{}, Definition{
+		StashServer: &stashServer{URL: url},
+	}, mockGlobalConfig{})
+}
+
+func TestStashScraper_scrapeGalleryByGallery(t *testing.T) {
+	const data = `{"findGalleries":{"count":1,"galleries":[{
+		"id":"1",
+		"title":"Gallery Title",
+		"code":"G-1",
+		"photographer":"Photographer",
+		"urls":["https://example.com/gallery/1"],
+		"date":"2020-01-02",
+		"studio":{"name":"Studio"},
+		"tags":[{"name":"Tag"}],
+		"performers":[{"name":"Performer"}]
+	}]}}`
+
+	var got graphqlRequest
+	ts := newStashTestServer(t, data, &got)
+
+	gallery := &models.Gallery{
+		Files: models.NewRelatedFiles([]models.File{
+			&models.BaseFile{
+				Fingerprints: models.Fingerprints{
+					{Type: models.FingerprintTypeMD5, Fingerprint: "checksum"},
+				},
+			},
+		}),
+	}
+
+	ret, err := newTestStashScraper(ts.URL).scrapeGalleryByGallery(context.Background(), gallery)
+	if !assert.NoError(t, err) || !assert.NotNil(t, ret) {
+		return
+	}
+
+	assert.Contains(t, got.Query, "findGalleries(gallery_filter: $f)")
+	assert.Equal(t, map[string]interface{}{
+		"checksum": map[string]interface{}{
+			"value":    "checksum",
+			"modifier": "EQUALS",
+		},
+	}, got.Variables["f"])
+
+	assert.Equal(t, "Gallery Title", *ret.Title)
+	assert.Equal(t, "G-1", *ret.Code)
+	assert.Equal(t, "Photographer", *ret.Photographer)
+	assert.Equal(t, []string{"https://example.com/gallery/1"}, ret.URLs)
+	assert.Equal(t, "2020-01-02", *ret.Date)
+	assert.Equal(t, "Studio", ret.Studio.Name)
+	if assert.Len(t, ret.Tags, 1) {
+		assert.Equal(t, "Tag", ret.Tags[0].Name)
+	}
+	if assert.Len(t, ret.Performers, 1) {
+		assert.Equal(t, "Performer", *ret.Performers[0].Name)
+	}
+}
+
+func TestStashScraper_scrapeGalleryByGalleryNotFound(t *testing.T) {
+	var got graphqlRequest
+	ts := newStashTestServer(t, `{"findGalleries":{"count":0,"galleries":[]}}`, &got)
+
+	gallery := &models.Gallery{
+		Files: models.NewRelatedFiles([]models.File{
+			&models.BaseFile{
+				Fingerprints: models.Fingerprints{
+					{Type: models.FingerprintTypeMD5, Fingerprint: "checksum"},
+				},
+			},
+		}),
+	}
+
+	ret, err := newTestStashScraper(ts.URL).scrapeGalleryByGallery(context.Background(), gallery)
+	assert.NoError(t, err)
+	assert.Nil(t, ret)
+
+	// folder-based galleries are not queried
+	got = graphqlRequest{}
+	ret, err = newTestStashScraper(ts.URL).scrapeGalleryByGallery(context.Background(), &models.Gallery{
+		Files: models.NewRelatedFiles(nil),
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, ret)
+	assert.Empty(t, got.Query)
+}
+
+func TestStashScraper_scrapeImageByImage(t *testing.T) {
+	const data = `{"findImage":{
+		"id":"1",
+		"title":"Image Title",
+		"details":"Details",
+		"urls":["https://example.com/image/1"],
+		"studio":{"name":"Studio"},
+		"tags":[{"name":"Tag"}],
+		"performers":[]
+	}}`
+
+	var got graphqlRequest
+	ts := newStashTestServer(t, data, &got)
+
+	ret, err := newTestStashScraper(ts.URL).scrapeImageByImage(context.Background(), &models.Image{
+		Checksum: "checksum",
+	})
+	if !assert.NoError(t, err) || !assert.NotNil(t, ret) {
+		return
+	}
+
+	assert.Contains(t, got.Query, "findImage(checksum: $c)")
+	assert.Equal(t, "checksum", got.Variables["c"])
+
+	assert.Equal(t, "Image Title", *ret.Title)
+	assert.Equal(t, "Details", *ret.Details)
+	assert.Equal(t, []string{"https://example.com/image/1"}, ret.URLs)
+	assert.Equal(t, "Studio", ret.Studio.Name)
+	if assert.Len(t, ret.Tags, 1) {
+		assert.Equal(t, "Tag", ret.Tags[0].Name)
+	}
+
+	// not found
+	ts = newStashTestServer(t, `{"findImage":null}`, &got)
+	ret, err = newTestStashScraper(ts.URL).scrapeImageByImage(context.Background(), &models.Image{
+		Checksum: "checksum",
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, ret)
+}
+
+// newStashSceneTestServer returns a server that responds to graphql requests
+// with the data of the first entry in responses whose key is contained in the
+// query, and to scene screenshot requests with an empty image. The queries
+// are appended to got.
+func newStashSceneTestServer(t *testing.T, responses map[string]string, got *[]graphqlRequest) *httptest.Server {
+	t.Helper()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/screenshot") {
+			w.Header().Set("Content-Type", "image/jpeg")
+			return
+		}
+
+		var req graphqlRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		*got = append(*got, req)
+
+		for k, data := range responses {
+			if strings.Contains(req.Query, k) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"data":` + data + `}`))
+				return
+			}
+		}
+
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+func TestStashScraper_scrapeSceneBySceneMaxPhashDistance(t *testing.T) {
+	const (
+		hashData = `{"findSceneByHash":null}`
+		// scene 1 is at distance 6, scene 2 at distance 1, and scene 3 has no phash
+		phashData = `{"findScenes":{"count":3,"scenes":[
+			{"id":"1","title":"Far","files":[{"fingerprints":[{"type":"phash","value":"f0f0f0f0f0f0f03f"}]}]},
+			{"id":"2","title":"Near","files":[{"fingerprints":[{"type":"oshash","value":"abc"},{"type":"phash","value":"f0f0f0f0f0f0f0f1"}]}]},
+			{"id":"3","title":"None","files":[{"fingerprints":[{"type":"md5","value":"abc"}]}]}
+		]}}`
+	)
+
+	phash, err := utils.StringToPhash("f0f0f0f0f0f0f0f0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scene := &models.Scene{
+		Checksum: "checksum",
+		OSHash:   "oshash",
+		Files: models.NewRelatedVideoFiles([]*models.VideoFile{
+			{
+				BaseFile: &models.BaseFile{
+					Fingerprints: models.Fingerprints{
+						{Type: models.FingerprintTypePhash, Fingerprint: phash},
+					},
+				},
+			},
+		}),
+	}
+
+	scrape := func(maxDistance *int, responses map[string]string) (*models.ScrapedScene, []graphqlRequest) {
+		t.Helper()
+
+		var got []graphqlRequest
+		ts := newStashSceneTestServer(t, responses, &got)
+
+		s := newTestStashScraper(ts.URL)
+		s.config.StashServer.MaxPhashDistance = maxDistance
+
+		ret, err := s.scrapeSceneByScene(context.Background(), scene)
+		assert.NoError(t, err)
+		return ret, got
+	}
+
+	responses := map[string]string{
+		"findSceneByHash": hashData,
+		"findScenes":      phashData,
+	}
+
+	intPtr := func(i int) *int { return &i }
+
+	t.Run("closest within distance", func(t *testing.T) {
+		ret, got := scrape(intPtr(8), responses)
+		if assert.NotNil(t, ret) {
+			assert.Equal(t, "Near", *ret.Title)
+		}
+
+		if assert.Len(t, got, 2) {
+			assert.Contains(t, got[1].Query, "findScenes(scene_filter: $f)")
+			assert.Equal(t, map[string]interface{}{
+				"phash_distance": map[string]interface{}{
+					"value":    "f0f0f0f0f0f0f0f0",
+					"modifier": "EQUALS",
+					"distance": float64(8),
+				},
+			}, got[1].Variables["f"])
+		}
+	})
+
+	t.Run("weak matches filtered", func(t *testing.T) {
+		ret, _ := scrape(intPtr(0), responses)
+		assert.Nil(t, ret)
+	})
+
+	t.Run("not set", func(t *testing.T) {
+		ret, got := scrape(nil, responses)
+		assert.Nil(t, ret)
+		assert.Len(t, got, 1)
+	})
+
+	t.Run("exact match", func(t *testing.T) {
+		ret, got := scrape(intPtr(8), map[string]string{
+			"findSceneByHash": `{"findSceneByHash":{"id":"4","title":"Exact"}}`,
+			"findScenes":      phashData,
+		})
+		if assert.NotNil(t, ret) {
+			assert.Equal(t, "Exact", *ret.Title)
+		}
+		assert.Len(t, got, 1)
+	})
+}
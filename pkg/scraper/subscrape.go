@@ -0,0 +1,130 @@
+package scraper
+
+import (
+	"context"
+	"sync"
+
+	"github.com/stashapp/stash/pkg/sliceutil"
+)
+
+const (
+	// maxConcurrentSubScrapes is the maximum number of values of a single
+	// attribute that are sub-scraped concurrently.
+	maxConcurrentSubScrapes = 8
+
+	// maxSubScrapesPerHost is the maximum number of concurrent sub-scrape
+	// requests to a single host, across all scrapes.
+	maxSubScrapesPerHost = 4
+)
+
+// hostLimiter limits the number of concurrent operations per host. Each Cache
+// has its own limiter, which is used by the clients created from its
+// transport pool.
+type hostLimiter struct {
+	limit int
+
+	mutex sync.Mutex
+	hosts map[string]*hostSemaphore
+}
+
+// hostSemaphore limits the operations against a single host. It is removed
+// from the limiter once it has no users.
+type hostSemaphore struct {
+	sem   chan struct{}
+	users int
+}
+
+func newHostLimiter(limit int) *hostLimiter {
+	return &hostLimiter{
+		limit: limit,
+		hosts: make(map[string]*hostSemaphore),
+	}
+}
+
+// semaphore returns the semaphore for host, registering the caller as a user.
+// The caller must call done once it no longer uses the semaphore.
+func (l *hostLimiter) semaphore(host string) *hostSemaphore {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	s, found := l.hosts[host]
+	if !found {
+		s = &hostSemaphore{
+			sem: make(chan struct{}, l.limit),
+		}
+		l.hosts[host] = s
+	}
+
+	s.users++
+	return s
+}
+
+// done unregisters a user of the semaphore for host, removing the semaphore
+// if it is no longer used.
+func (l *hostLimiter) done(host string, s *hostSemaphore) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	s.users--
+	if s.users == 0 {
+		delete(l.hosts, host)
+	}
+}
+
+// acquire waits until an operation may be performed against host, returning
+// a function to call once the operation is complete. A nil limiter does not
+// limit operations.
+func (l *hostLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	s := l.semaphore(host)
+
+	select {
+	case s.sem <- struct{}{}:
+		return func() {
+			<-s.sem
+			l.done(host, s)
+		}, nil
+	case <-ctx.Done():
+		l.done(host, s)
+		return nil, ctx.Err()
+	}
+}
+
+// hasSubScraper returns true if any of the post-process actions sub-scrape.
+func (c mappedScraperAttrConfig) hasSubScraper() bool {
+	for _, a := range c.postProcessActions {
+		if _, ok := a.(*postProcessSubScraper); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// postProcessConcurrent applies the post-process actions to each value,
+// processing up to maxConcurrentSubScrapes values at a time. The order of
+// the returned values matches the order of the input values.
+func (c mappedScraperAttrConfig) postProcessConcurrent(ctx context.Context, values []string, q mappedQuery) []string {
+	results := make([][]string, len(values))
+	sem := make(chan struct{}, maxConcurrentSubScrapes)
+
+	var wg sync.WaitGroup
+	for i, value := range values {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			results[i] = c.postProcessMulti(ctx, value, q)
+		}()
+	}
+
+	wg.Wait()
+	return sliceutil.Flatten(results)
+}
@@ -0,0 +1,330 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestConcurrentSubScrape(t *testing.T) {
+	const (
+		numPerformers = 12
+		delay         = 50 * time.Millisecond
+	)
+
+	var (
+		mutex       sync.Mutex
+		inFlight    int
+		maxInFlight int
+	)
+
+	var links strings.Builder
+	for i := 0; i < numPerformers; i++ {
+		fmt.Fprintf(&links, `<a class="performer" href="/performer/%d">link</a>`, i)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/performer/") {
+			fmt.Fprintf(w, `<html><h1>Title</h1>%s</html>`, links.String())
+			return
+		}
+
+		mutex.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mutex.Unlock()
+
+		time.Sleep(delay)
+
+		mutex.Lock()
+		inFlight--
+		mutex.Unlock()
+
+		id := strings.TrimPrefix(r.URL.Path, "/performer/")
+		fmt.Fprintf(w, `<html><h1>Performer %s</h1></html>`, id)
+	}))
+	defer ts.Close()
+
+	yamlStr := `name: Test
+sceneByURL:
+  - action: scrapeXPath
+    url:
+      - ` + ts.URL + `
+    scraper: sceneScraper
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Title: //h1
+      Performers:
+        Name:
+          selector: //a[@class="performer"]/@href
+          postProcess:
+            - replace:
+                - regex: ^
+                  with: ` + ts.URL + `
+            - subScraper:
+                selector: //h1
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	s := scraperFromDefinition(*c, mockGlobalConfig{})
+
+	client := newClient(mockGlobalConfig{}, newTransportPool(nil, newHostLimiter(maxSubScrapesPerHost)))
+
+	start := time.Now()
+	content, err := s.viaURL(context.Background(), client, ts.URL, ScrapeContentTypeScene)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		t.Fatalf("Error scraping scene: %s", err.Error())
+	}
+
+	scene, ok := content.(*models.ScrapedScene)
+	if !ok {
+		t.Fatal("couldn't convert scraped content into a scene")
+	}
+
+	// ordering must be preserved
+	if assert.Len(t, scene.Performers, numPerformers) {
+		for i, p := range scene.Performers {
+			verifyField(t, fmt.Sprintf("Performer %d", i), p.Name, "Name")
+		}
+	}
+
+	assert.Greater(t, maxInFlight, 1, "sub-scrapes should run concurrently")
+	assert.LessOrEqual(t, maxInFlight, maxSubScrapesPerHost, "sub-scrapes should honour the per-host limit")
+	assert.Less(t, elapsed, numPerformers*delay, "concurrent sub-scrapes should be faster than sequential")
+}
+
+func TestHostLimiter(t *testing.T) {
+	l := newHostLimiter(1)
+
+	release, err := l.acquire(context.Background(), "a.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a different host is not limited
+	releaseOther, err := l.acquire(context.Background(), "b.example")
+	if assert.NoError(t, err) {
+		releaseOther()
+	}
+
+	// the same host blocks until released
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = l.acquire(ctx, "a.example")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	release()
+
+	release, err = l.acquire(context.Background(), "a.example")
+	if assert.NoError(t, err) {
+		release()
+	}
+
+	// idle hosts are removed
+	assert.Empty(t, l.hosts)
+
+	// a nil limiter does not limit
+	var nilLimiter *hostLimiter
+	release, err = nilLimiter.acquire(context.Background(), "a.example")
+	if assert.NoError(t, err) {
+		release()
+	}
+}
+
+func TestMultipleSubScrape(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/details":
+			fmt.Fprint(w, `<html>
+<ul class="tags"><li> Tag 1 </li><li>Tag 2</li><li>Tag 3</li></ul>
+<a class="mirror" href="https://example.com/1">mirror</a>
+<a class="mirror" href="https://example.org/1">mirror</a>
+</html>`)
+		default:
+			fmt.Fprint(w, `<html><h1>Title</h1><a class="details" href="/details">details</a></html>`)
+		}
+	}))
+	defer ts.Close()
+
+	yamlStr := `name: Test
+sceneByURL:
+  - action: scrapeXPath
+    url:
+      - ` + ts.URL + `
+    scraper: sceneScraper
+xPathScrapers:
+  sceneScraper:
+    common:
+      $details: //a[@class="details"]/@href
+    scene:
+      Title: //h1
+      URLs:
+        selector: $details
+        postProcess:
+          - replace:
+              - regex: ^
+                with: ` + ts.URL + `
+          - subScraper:
+              selector: //a[@class="mirror"]/@href
+              multiple: true
+      Tags:
+        Name:
+          selector: $details
+          postProcess:
+            - replace:
+                - regex: ^
+                  with: ` + ts.URL + `
+            - subScraper:
+                selector: //ul[@class="tags"]/li
+                multiple: true
+                postProcess:
+                  - replace:
+                      - regex: ^Tag
+                        with: Genre
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	s := scraperFromDefinition(*c, mockGlobalConfig{})
+
+	content, err := s.viaURL(context.Background(), &http.Client{}, ts.URL, ScrapeContentTypeScene)
+	if err != nil {
+		t.Fatalf("Error scraping scene: %s", err.Error())
+	}
+
+	scene, ok := content.(*models.ScrapedScene)
+	if !ok {
+		t.Fatal("couldn't convert scraped content into a scene")
+	}
+
+	// each value found on the sub-page is returned and post-processed
+	var tags []string
+	for _, tag := range scene.Tags {
+		tags = append(tags, tag.Name)
+	}
+	assert.Equal(t, []string{"Genre 1", "Genre 2", "Genre 3"}, tags)
+	assert.Equal(t, []string{"https://example.com/1", "https://example.org/1"}, scene.URLs)
+}
+
+func TestMultipleSubScrapeYAML(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Tags:
+        Name:
+          selector: //a/@href
+          postProcess:
+            - subScraper:
+                selector: //li
+                multiple: true
+                concat: ", "
+`
+
+	c := &Definition{}
+	err := yaml.Unmarshal([]byte(yamlStr), &c)
+	assert.ErrorContains(t, err, "multiple and concat cannot both be set")
+}
+
+func TestStudioSubScraper(t *testing.T) {
+	var studioRequests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/studio/1":
+			studioRequests.Add(1)
+			fmt.Fprint(w, `<html>
+<h1>Studio Name</h1>
+<p class="bio">Studio Details</p>
+<a class="network">Network</a>
+</html>`)
+		case "/named":
+			fmt.Fprint(w, `<html><h1>Named Image</h1><a class="studio" href="/studio/1">Named Studio</a></html>`)
+		default:
+			fmt.Fprint(w, `<html><h1>Image</h1><a class="studio" href="/studio/1"></a></html>`)
+		}
+	}))
+	defer ts.Close()
+
+	yamlStr := `name: Test
+imageByURL:
+  - action: scrapeXPath
+    url:
+      - ` + ts.URL + `
+    scraper: imageScraper
+xPathScrapers:
+  imageScraper:
+    image:
+      Title: //h1
+      Studio:
+        Name: //a[@class="studio"]
+        URL:
+          selector: //a[@class="studio"]/@href
+          postProcess:
+            - replace:
+                - regex: ^
+                  with: ` + ts.URL + `
+        SubScraper:
+          Name: //h1
+          Details: //p[@class="bio"]
+          URL: //a[@class="other"]/@href
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	s := scraperFromDefinition(*c, mockGlobalConfig{})
+
+	content, err := s.viaURL(context.Background(), &http.Client{}, ts.URL+"/image", ScrapeContentTypeImage)
+	if err != nil {
+		t.Fatalf("Error scraping image: %s", err.Error())
+	}
+
+	image, ok := content.(*models.ScrapedImage)
+	if !ok {
+		t.Fatal("couldn't convert scraped content into an image")
+	}
+
+	verifyField(t, "Image", image.Title, "Title")
+	if assert.NotNil(t, image.Studio) {
+		// the studio is scraped from its URL
+		assert.Equal(t, "Studio Name", image.Studio.Name)
+		verifyField(t, "Studio Details", image.Studio.Details, "Studio.Details")
+		verifyField(t, ts.URL+"/studio/1", image.Studio.URL, "Studio.URL")
+	}
+	assert.Equal(t, int32(1), studioRequests.Load())
+
+	// studios with a name are not sub-scraped
+	content, err = s.viaURL(context.Background(), &http.Client{}, ts.URL+"/named", ScrapeContentTypeImage)
+	if err != nil {
+		t.Fatalf("Error scraping image: %s", err.Error())
+	}
+
+	image = content.(*models.ScrapedImage)
+	if assert.NotNil(t, image.Studio) {
+		assert.Equal(t, "Named Studio", image.Studio.Name)
+		assert.Nil(t, image.Studio.Details)
+	}
+	assert.Equal(t, int32(1), studioRequests.Load())
+}
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
@@ -16,6 +17,7 @@ import (
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/fetch"
 	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/security"
 	"github.com/chromedp/chromedp"
 	jsoniter "github.com/json-iterator/go"
 	"golang.org/x/net/html/charset"
@@ -25,11 +27,37 @@ import (
 
 const scrapeDefaultSleep = time.Second * 2
 
+const fileURLPrefix = "file://"
+
+// loadLocalFile loads the contents of a file:// url. Returns an error if
+// local files are not allowed by the global config.
+func loadLocalFile(loadURL string, globalConfig GlobalConfig) (io.Reader, error) {
+	if !globalConfig.GetScraperAllowLocalFiles() {
+		return nil, fmt.Errorf("%w: loading local files is not enabled", ErrNotSupported)
+	}
+
+	u, err := url.Parse(loadURL)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing url %s: %w", loadURL, err)
+	}
+
+	body, err := os.ReadFile(filepath.FromSlash(u.Path))
+	if err != nil {
+		return nil, err
+	}
+
+	return charset.NewReader(bytes.NewReader(body), "")
+}
+
 func loadURL(ctx context.Context, loadURL string, client *http.Client, def Definition, globalConfig GlobalConfig) (io.Reader, error) {
+	if strings.HasPrefix(loadURL, fileURLPrefix) {
+		return loadLocalFile(loadURL, globalConfig)
+	}
+
 	driverOptions := def.DriverOptions
 	if driverOptions != nil && driverOptions.UseCDP {
 		// get the page using chrome dp
-		return urlFromCDP(ctx, loadURL, *driverOptions, globalConfig)
+		return urlFromCDP(ctx, loadURL, *driverOptions, globalConfig, def.SkipTLSVerify)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, loadURL, nil)
@@ -54,6 +82,9 @@ func loadURL(ctx context.Context, loadURL string, client *http.Client, def Defin
 	}
 
 	userAgent := globalConfig.GetScraperUserAgent()
+	if def.UserAgent != "" {
+		userAgent = def.UserAgent
+	}
 	if userAgent != "" {
 		req.Header.Set("User-Agent", userAgent)
 	}
@@ -61,12 +92,30 @@ func loadURL(ctx context.Context, loadURL string, client *http.Client, def Defin
 	if driverOptions != nil { // setting the Headers after the UA allows us to override it from inside the scraper
 		for _, h := range driverOptions.Headers {
 			if h.Key != "" {
-				req.Header.Set(h.Key, h.Value)
+				// log the configured value, which does not include substituted secrets
+				req.Header.Set(h.Key, substituteEnv(h.Value))
 				logger.Debugf("[scraper] adding header <%s:%s>", h.Key, h.Value)
 			}
 		}
 	}
 
+	// revalidate the cached response if present
+	responses := clientResponseCache(client)
+	useCache := responses != nil && !def.DisableResponseCache
+	var cacheKey string
+	var cached *cachedResponse
+	if useCache {
+		cacheKey = responseCacheKey(req)
+		cached = responses.get(cacheKey)
+		if cached != nil {
+			cached.setConditionalHeaders(req)
+		}
+	}
+
+	if def.MaxRedirects > 0 {
+		client = clientWithMaxRedirects(client, def.MaxRedirects)
+	}
+
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
@@ -77,11 +126,24 @@ func loadURL(ctx context.Context, loadURL string, client *http.Client, def Defin
 
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		logger.Debugf("[scraper] %s not modified, using cached response", loadURL)
+		printCookies(jar, def, "Jar cookies found for scraper urls")
+		captureResponse(ctx, def, loadURL, cached.body)
+		return charset.NewReader(bytes.NewReader(cached.body), cached.contentType)
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
 
+	if useCache && resp.StatusCode == http.StatusOK {
+		responses.set(cacheKey, resp, body)
+	}
+
+	captureResponse(ctx, def, loadURL, body)
+
 	bodyReader := bytes.NewReader(body)
 	printCookies(jar, def, "Jar cookies found for scraper urls")
 	return charset.NewReader(bodyReader, resp.Header.Get("Content-Type"))
@@ -90,7 +152,8 @@ func loadURL(ctx context.Context, loadURL string, client *http.Client, def Defin
 // func urlFromCDP uses chrome cdp and DOM to load and process the url
 // if remote is set as true in the scraperConfig  it will try to use localhost:9222
 // else it will look for google-chrome in path
-func urlFromCDP(ctx context.Context, urlCDP string, driverOptions scraperDriverOptions, globalConfig GlobalConfig) (io.Reader, error) {
+// if skipTLSVerify is true, certificate errors are ignored by the browser
+func urlFromCDP(ctx context.Context, urlCDP string, driverOptions scraperDriverOptions, globalConfig GlobalConfig, skipTLSVerify bool) (io.Reader, error) {
 
 	if !driverOptions.UseCDP {
 		return nil, fmt.Errorf("url shouldn't be fetched through CDP")
@@ -214,6 +277,7 @@ func urlFromCDP(ctx context.Context, urlCDP string, driverOptions scraperDriverO
 	}
 
 	err := chromedp.Run(ctx,
+		ignoreCDPCertificateErrors(skipTLSVerify),
 		network.Enable(),
 		setCDPCookies(driverOptions),
 		printCDPCookies(driverOptions, "Cookies found"),
@@ -232,6 +296,15 @@ func urlFromCDP(ctx context.Context, urlCDP string, driverOptions scraperDriverO
 	return strings.NewReader(res), nil
 }
 
+// ignore certificate errors for the browser target if ignore is true
+func ignoreCDPCertificateErrors(ignore bool) chromedp.Tasks {
+	if !ignore {
+		return nil
+	}
+
+	return chromedp.Tasks{security.SetIgnoreCertificateErrors(true)}
+}
+
 // click all xpaths listed in the scraper config
 func setCDPClicks(driverOptions scraperDriverOptions) chromedp.Tasks {
 	var tasks chromedp.Tasks
@@ -293,7 +366,7 @@ func cdpHeaders(driverOptions scraperDriverOptions) map[string]interface{} {
 	if driverOptions.Headers != nil {
 		for _, h := range driverOptions.Headers {
 			if h.Key != "" {
-				headers[h.Key] = h.Value
+				headers[h.Key] = substituteEnv(h.Value)
 				logger.Debugf("[scraper] adding header <%s:%s>", h.Key, h.Value)
 			}
 		}
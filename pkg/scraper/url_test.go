@@ -0,0 +1,610 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+// writeFixture writes the contents to a file in a temporary directory,
+// returning its file:// url.
+func writeFixture(t *testing.T, name string, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return u.String()
+}
+
+func scrapeLocalPerformer(t *testing.T, yamlStr string, fixtureURL string, globalConfig GlobalConfig) (*models.ScrapedPerformer, error) {
+	t.Helper()
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	s := scraperFromDefinition(*c, globalConfig)
+	content, err := s.viaURL(context.Background(), nil, fixtureURL, ScrapeContentTypePerformer)
+	if err != nil || content == nil {
+		return nil, err
+	}
+
+	performer, ok := content.(*models.ScrapedPerformer)
+	if !ok {
+		t.Fatal("couldn't convert scraped content into a performer")
+	}
+
+	return performer, nil
+}
+
+func TestLoadLocalFileXPath(t *testing.T) {
+	fixtureURL := writeFixture(t, "performer.html", `<html><body><h1>Local Name</h1></body></html>`)
+
+	yamlStr := `name: Test
+performerByURL:
+  - action: scrapeXPath
+    url:
+      - file://
+    scraper: performerScraper
+xPathScrapers:
+  performerScraper:
+    performer:
+      Name: //h1
+`
+
+	performer, err := scrapeLocalPerformer(t, yamlStr, fixtureURL, mockGlobalConfig{allowLocalFiles: true})
+	if assert.NoError(t, err) && assert.NotNil(t, performer) {
+		verifyField(t, "Local Name", performer.Name, "Name")
+	}
+
+	_, err = scrapeLocalPerformer(t, yamlStr, fixtureURL, mockGlobalConfig{})
+	assert.ErrorIs(t, err, ErrNotSupported)
+}
+
+func TestLoadLocalFileJson(t *testing.T) {
+	fixtureURL := writeFixture(t, "performer.json", `{"data": {"name": "Local Name"}}`)
+
+	yamlStr := `name: Test
+performerByURL:
+  - action: scrapeJson
+    url:
+      - file://
+    scraper: performerScraper
+jsonScrapers:
+  performerScraper:
+    performer:
+      Name: data.name
+`
+
+	performer, err := scrapeLocalPerformer(t, yamlStr, fixtureURL, mockGlobalConfig{allowLocalFiles: true})
+	if assert.NoError(t, err) && assert.NotNil(t, performer) {
+		verifyField(t, "Local Name", performer.Name, "Name")
+	}
+
+	_, err = scrapeLocalPerformer(t, yamlStr, fixtureURL, mockGlobalConfig{})
+	assert.ErrorIs(t, err, ErrNotSupported)
+}
+
+func TestLoadURLUserAgent(t *testing.T) {
+	const (
+		globalUA  = "global-agent"
+		scraperUA = "scraper-agent"
+	)
+
+	var gotUA, gotHeader string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUA = r.Header.Get("User-Agent")
+		gotHeader = r.Header.Get("X-Custom")
+		fmt.Fprint(w, "<html></html>")
+	}))
+	defer ts.Close()
+
+	globalConfig := mockGlobalConfig{userAgent: globalUA}
+
+	tests := []struct {
+		name    string
+		def     Definition
+		wantUA  string
+		wantHdr string
+	}{
+		{
+			"global",
+			Definition{},
+			globalUA,
+			"",
+		},
+		{
+			"scraper overrides global",
+			Definition{UserAgent: scraperUA},
+			scraperUA,
+			"",
+		},
+		{
+			"combined with headers",
+			Definition{
+				UserAgent: scraperUA,
+				DriverOptions: &scraperDriverOptions{
+					Headers: []*header{{Key: "X-Custom", Value: "value"}},
+				},
+			},
+			scraperUA,
+			"value",
+		},
+		{
+			"header overrides scraper",
+			Definition{
+				UserAgent: scraperUA,
+				DriverOptions: &scraperDriverOptions{
+					Headers: []*header{{Key: "User-Agent", Value: "header-agent"}},
+				},
+			},
+			"header-agent",
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotUA, gotHeader = "", ""
+
+			_, err := loadURL(context.Background(), ts.URL, &http.Client{}, tt.def, globalConfig)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.wantUA, gotUA)
+				assert.Equal(t, tt.wantHdr, gotHeader)
+			}
+		})
+	}
+}
+
+func TestLoadURLReusesConnections(t *testing.T) {
+	var newConns, cookieRequests atomic.Int32
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := r.Cookie("name"); err == nil {
+			cookieRequests.Add(1)
+		}
+		fmt.Fprint(w, "<html></html>")
+	}))
+	ts.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			newConns.Add(1)
+		}
+	}
+	ts.Start()
+	defer ts.Close()
+
+	globalConfig := mockGlobalConfig{}
+
+	defs := []Definition{
+		{},
+		{UserAgent: "scraper-agent"},
+		{
+			DriverOptions: &scraperDriverOptions{
+				Cookies: []*cookieOptions{
+					{
+						CookieURL: ts.URL,
+						Cookies:   []*scraperCookies{{Name: "name", Value: "value"}},
+					},
+				},
+			},
+		},
+	}
+
+	// each request uses a separate client from the same pool, as different
+	// scrapers would
+	pool := newTransportPool(nil, nil)
+	for i := 0; i < 3; i++ {
+		for _, def := range defs {
+			_, err := loadURL(context.Background(), ts.URL, newClient(globalConfig, pool), def, globalConfig)
+			assert.NoError(t, err)
+		}
+	}
+
+	assert.Equal(t, int32(1), newConns.Load())
+	// cookies are still applied per scraper
+	assert.Equal(t, int32(3), cookieRequests.Load())
+
+	// clients from a different pool do not share connections
+	_, err := loadURL(context.Background(), ts.URL, newClient(globalConfig, newTransportPool(nil, nil)), Definition{}, globalConfig)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), newConns.Load())
+}
+
+func TestLoadURLConditional(t *testing.T) {
+	const lastModified = "Mon, 02 Jan 2006 15:04:05 GMT"
+
+	tests := []struct {
+		name       string
+		validate   func(w http.ResponseWriter, r *http.Request) bool
+		setHeaders func(w http.ResponseWriter)
+	}{
+		{
+			"etag",
+			func(w http.ResponseWriter, r *http.Request) bool {
+				return r.Header.Get("If-None-Match") == `"v1"`
+			},
+			func(w http.ResponseWriter) {
+				w.Header().Set("ETag", `"v1"`)
+			},
+		},
+		{
+			"last modified",
+			func(w http.ResponseWriter, r *http.Request) bool {
+				return r.Header.Get("If-Modified-Since") == lastModified
+			},
+			func(w http.ResponseWriter) {
+				w.Header().Set("Last-Modified", lastModified)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var full, notModified int
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tt.setHeaders(w)
+				if tt.validate(w, r) {
+					notModified++
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+
+				full++
+				fmt.Fprint(w, "<html>body</html>")
+			}))
+			defer ts.Close()
+
+			globalConfig := mockGlobalConfig{}
+			client := newClient(globalConfig, newTransportPool(newResponseCache(), nil))
+
+			load := func(client *http.Client) {
+				r, err := loadURL(context.Background(), ts.URL, client, Definition{}, globalConfig)
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				body, err := io.ReadAll(r)
+				assert.NoError(t, err)
+				assert.Equal(t, "<html>body</html>", string(body))
+			}
+
+			for i := 0; i < 3; i++ {
+				load(client)
+			}
+
+			assert.Equal(t, 1, full)
+			assert.Equal(t, 2, notModified)
+
+			// responses are not shared with clients of other pools
+			load(newClient(globalConfig, newTransportPool(newResponseCache(), nil)))
+			load(newClient(globalConfig, newTransportPool(nil, nil)))
+			assert.Equal(t, 3, full)
+			assert.Equal(t, 2, notModified)
+		})
+	}
+}
+
+func TestLoadURLConditionalChanged(t *testing.T) {
+	version := "v1"
+	var conditional int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := `"` + version + `"`
+		if r.Header.Get("If-None-Match") != "" {
+			conditional++
+		}
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		fmt.Fprint(w, version)
+	}))
+	defer ts.Close()
+
+	client := newClient(mockGlobalConfig{}, newTransportPool(newResponseCache(), nil))
+	load := func() string {
+		r, err := loadURL(context.Background(), ts.URL, client, Definition{}, mockGlobalConfig{})
+		if err != nil {
+			t.Fatalf("loadURL: %v", err)
+		}
+
+		body, _ := io.ReadAll(r)
+		return string(body)
+	}
+
+	assert.Equal(t, "v1", load())
+	version = "v2"
+	assert.Equal(t, "v2", load())
+	assert.Equal(t, "v2", load())
+	assert.Equal(t, 2, conditional)
+}
+
+func TestLoadURLConditionalHeaders(t *testing.T) {
+	var conditional int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := `"` + r.Header.Get("Authorization") + `"`
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") != "" {
+			conditional++
+		}
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		fmt.Fprint(w, r.Header.Get("Authorization"))
+	}))
+	defer ts.Close()
+
+	newDefinition := func(auth string, disableCache bool) Definition {
+		return Definition{
+			DriverOptions: &scraperDriverOptions{
+				Headers: []*header{{Key: "Authorization", Value: auth}},
+			},
+			DisableResponseCache: disableCache,
+		}
+	}
+
+	client := newClient(mockGlobalConfig{}, newTransportPool(newResponseCache(), nil))
+	load := func(def Definition) string {
+		r, err := loadURL(context.Background(), ts.URL, client, def, mockGlobalConfig{})
+		if err != nil {
+			t.Fatalf("loadURL: %v", err)
+		}
+
+		body, _ := io.ReadAll(r)
+		return string(body)
+	}
+
+	// responses fetched with different headers are not shared
+	assert.Equal(t, "one", load(newDefinition("one", false)))
+	assert.Equal(t, "two", load(newDefinition("two", false)))
+	assert.Equal(t, 0, conditional)
+
+	assert.Equal(t, "one", load(newDefinition("one", false)))
+	assert.Equal(t, 1, conditional)
+
+	// conditional requests are not made if the cache is disabled
+	assert.Equal(t, "one", load(newDefinition("one", true)))
+	assert.Equal(t, "three", load(newDefinition("three", true)))
+	assert.Equal(t, "three", load(newDefinition("three", false)))
+	assert.Equal(t, 1, conditional)
+}
+
+func TestResponseCacheEviction(t *testing.T) {
+	c := &responseCache{limit: 2}
+	resp := &http.Response{
+		Header: http.Header{"Etag": []string{`"v1"`}},
+	}
+
+	c.set("a", resp, []byte("a"))
+	c.set("b", resp, []byte("b"))
+	c.set("a", resp, []byte("a2"))
+	c.set("c", resp, []byte("c"))
+
+	// oldest key is evicted first
+	assert.Nil(t, c.get("a"))
+	assert.Equal(t, []byte("b"), c.get("b").body)
+	assert.Equal(t, []byte("c"), c.get("c").body)
+
+	// responses without validators are removed
+	c.set("b", &http.Response{Header: http.Header{}}, []byte("b2"))
+	assert.Nil(t, c.get("b"))
+	assert.Equal(t, []string{"c"}, c.keys)
+
+	c.clear()
+	assert.Nil(t, c.get("c"))
+	assert.Empty(t, c.keys)
+}
+
+func TestLoadURLCaptureResponse(t *testing.T) {
+	const body = "<html>captured body</html>"
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body)
+	}))
+	defer ts.Close()
+
+	globalConfig := mockGlobalConfig{}
+
+	load := func(def Definition) *ResponseCapture {
+		c := &ResponseCapture{}
+		ctx := WithResponseCapture(context.Background(), c)
+		r, err := loadURL(ctx, ts.URL, &http.Client{}, def, globalConfig)
+		if assert.NoError(t, err) {
+			_, err = io.ReadAll(r)
+			assert.NoError(t, err)
+		}
+		return c
+	}
+
+	// not captured by default
+	assert.Empty(t, load(Definition{}).Responses())
+
+	c := load(Definition{
+		DebugOptions: &scraperDebugOptions{CaptureResponse: true},
+	})
+	assert.Equal(t, []CapturedResponse{
+		{URL: ts.URL, Body: []byte(body)},
+	}, c.Responses())
+
+	// truncated to the capture limit
+	c = load(Definition{
+		DebugOptions: &scraperDebugOptions{CaptureResponse: true, CaptureLimit: 6},
+	})
+	assert.Equal(t, []CapturedResponse{
+		{URL: ts.URL, Body: []byte("<html>"), Truncated: true},
+	}, c.Responses())
+
+	// no capture without a ResponseCapture in the context
+	_, err := loadURL(context.Background(), ts.URL, &http.Client{}, Definition{
+		DebugOptions: &scraperDebugOptions{CaptureResponse: true},
+	}, globalConfig)
+	assert.NoError(t, err)
+}
+
+func TestLoadURLMaxRedirects(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		// redirect loop with a distinct url for each redirect
+		http.Redirect(w, r, fmt.Sprintf("/loop/%d", requests), http.StatusFound)
+	}))
+	defer ts.Close()
+
+	globalConfig := mockGlobalConfig{}
+	client := newClient(globalConfig, newTransportPool(nil, nil))
+
+	tests := []struct {
+		name    string
+		max     int
+		wantReq int
+	}{
+		{"default", 0, maxRedirects},
+		{"scraper maximum", 3, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = 0
+
+			_, err := loadURL(context.Background(), ts.URL, client, Definition{MaxRedirects: tt.max}, globalConfig)
+			assert.ErrorIs(t, err, ErrMaxRedirects)
+			assert.Equal(t, tt.wantReq, requests)
+
+			// the error names the url that would have been requested next
+			if err != nil {
+				assert.Contains(t, err.Error(), fmt.Sprintf("%s/loop/%d", ts.URL, tt.wantReq))
+			}
+		})
+	}
+}
+
+// certCheckGlobalConfig is a mockGlobalConfig that verifies TLS certificates.
+type certCheckGlobalConfig struct {
+	mockGlobalConfig
+}
+
+func (certCheckGlobalConfig) GetScraperCertCheck() bool {
+	return true
+}
+
+func TestSkipTLSVerify(t *testing.T) {
+	// the test server uses a self-signed certificate
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><h1>Title</h1></html>`)
+	}))
+	defer ts.Close()
+
+	newDefinition := func(skip bool) Definition {
+		return Definition{
+			SkipTLSVerify: skip,
+			SceneByURL: []*ByURLDefinition{{
+				URL: []string{ts.URL},
+				ActionDefinition: ActionDefinition{
+					Action:  scraperActionXPath,
+					Scraper: "sceneScraper",
+				},
+			}},
+			XPathScrapers: mappedScrapers{
+				"sceneScraper": mappedScraper{
+					Scene: &mappedSceneScraperConfig{
+						mappedConfig: mappedConfig{
+							"Title": mappedScraperAttrConfig{Selector: "//h1"},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	gc := certCheckGlobalConfig{}
+	client := newClient(gc, newTransportPool(nil, nil))
+	ctx := context.Background()
+
+	_, err := scraperFromDefinition(newDefinition(false), gc).viaURL(ctx, client, ts.URL, ScrapeContentTypeScene)
+	assert.Error(t, err)
+
+	content, err := scraperFromDefinition(newDefinition(true), gc).viaURL(ctx, client, ts.URL, ScrapeContentTypeScene)
+	if assert.NoError(t, err) {
+		scene, ok := content.(*models.ScrapedScene)
+		if assert.True(t, ok) {
+			verifyField(t, "Title", scene.Title, "Title")
+		}
+	}
+
+	// the shared client is not changed
+	_, err = scraperFromDefinition(newDefinition(false), gc).viaURL(ctx, client, ts.URL, ScrapeContentTypeScene)
+	assert.Error(t, err)
+
+	// the copy uses the transport pool of the client
+	pool := client.Transport.(*scraperTransport).pool
+	insecure := clientWithoutTLSVerify(client, gc).Transport.(*scraperTransport)
+	assert.Same(t, pool, insecure.pool)
+	assert.Same(t, insecure, pool.get(gc, false))
+
+	// the insecure client is reused
+	assert.Same(t, clientWithoutTLSVerify(client, gc), clientWithoutTLSVerify(client, gc))
+}
+
+func TestViaURLIgnoreQuery(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><h1>%s</h1></html>`, r.URL.Query().Get("lang"))
+	}))
+	defer ts.Close()
+
+	def := Definition{
+		SceneByURL: []*ByURLDefinition{{
+			URLRegex:    urlRegexps{regexp.MustCompile("^" + regexp.QuoteMeta(ts.URL) + `/scene/\d+$`)},
+			IgnoreQuery: true,
+			ActionDefinition: ActionDefinition{
+				Action:  scraperActionXPath,
+				Scraper: "sceneScraper",
+			},
+		}},
+		XPathScrapers: mappedScrapers{
+			"sceneScraper": mappedScraper{
+				Scene: &mappedSceneScraperConfig{
+					mappedConfig: mappedConfig{
+						"Title": mappedScraperAttrConfig{Selector: "//h1"},
+					},
+				},
+			},
+		},
+	}
+
+	gc := mockGlobalConfig{}
+	s := scraperFromDefinition(def, gc)
+	u := ts.URL + "/scene/1?lang=en&utm_source=feed"
+
+	assert.True(t, s.supportsURL(u, ScrapeContentTypeScene))
+
+	content, err := s.viaURL(context.Background(), newClient(gc, newTransportPool(nil, nil)), u, ScrapeContentTypeScene)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	scene, ok := content.(*models.ScrapedScene)
+	if assert.True(t, ok) {
+		// the url is scraped with its query string
+		verifyField(t, "en", scene.Title, "Title")
+	}
+}
@@ -0,0 +1,394 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+type xmlScraper struct {
+	definition   Definition
+	globalConfig GlobalConfig
+	client       *http.Client
+}
+
+func (s *xmlScraper) getXMLScraper(name string) (*mappedScraper, error) {
+	ret, ok := s.definition.XMLScrapers[name]
+	if !ok {
+		return nil, fmt.Errorf("xml scraper with name %s not found in config", name)
+	}
+	return &ret, nil
+}
+
+type xmlURLScraper struct {
+	xmlScraper
+	definition ByURLDefinition
+}
+
+func (s *xmlURLScraper) scrapeByURL(ctx context.Context, url string, ty ScrapeContentType) (ScrapedContent, error) {
+	scraper, err := s.getXMLScraper(s.definition.Scraper)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := s.loadURL(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	q := s.getXMLQuery(doc, url)
+	if ty == ScrapeContentTypeScene && s.definition.Multiple {
+		// use the first scene from the list
+		q.setType(SearchQuery)
+		scenes, err := scraper.scrapeScenes(ctx, q)
+		if err != nil || len(scenes) == 0 {
+			return nil, err
+		}
+		return scenes[0], nil
+	}
+
+	return scraper.scrapeContent(ctx, q, ty)
+}
+
+// scrapeByURLMulti scrapes a URL which returns a list of results.
+// Only scenes are supported.
+func (s *xmlURLScraper) scrapeByURLMulti(ctx context.Context, url string, ty ScrapeContentType) ([]ScrapedContent, error) {
+	scraper, err := s.getXMLScraper(s.definition.Scraper)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := s.loadURL(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	return scraper.scrapeContentList(ctx, s.getXMLQuery(doc, url), ty)
+}
+
+type xmlNameScraper struct {
+	xmlScraper
+	definition ByNameDefinition
+}
+
+func (s *xmlNameScraper) scrapeByName(ctx context.Context, name string, ty ScrapeContentType) ([]ScrapedContent, error) {
+	scraper, err := s.getXMLScraper(s.definition.Scraper)
+	if err != nil {
+		return nil, err
+	}
+
+	const placeholder = "{}"
+
+	// replace the placeholder string with the URL-escaped name
+	escapedName := url.QueryEscape(name)
+
+	url := s.definition.QueryURL
+	url = strings.ReplaceAll(url, placeholder, escapedName)
+
+	doc, err := s.loadURL(ctx, url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	q := s.getXMLQuery(doc, url)
+	q.setType(SearchQuery)
+
+	var content []ScrapedContent
+	switch ty {
+	case ScrapeContentTypePerformer:
+		performers, err := scraper.scrapePerformers(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range performers {
+			content = append(content, p)
+		}
+
+		return content, nil
+	case ScrapeContentTypeScene:
+		scenes, err := scraper.scrapeScenes(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range scenes {
+			content = append(content, s)
+		}
+
+		return content, nil
+	}
+
+	return nil, ErrNotSupported
+}
+
+type xmlFragmentScraper struct {
+	xmlScraper
+	definition ByFragmentDefinition
+}
+
+func (s *xmlFragmentScraper) scrapeSceneByScene(ctx context.Context, scene *models.Scene) (*models.ScrapedScene, error) {
+	// construct the URL
+	queryURL := queryURLParametersFromScene(scene)
+	if s.definition.QueryURLReplacements != nil {
+		queryURL.applyReplacements(s.definition.QueryURLReplacements)
+	}
+	url := queryURL.constructURL(s.definition.QueryURL)
+
+	scraper, err := s.getXMLScraper(s.definition.Scraper)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := s.loadURL(ctx, url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	q := s.getXMLQuery(doc, url)
+	return scraper.scrapeScene(ctx, q)
+}
+
+func (s *xmlFragmentScraper) scrapeByFragment(ctx context.Context, input Input) (ScrapedContent, error) {
+	switch {
+	case input.Gallery != nil:
+		return nil, fmt.Errorf("%w: cannot use an xml scraper as a gallery fragment scraper", ErrNotSupported)
+	case input.Performer != nil:
+		return nil, fmt.Errorf("%w: cannot use an xml scraper as a performer fragment scraper", ErrNotSupported)
+	case input.Scene == nil:
+		return nil, fmt.Errorf("%w: scene input is nil", ErrNotSupported)
+	}
+
+	scene := *input.Scene
+
+	// construct the URL
+	queryURL := queryURLParametersFromScrapedScene(scene)
+	if s.definition.QueryURLReplacements != nil {
+		queryURL.applyReplacements(s.definition.QueryURLReplacements)
+	}
+	url := queryURL.constructURL(s.definition.QueryURL)
+
+	scraper, err := s.getXMLScraper(s.definition.Scraper)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := s.loadURL(ctx, url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	q := s.getXMLQuery(doc, url)
+	return scraper.scrapeScene(ctx, q)
+}
+
+func (s *xmlFragmentScraper) scrapeGalleryByGallery(ctx context.Context, gallery *models.Gallery) (*models.ScrapedGallery, error) {
+	// construct the URL
+	queryURL := queryURLParametersFromGallery(gallery)
+	if s.definition.QueryURLReplacements != nil {
+		queryURL.applyReplacements(s.definition.QueryURLReplacements)
+	}
+	url := queryURL.constructURL(s.definition.QueryURL)
+
+	scraper, err := s.getXMLScraper(s.definition.Scraper)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := s.loadURL(ctx, url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	q := s.getXMLQuery(doc, url)
+	return scraper.scrapeGallery(ctx, q)
+}
+
+func (s *xmlFragmentScraper) scrapeImageByImage(ctx context.Context, image *models.Image) (*models.ScrapedImage, error) {
+	// construct the URL
+	queryURL := queryURLParametersFromImage(image)
+	if s.definition.QueryURLReplacements != nil {
+		queryURL.applyReplacements(s.definition.QueryURLReplacements)
+	}
+	url := queryURL.constructURL(s.definition.QueryURL)
+
+	scraper, err := s.getXMLScraper(s.definition.Scraper)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := s.loadURL(ctx, url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	q := s.getXMLQuery(doc, url)
+	return scraper.scrapeImage(ctx, q)
+}
+
+func (s *xmlScraper) loadURL(ctx context.Context, url string) (*xmlquery.Node, error) {
+	r, err := loadURL(ctx, url, s.client, s.definition, s.globalConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load URL %q: %w", url, err)
+	}
+
+	ret, err := xmlquery.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("parsing XML from %q: %w", url, err)
+	}
+
+	if s.definition.DebugOptions != nil && s.definition.DebugOptions.PrintHTML {
+		logger.Infof("loadURL (%s) response: \n%s", url, ret.OutputXML(true))
+	}
+
+	return ret, nil
+}
+
+func (s *xmlScraper) getXMLQuery(doc *xmlquery.Node, url string) *xmlQuery {
+	return &xmlQuery{
+		doc:     doc,
+		scraper: s,
+		url:     url,
+	}
+}
+
+// xmlQuery runs xpath selectors against an XML document, such as an RSS feed.
+type xmlQuery struct {
+	doc       *xmlquery.Node
+	scraper   *xmlScraper
+	queryType QueryType
+	url       string
+}
+
+func (q *xmlQuery) getType() QueryType {
+	return q.queryType
+}
+
+func (q *xmlQuery) setType(t QueryType) {
+	q.queryType = t
+}
+
+func (q *xmlQuery) getURL() string {
+	return q.url
+}
+
+func (q *xmlQuery) getClient() *http.Client {
+	return q.scraper.client
+}
+
+// evaluate returns the result of the selector, which is either a node
+// iterator or a scalar value.
+func (q *xmlQuery) evaluate(selector string) (interface{}, error) {
+	expr, err := xpath.Compile(selector)
+	if err != nil {
+		return nil, fmt.Errorf("selector '%s': parse error: %v", selector, err)
+	}
+
+	return expr.Evaluate(xmlquery.CreateXPathNavigator(q.doc)), nil
+}
+
+func (q *xmlQuery) runQuery(selector string) ([]string, error) {
+	result, err := q.evaluate(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	// functions such as count() or normalize-space() return a scalar value
+	// rather than a node set
+	if v, isScalar := scalarResult(result); isScalar {
+		if v == "" {
+			return nil, nil
+		}
+		return []string{v}, nil
+	}
+
+	return q.nodeTexts(result), nil
+}
+
+// evaluateGuard returns true if the selector returns a non-empty node set,
+// or a scalar value that is true when converted by the boolean() function.
+func (q *xmlQuery) evaluateGuard(selector string) (bool, error) {
+	result, err := q.evaluate(selector)
+	if err != nil {
+		return false, err
+	}
+
+	if v, isScalar := scalarTruth(result); isScalar {
+		return v, nil
+	}
+
+	return len(q.nodeTexts(result)) > 0, nil
+}
+
+// nodeTexts returns the non-empty text of the nodes of a node set result.
+func (q *xmlQuery) nodeTexts(result interface{}) []string {
+	iter, ok := result.(*xpath.NodeIterator)
+	if !ok {
+		return nil
+	}
+
+	var ret []string
+	for iter.MoveNext() {
+		nav := iter.Current().(*xmlquery.NodeNavigator)
+
+		n := nav.Current()
+		if nav.NodeType() == xpath.AttributeNode {
+			n = &xmlquery.Node{
+				Type: xmlquery.TextNode,
+				Data: nav.Value(),
+			}
+		}
+
+		// don't add empty strings
+		nodeText := q.nodeText(n)
+		if nodeText != "" {
+			ret = append(ret, nodeText)
+		}
+	}
+
+	return ret
+}
+
+func (q *xmlQuery) nodeText(n *xmlquery.Node) string {
+	var ret string
+	if n != nil && n.Type == xmlquery.CommentNode {
+		ret = n.OutputXML(true)
+	} else {
+		ret = n.InnerText()
+	}
+
+	// trim all leading and trailing whitespace
+	ret = strings.TrimSpace(ret)
+
+	// remove multiple whitespace
+	re := regexp.MustCompile("  +")
+	ret = re.ReplaceAllString(ret, " ")
+
+	re = regexp.MustCompile("\n")
+	ret = re.ReplaceAllString(ret, "")
+
+	return ret
+}
+
+func (q *xmlQuery) subScrape(ctx context.Context, value string) mappedQuery {
+	doc, err := q.scraper.loadURL(ctx, value)
+
+	if err != nil {
+		logger.Warnf("Error getting URL '%s' for sub-scraper: %s", value, err.Error())
+		return nil
+	}
+
+	return q.scraper.getXMLQuery(doc, value)
+}
@@ -0,0 +1,136 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+const xmlTestFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/">
+  <channel>
+    <title>Example Feed</title>
+    <item>
+      <title>Scene Title</title>
+      <link>https://example.com/scenes/1</link>
+      <pubDate>Tue, 02 Jan 2024 15:04:05 +0000</pubDate>
+      <description><![CDATA[<p>Scene details</p>]]></description>
+      <category>Tag 1</category>
+      <category>Tag 2</category>
+      <media:credit role="performer">Performer 1</media:credit>
+      <media:credit role="performer">Performer 2</media:credit>
+      <media:thumbnail url="https://example.com/scenes/1.jpg"/>
+    </item>
+  </channel>
+</rss>`
+
+func TestXMLSceneScraper(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprint(w, xmlTestFeed)
+	}))
+	defer ts.Close()
+
+	yamlStr := `name: Test
+sceneByURL:
+  - action: scrapeXML
+    url:
+      - ` + ts.URL + `
+    scraper: sceneScraper
+xmlScrapers:
+  sceneScraper:
+    common:
+      $item: //channel/item[1]
+    scene:
+      Title: $item/title
+      URL: $item/link
+      Date:
+        selector: $item/pubDate
+        postProcess:
+          - parseDate: Mon, 02 Jan 2006 15:04:05 -0700
+      Details: $item/description
+      Image: $item/media:thumbnail/@url
+      Tags:
+        Name: $item/category
+      Performers:
+        Name: $item/media:credit[@role="performer"]
+      Code:
+        fixed: guarded
+        when: count($item/category)
+      Director:
+        fixed: guarded
+        when: boolean($item/enclosure)
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	s := scraperFromDefinition(*c, mockGlobalConfig{})
+	content, err := s.viaURL(context.Background(), &http.Client{}, ts.URL+"/feed", ScrapeContentTypeScene)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	scene, ok := content.(*models.ScrapedScene)
+	if !assert.True(t, ok) {
+		return
+	}
+
+	verifyField(t, "Scene Title", scene.Title, "Title")
+	verifyField(t, "https://example.com/scenes/1", scene.URL, "URL")
+	verifyField(t, "2024-01-02", scene.Date, "Date")
+	verifyField(t, "<p>Scene details</p>", scene.Details, "Details")
+	verifyField(t, "https://example.com/scenes/1.jpg", scene.Image, "Image")
+	verifyField(t, "guarded", scene.Code, "Code")
+	assert.Nil(t, scene.Director)
+
+	var tags []string
+	for _, tag := range scene.Tags {
+		tags = append(tags, tag.Name)
+	}
+	assert.Equal(t, []string{"Tag 1", "Tag 2"}, tags)
+
+	var performers []string
+	for _, p := range scene.Performers {
+		performers = append(performers, *p.Name)
+	}
+	assert.Equal(t, []string{"Performer 1", "Performer 2"}, performers)
+}
+
+func TestXMLScraperInvalidDocument(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<rss><channel><title>Unclosed</channel></rss>`)
+	}))
+	defer ts.Close()
+
+	c := Definition{
+		SceneByURL: []*ByURLDefinition{{
+			URL: []string{ts.URL},
+			ActionDefinition: ActionDefinition{
+				Action:  scraperActionXML,
+				Scraper: "sceneScraper",
+			},
+		}},
+		XMLScrapers: mappedScrapers{
+			"sceneScraper": mappedScraper{
+				Scene: &mappedSceneScraperConfig{
+					mappedConfig: mappedConfig{
+						"Title": mappedScraperAttrConfig{Selector: "//title"},
+					},
+				},
+			},
+		},
+	}
+
+	s := scraperFromDefinition(c, mockGlobalConfig{})
+	_, err := s.viaURL(context.Background(), &http.Client{}, ts.URL, ScrapeContentTypeScene)
+	assert.Error(t, err)
+}
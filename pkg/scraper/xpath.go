@@ -4,12 +4,15 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"math"
 	"net/http"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
 
 	"golang.org/x/net/html"
 
@@ -48,42 +51,33 @@ func (s *xpathURLScraper) scrapeByURL(ctx context.Context, url string, ty Scrape
 	}
 
 	q := s.getXPathQuery(doc, url)
-	// if these just return the return values from scraper.scrape* functions then
-	// it ends up returning ScrapedContent(nil) rather than nil
-	switch ty {
-	case ScrapeContentTypePerformer:
-		ret, err := scraper.scrapePerformer(ctx, q)
-		if err != nil || ret == nil {
-			return nil, err
-		}
-		return ret, nil
-	case ScrapeContentTypeScene:
-		ret, err := scraper.scrapeScene(ctx, q)
-		if err != nil || ret == nil {
-			return nil, err
-		}
-		return ret, nil
-	case ScrapeContentTypeGallery:
-		ret, err := scraper.scrapeGallery(ctx, q)
-		if err != nil || ret == nil {
-			return nil, err
-		}
-		return ret, nil
-	case ScrapeContentTypeImage:
-		ret, err := scraper.scrapeImage(ctx, q)
-		if err != nil || ret == nil {
-			return nil, err
-		}
-		return ret, nil
-	case ScrapeContentTypeMovie, ScrapeContentTypeGroup:
-		ret, err := scraper.scrapeGroup(ctx, q)
-		if err != nil || ret == nil {
+	if ty == ScrapeContentTypeScene && s.definition.Multiple {
+		// use the first scene from the list
+		q.setType(SearchQuery)
+		scenes, err := scraper.scrapeScenes(ctx, q)
+		if err != nil || len(scenes) == 0 {
 			return nil, err
 		}
-		return ret, nil
+		return scenes[0], nil
 	}
 
-	return nil, ErrNotSupported
+	return scraper.scrapeContent(ctx, q, ty)
+}
+
+// scrapeByURLMulti scrapes a URL which returns a list of results.
+// Only scenes are supported.
+func (s *xpathURLScraper) scrapeByURLMulti(ctx context.Context, url string, ty ScrapeContentType) ([]ScrapedContent, error) {
+	scraper, err := s.getXpathScraper(s.definition.Scraper)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := s.loadURL(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	return scraper.scrapeContentList(ctx, s.getXPathQuery(doc, url), ty)
 }
 
 type xpathNameScraper struct {
@@ -295,22 +289,113 @@ func (q *xpathQuery) getURL() string {
 	return q.url
 }
 
-func (q *xpathQuery) runQuery(selector string) ([]string, error) {
-	found, err := htmlquery.QueryAll(q.doc, selector)
+func (q *xpathQuery) getClient() *http.Client {
+	return q.scraper.client
+}
+
+// evaluate returns the result of the selector, which is either a node
+// iterator or a scalar value.
+func (q *xpathQuery) evaluate(selector string) (interface{}, error) {
+	expr, err := xpath.Compile(selector)
 	if err != nil {
 		return nil, fmt.Errorf("selector '%s': parse error: %v", selector, err)
 	}
 
+	return expr.Evaluate(htmlquery.CreateXPathNavigator(q.doc)), nil
+}
+
+func (q *xpathQuery) runQuery(selector string) ([]string, error) {
+	result, err := q.evaluate(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	// functions such as count() or normalize-space() return a scalar value
+	// rather than a node set
+	if v, isScalar := scalarResult(result); isScalar {
+		if v == "" {
+			return nil, nil
+		}
+		return []string{v}, nil
+	}
+
+	return q.nodeTexts(result), nil
+}
+
+// evaluateGuard returns true if the selector returns a non-empty node set,
+// or a scalar value that is true when converted by the boolean() function.
+func (q *xpathQuery) evaluateGuard(selector string) (bool, error) {
+	result, err := q.evaluate(selector)
+	if err != nil {
+		return false, err
+	}
+
+	if v, isScalar := scalarTruth(result); isScalar {
+		return v, nil
+	}
+
+	return len(q.nodeTexts(result)) > 0, nil
+}
+
+// nodeTexts returns the non-empty text of the nodes of a node set result.
+func (q *xpathQuery) nodeTexts(result interface{}) []string {
+	iter, ok := result.(*xpath.NodeIterator)
+	if !ok {
+		return nil
+	}
+
 	var ret []string
-	for _, n := range found {
+	for iter.MoveNext() {
+		nav := iter.Current().(*htmlquery.NodeNavigator)
+
+		n := nav.Current()
+		if nav.NodeType() == xpath.AttributeNode {
+			n = &html.Node{
+				Type: html.TextNode,
+				Data: nav.Value(),
+			}
+		}
+
 		// don't add empty strings
 		nodeText := q.nodeText(n)
 		if nodeText != "" {
-			ret = append(ret, q.nodeText(n))
+			ret = append(ret, nodeText)
 		}
 	}
 
-	return ret, nil
+	return ret
+}
+
+// scalarResult returns the string representation of an evaluated xpath
+// expression, if the result is not a node set.
+func scalarResult(result interface{}) (string, bool) {
+	switch v := result.(type) {
+	case string:
+		return strings.TrimSpace(v), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
+
+// scalarTruth returns the boolean value of an evaluated xpath expression, as
+// converted by the boolean() function, if the result is not a node set.
+// Numbers are true if they are not zero or NaN, and strings are true if they
+// are not empty.
+func scalarTruth(result interface{}) (bool, bool) {
+	switch v := result.(type) {
+	case string:
+		return strings.TrimSpace(v) != "", true
+	case float64:
+		return v != 0 && !math.IsNaN(v), true
+	case bool:
+		return v, true
+	default:
+		return false, false
+	}
 }
 
 func (q *xpathQuery) nodeText(n *html.Node) string {
@@ -436,6 +436,62 @@ func TestConcatXPath(t *testing.T) {
 	verifyField(t, eyeColor, performer.EyeColor, "EyeColor")
 }
 
+func TestAliasListXPath(t *testing.T) {
+	const testDoc = `
+	<html>
+	<h1>Jane Doe</h1>
+	<ul>
+		<li>Jane Smith</li>
+		<li>JD</li>
+	</ul>
+	</html>
+	`
+
+	reader := strings.NewReader(testDoc)
+	doc, err := htmlquery.Parse(reader)
+
+	if err != nil {
+		t.Errorf("Error loading document: %s", err.Error())
+		return
+	}
+
+	xpathConfig := make(mappedConfig)
+	xpathConfig["Name"] = makeSimpleAttrConfig("//h1")
+	xpathConfig["Aliases"] = makeSimpleAttrConfig("//li")
+
+	scraper := mappedScraper{
+		Performer: &mappedPerformerScraperConfig{
+			mappedConfig: xpathConfig,
+		},
+	}
+
+	q := &xpathQuery{
+		doc: doc,
+	}
+
+	performer, err := scraper.scrapePerformer(context.Background(), q)
+
+	if err != nil {
+		t.Errorf("Error scraping performer: %s", err.Error())
+		return
+	}
+
+	verifyField(t, "Jane Doe", performer.Name, "Name")
+	verifyField(t, "Jane Smith, JD", performer.Aliases, "Aliases")
+
+	// single value selector should behave as before
+	xpathConfig["Aliases"] = makeSimpleAttrConfig("//li[1]")
+
+	performer, err = scraper.scrapePerformer(context.Background(), q)
+
+	if err != nil {
+		t.Errorf("Error scraping performer: %s", err.Error())
+		return
+	}
+
+	verifyField(t, "Jane Smith", performer.Aliases, "Aliases")
+}
+
 const sceneHTML = `
 <!DOCTYPE html>
 
@@ -590,12 +646,12 @@ func makeSceneXPathConfig() mappedScraper {
 	studioConfig := make(mappedConfig)
 	studioConfig["Name"] = makeSimpleAttrConfig(`$studioElem`)
 	studioConfig["URL"] = makeSimpleAttrConfig(`$studioElem/@href`)
-	config.Studio = studioConfig
+	config.Studio.mappedConfig = studioConfig
 
 	const sep = " "
 	moviesNameConfig := mappedScraperAttrConfig{
 		Selector: `//i[@class="isMe tooltipTrig"]/@data-title`,
-		Split:    sep,
+		Split:    mappedSplitConfig{sep},
 	}
 	moviesConfig := make(mappedConfig)
 	moviesConfig["Name"] = moviesNameConfig
@@ -796,7 +852,7 @@ xPathScrapers:
 	assert.Equal(t, "//tags", sceneConfig.Tags["Name"].Selector)
 	assert.Equal(t, "//movies", sceneConfig.Movies["Name"].Selector)
 	assert.Equal(t, "//performers", sceneConfig.Performers.mappedConfig["Name"].Selector)
-	assert.Equal(t, "//studio", sceneConfig.Studio["Name"].Selector)
+	assert.Equal(t, "//studio", sceneConfig.Studio.mappedConfig["Name"].Selector)
 
 	postProcess := sceneConfig.mappedConfig["Title"].postProcessActions
 	parseDate := postProcess[0].(*postProcessParseDate)
@@ -823,10 +879,13 @@ func TestLoadInvalidXPath(t *testing.T) {
 	config.process(context.Background(), q, nil, nil)
 }
 
-type mockGlobalConfig struct{}
+type mockGlobalConfig struct {
+	allowLocalFiles bool
+	userAgent       string
+}
 
-func (mockGlobalConfig) GetScraperUserAgent() string {
-	return ""
+func (c mockGlobalConfig) GetScraperUserAgent() string {
+	return c.userAgent
 }
 
 func (mockGlobalConfig) GetScrapersPath() string {
@@ -853,6 +912,18 @@ func (mockGlobalConfig) GetProxy() string {
 	return ""
 }
 
+func (c mockGlobalConfig) GetScraperAllowLocalFiles() bool {
+	return c.allowLocalFiles
+}
+
+func (mockGlobalConfig) GetScraperMaxRedirects() int {
+	return 0
+}
+
+func (mockGlobalConfig) GetScraperMaxIdleConnsPerHost() int {
+	return 0
+}
+
 func TestSubScrape(t *testing.T) {
 	retHTML := `
 	<div>
@@ -919,3 +990,1849 @@ xPathScrapers:
 
 	verifyField(t, "The name", performer.Name, "Name")
 }
+
+func TestScrapeMultipleScenesByURL(t *testing.T) {
+	const listingHTML = `
+	<html>
+	<body>
+		<div class="scene">
+			<a class="title" href="/scene/1">Scene One</a>
+			<span class="date">2021-01-01</span>
+		</div>
+		<div class="scene">
+			<a class="title" href="/scene/2">Scene Two</a>
+			<span class="date">2021-01-02</span>
+		</div>
+		<div class="scene">
+			<a class="title" href="/scene/3">Scene Three</a>
+			<span class="date">2021-01-03</span>
+		</div>
+	</body>
+	</html>
+	`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, listingHTML)
+	}))
+	defer ts.Close()
+
+	yamlStr := `name: Test
+sceneByURL:
+  - action: scrapeXPath
+    url:
+      - ` + ts.URL + `
+    scraper: sceneScraper
+    multiple: true
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Title: //div[@class="scene"]/a[@class="title"]
+      URL: //div[@class="scene"]/a[@class="title"]/@href
+      Date: //div[@class="scene"]/span[@class="date"]
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	client := &http.Client{}
+	ctx := context.Background()
+	s := scraperFromDefinition(*c, mockGlobalConfig{})
+
+	content, err := s.viaURLMulti(ctx, client, ts.URL+"/scenes", ScrapeContentTypeScene)
+	if err != nil {
+		t.Fatalf("Error scraping scenes: %s", err.Error())
+	}
+
+	expectedTitles := []string{"Scene One", "Scene Two", "Scene Three"}
+	if !assert.Len(t, content, len(expectedTitles)) {
+		return
+	}
+
+	for i, cc := range content {
+		scene, ok := cc.(*models.ScrapedScene)
+		if !ok {
+			t.Fatalf("couldn't convert scraped content into a scene")
+		}
+
+		verifyField(t, expectedTitles[i], scene.Title, "Title")
+		verifyField(t, fmt.Sprintf("/scene/%d", i+1), scene.URL, "URL")
+		verifyField(t, fmt.Sprintf("2021-01-0%d", i+1), scene.Date, "Date")
+	}
+
+	// single result scrape returns the first scene
+	single, err := s.viaURL(ctx, client, ts.URL+"/scenes", ScrapeContentTypeScene)
+	if err != nil {
+		t.Fatalf("Error scraping scene: %s", err.Error())
+	}
+
+	scene, ok := single.(*models.ScrapedScene)
+	if !ok {
+		t.Fatalf("couldn't convert scraped content into a scene")
+	}
+	verifyField(t, "Scene One", scene.Title, "Title")
+}
+
+func TestScrapeMultipleByURLInvalid(t *testing.T) {
+	for _, key := range []string{"performerByURL", "galleryByURL", "imageByURL", "movieByURL", "groupByURL"} {
+		t.Run(key, func(t *testing.T) {
+			yamlStr := `name: Test
+` + key + `:
+  - action: scrapeXPath
+    url:
+      - example.com
+    scraper: s
+    multiple: true
+`
+
+			_, err := loadConfigFromYAML("test", strings.NewReader(yamlStr))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestJsonPostProcessXPath(t *testing.T) {
+	const jsonLDHTML = `
+	<html>
+	<head>
+		<script type="application/ld+json">
+			{
+				"@type": "VideoObject",
+				"name": "Test Video",
+				"uploadDate": "2019-10-13T00:33:51+00:00",
+				"actor": [
+					{ "name": "Alex D" },
+					{ "name": "Mia Malkova" }
+				]
+			}
+		</script>
+		<script type="application/javascript">not json</script>
+	</head>
+	</html>
+	`
+
+	const yamlStr = `name: Test
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Title:
+        selector: //script[@type="application/ld+json"]
+        postProcess:
+          - json: name
+      Date:
+        selector: //script[@type="application/ld+json"]
+        postProcess:
+          - json: uploadDate
+          - parseDate: 2006-01-02T15:04:05-07:00
+      Details:
+        selector: //script[@type="application/ld+json"]
+        postProcess:
+          - json: actor.#.name
+      Director:
+        selector: //script[@type="application/ld+json"]
+        postProcess:
+          - json: director
+      Code:
+        selector: //script[@type="application/javascript"]
+        postProcess:
+          - json: name
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	doc, err := htmlquery.Parse(strings.NewReader(jsonLDHTML))
+	if err != nil {
+		t.Fatalf("Error loading document: %s", err.Error())
+	}
+
+	q := &xpathQuery{
+		doc: doc,
+	}
+
+	scraper := c.XPathScrapers["sceneScraper"]
+	scene, err := scraper.scrapeScene(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping scene: %s", err.Error())
+	}
+
+	verifyField(t, "Test Video", scene.Title, "Title")
+	verifyField(t, "2019-10-13", scene.Date, "Date")
+	verifyField(t, "Alex D, Mia Malkova", scene.Details, "Details")
+
+	// missing selector and invalid json are treated as empty
+	assert.Nil(t, scene.Director)
+	assert.Nil(t, scene.Code)
+}
+
+func TestWhenGuardXPath(t *testing.T) {
+	const testDoc = `
+	<html>
+	<div class="new-layout">
+		<h1>New Title</h1>
+	</div>
+	<span class="details">Details</span>
+	</html>
+	`
+
+	const yamlStr = `name: Test
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Title:
+        selector: //div[@class="new-layout"]/h1
+        when: //div[@class="new-layout"]
+      Code:
+        fixed: guarded
+        when: //div[@class="new-layout"]
+      Details:
+        selector: //span[@class="details"]
+        when: //div[@class="old-layout"]
+      Director:
+        fixed: guarded
+        when: //div[@class="old-layout"]
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	doc, err := htmlquery.Parse(strings.NewReader(testDoc))
+	if err != nil {
+		t.Fatalf("Error loading document: %s", err.Error())
+	}
+
+	q := &xpathQuery{
+		doc: doc,
+	}
+
+	scraper := c.XPathScrapers["sceneScraper"]
+	scene, err := scraper.scrapeScene(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping scene: %s", err.Error())
+	}
+
+	// guard passes
+	verifyField(t, "New Title", scene.Title, "Title")
+	verifyField(t, "guarded", scene.Code, "Code")
+
+	// guard fails - keys are omitted
+	assert.Nil(t, scene.Details)
+	assert.Nil(t, scene.Director)
+}
+
+func TestWhenGuardXPathScalar(t *testing.T) {
+	const testDoc = `
+	<html>
+	<div class="new-layout">
+		<h1>New Title</h1>
+	</div>
+	</html>
+	`
+
+	const yamlStr = `name: Test
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Title:
+        selector: //h1
+        when: boolean(//div[@class="new-layout"])
+      Code:
+        fixed: guarded
+        when: count(//div[@class="new-layout"])
+      Details:
+        fixed: guarded
+        when: boolean(//div[@class="old-layout"])
+      Director:
+        fixed: guarded
+        when: count(//div[@class="old-layout"])
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	doc, err := htmlquery.Parse(strings.NewReader(testDoc))
+	if err != nil {
+		t.Fatalf("Error loading document: %s", err.Error())
+	}
+
+	q := &xpathQuery{
+		doc: doc,
+	}
+
+	scraper := c.XPathScrapers["sceneScraper"]
+	scene, err := scraper.scrapeScene(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping scene: %s", err.Error())
+	}
+
+	// true and non-zero results pass
+	verifyField(t, "New Title", scene.Title, "Title")
+	verifyField(t, "guarded", scene.Code, "Code")
+
+	// false and zero results fail
+	assert.Nil(t, scene.Details)
+	assert.Nil(t, scene.Director)
+}
+
+func TestCoalesceXPath(t *testing.T) {
+	const testDoc = `
+	<html>
+	<div class="description">
+		<p></p>
+		<p>   </p>
+		<p>First</p>
+		<p>Second</p>
+	</div>
+	<span class="empty"></span>
+	</html>
+	`
+
+	const yamlStr = `name: Test
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Details:
+        selector: //div[@class="description"]/p
+        coalesce: true
+      Title:
+        selector: //div[@class="description"]/p
+        concat: "|"
+        coalesce: true
+      Code:
+        selector: //span[@class="empty"]
+        coalesce: true
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	doc, err := htmlquery.Parse(strings.NewReader(testDoc))
+	if err != nil {
+		t.Fatalf("Error loading document: %s", err.Error())
+	}
+
+	q := &xpathQuery{
+		doc: doc,
+	}
+
+	scraper := c.XPathScrapers["sceneScraper"]
+	scene, err := scraper.scrapeScene(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping scene: %s", err.Error())
+	}
+
+	verifyField(t, "First", scene.Details, "Details")
+	verifyField(t, "First", scene.Title, "Title")
+	assert.Nil(t, scene.Code)
+}
+
+func TestSceneStudiosXPath(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Title: //h1
+      Studio:
+        Name: //span[@class="studio"]
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	tests := []struct {
+		name        string
+		doc         string
+		wantStudio  string
+		wantStudios []string
+	}{
+		{
+			"single studio",
+			`<html><h1>Title</h1><span class="studio">Studio A</span></html>`,
+			"Studio A",
+			nil,
+		},
+		{
+			"multiple studios",
+			`<html><h1>Title</h1><span class="studio">Studio A</span><span class="studio">Studio B</span></html>`,
+			"Studio A",
+			[]string{"Studio A", "Studio B"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := htmlquery.Parse(strings.NewReader(tt.doc))
+			if err != nil {
+				t.Fatalf("Error loading document: %s", err.Error())
+			}
+
+			q := &xpathQuery{
+				doc: doc,
+			}
+
+			scraper := c.XPathScrapers["sceneScraper"]
+			scene, err := scraper.scrapeScene(context.Background(), q)
+			if err != nil {
+				t.Fatalf("Error scraping scene: %s", err.Error())
+			}
+
+			if assert.NotNil(t, scene.Studio) {
+				assert.Equal(t, tt.wantStudio, scene.Studio.Name)
+			}
+
+			var studios []string
+			for _, s := range scene.Studios {
+				studios = append(studios, s.Name)
+			}
+			assert.Equal(t, tt.wantStudios, studios)
+		})
+	}
+}
+
+func TestCustomFieldsXPath(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Title: //h1
+      CustomFields:
+        resolution: //span[@class="resolution"]
+        site_id:
+          selector: //span[@class="id"]
+          postProcess:
+            - replace:
+                - regex: ^ID-
+                  with:
+        missing: //span[@class="missing"]
+  performerScraper:
+    performer:
+      Name: //h1
+      CustomFields:
+        eye_shape: //span[@class="eyes"]
+        hometown: //span[@class="hometown"]
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	const html = `<html>
+<h1>Title</h1>
+<span class="resolution">1080p</span>
+<span class="id">ID-1234</span>
+<span class="eyes">Almond</span>
+<span class="hometown">Springfield</span>
+</html>`
+
+	doc, err := htmlquery.Parse(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("Error loading document: %s", err.Error())
+	}
+
+	q := &xpathQuery{
+		doc: doc,
+	}
+
+	scene, err := c.XPathScrapers["sceneScraper"].scrapeScene(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping scene: %s", err.Error())
+	}
+
+	// fields without a result are omitted
+	assert.Equal(t, map[string]interface{}{
+		"resolution": "1080p",
+		"site_id":    "1234",
+	}, scene.CustomFields)
+
+	performer, err := c.XPathScrapers["performerScraper"].scrapePerformer(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping performer: %s", err.Error())
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"eye_shape": "Almond",
+		"hometown":  "Springfield",
+	}, performer.CustomFields)
+}
+
+func TestJoinCustomFieldXPath(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Title: //h1
+      CustomFields:
+        play_history:
+          selector: //ul[@class="plays"]/li/time/@datetime
+          postProcess:
+            - parseDate: 2006-01-02T15:04:05Z07:00
+          join: ","
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	const html = `<html>
+<h1>Title</h1>
+<ul class="plays">
+	<li><time datetime="2024-01-02T10:00:00Z">Jan 2</time></li>
+	<li><time datetime="2024-02-03T23:30:00+01:00">Feb 3</time></li>
+	<li><time datetime="2024-01-02T18:00:00Z">Jan 2</time></li>
+	<li><time datetime="2024-03-04T00:00:00Z">Mar 4</time></li>
+</ul>
+</html>`
+
+	doc, err := htmlquery.Parse(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("Error loading document: %s", err.Error())
+	}
+
+	q := &xpathQuery{
+		doc: doc,
+	}
+
+	scene, err := c.XPathScrapers["sceneScraper"].scrapeScene(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping scene: %s", err.Error())
+	}
+
+	// each value is parsed before joining, and duplicates are removed
+	assert.Equal(t, map[string]interface{}{
+		"play_history": "2024-01-02,2024-02-03,2024-03-04",
+	}, scene.CustomFields)
+}
+
+func TestJoinInvalidYAML(t *testing.T) {
+	for _, yamlStr := range []string{
+		"selector: //a\njoin: \",\"\nconcat: \",\"",
+		"selector: //a\njoin: \",\"\nsplit: \",\"",
+	} {
+		var c mappedScraperAttrConfig
+		assert.Error(t, yaml.Unmarshal([]byte(yamlStr), &c), yamlStr)
+	}
+}
+
+func TestColumnsXPath(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  performerScraper:
+    performer:
+      Info:
+        selector: //td[@class="info"]
+        split: "|"
+        columns:
+          - Name
+          - Birthdate
+          - Country
+      Gender:
+        selector: //td[@class="bio"]
+        split: ","
+        columns:
+          - ""
+          - Gender
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	const html = `<html><table><tr>
+<td class="info">Jane Doe | 1990-01-01 | USA</td>
+<td class="bio">Actress, Female</td>
+</tr></table></html>`
+
+	doc, err := htmlquery.Parse(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("Error loading document: %s", err.Error())
+	}
+
+	q := &xpathQuery{
+		doc: doc,
+	}
+
+	performer, err := c.XPathScrapers["performerScraper"].scrapePerformer(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping performer: %s", err.Error())
+	}
+
+	if assert.NotNil(t, performer) {
+		assert.Equal(t, "Jane Doe", *performer.Name)
+		assert.Equal(t, "1990-01-01", *performer.Birthdate)
+		assert.Equal(t, "USA", *performer.Country)
+		assert.Equal(t, "Female", *performer.Gender)
+	}
+}
+
+func Test_mappedScraperAttrConfig_splitColumns(t *testing.T) {
+	c := mappedScraperAttrConfig{
+		Split:   mappedSplitConfig{"|"},
+		Columns: []string{"A", "", "C"},
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		want  map[string]string
+	}{
+		{"all columns", "a | b | c", map[string]string{"A": "a", "C": "c"}},
+		{"remainder in last column", "a|b|c|d", map[string]string{"A": "a", "C": "c|d"}},
+		{"fewer parts", "a", map[string]string{"A": "a"}},
+		{"empty part", " | b | ", map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, c.splitColumns(tt.value))
+		})
+	}
+}
+
+func TestMultipleSplitXPath(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Title: //h1
+      Tags:
+        Name:
+          selector: //div[@class="tags"]
+          split:
+            - ", "
+            - " / "
+            - " | "
+            - "/"
+      Performers:
+        Name:
+          selector: //div[@class="performers"]
+          split: ", "
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	const html = `<html>
+<h1>Title</h1>
+<div class="tags">Tag A, Tag B / Tag C | Tag D/Tag E, , Tag F</div>
+<div class="performers">Performer A, Performer B</div>
+</html>`
+
+	doc, err := htmlquery.Parse(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("Error loading document: %s", err.Error())
+	}
+
+	q := &xpathQuery{
+		doc: doc,
+	}
+
+	scene, err := c.XPathScrapers["sceneScraper"].scrapeScene(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping scene: %s", err.Error())
+	}
+
+	if assert.NotNil(t, scene) {
+		verifyTags(t, []string{"Tag A", "Tag B", "Tag C", "Tag D", "Tag E", "Tag F"}, scene.Tags)
+		if assert.Len(t, scene.Performers, 2) {
+			assert.Equal(t, "Performer A", *scene.Performers[0].Name)
+			assert.Equal(t, "Performer B", *scene.Performers[1].Name)
+		}
+	}
+}
+
+func Test_mappedSplitConfig_UnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want mappedSplitConfig
+	}{
+		{"single", `split: ","`, mappedSplitConfig{","}},
+		{"list", `split: [",", "/"]`, mappedSplitConfig{",", "/"}},
+		{"empty dropped", `split: ["", "/"]`, mappedSplitConfig{"/"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got struct {
+				Split mappedSplitConfig `yaml:"split"`
+			}
+			if assert.NoError(t, yaml.Unmarshal([]byte(tt.yaml), &got)) {
+				assert.Equal(t, tt.want, got.Split)
+			}
+		})
+	}
+}
+
+func TestColumnsRequiresSplit(t *testing.T) {
+	const yamlStr = `selector: //td
+columns:
+  - Name
+`
+
+	var c mappedScraperAttrConfig
+	assert.Error(t, yaml.Unmarshal([]byte(yamlStr), &c))
+}
+
+func TestSceneMarkersXPath(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Title: //h1
+      Markers:
+        Title: //li[@class="chapter"]/span[@class="title"]
+        Seconds: //li[@class="chapter"]/span[@class="time"]
+        Tag: //li[@class="chapter"]/span[@class="tag"]
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	const html = `<html>
+<h1>Title</h1>
+<ul>
+	<li class="chapter"><span class="title">Intro</span><span class="time">0:30</span><span class="tag">Opening</span></li>
+	<li class="chapter"><span class="title">Finale</span><span class="time">1:02:03</span><span class="tag">Ending</span></li>
+</ul>
+</html>`
+
+	doc, err := htmlquery.Parse(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("Error loading document: %s", err.Error())
+	}
+
+	q := &xpathQuery{
+		doc: doc,
+	}
+
+	scene, err := c.XPathScrapers["sceneScraper"].scrapeScene(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping scene: %s", err.Error())
+	}
+
+	assert.Equal(t, []*models.ScrapedSceneMarker{
+		{Title: "Intro", Seconds: 30, Tag: &models.ScrapedTag{Name: "Opening"}},
+		{Title: "Finale", Seconds: 3723, Tag: &models.ScrapedTag{Name: "Ending"}},
+	}, scene.Markers)
+}
+
+func Test_parseDuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{"45", 45, false},
+		{"12.5", 12.5, false},
+		{"02:03", 123, false},
+		{" 1:02:03 ", 3723, false},
+		{"", 0, true},
+		{"1:2:3:4", 0, true},
+		{"abc", 0, true},
+		{"-5", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseDuration(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseDuration() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSubstitutionsXPath(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  performerScraper:
+    substitutions:
+      U.S.A.: USA
+      N/A: ""
+    performer:
+      Name: //h1
+      Country: //span[@class="country"]
+      Birthdate: //span[@class="birthdate"]
+      Tags:
+        Name: //span[@class="tag"]
+      URLs:
+        selector: //span[@class="url"]
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	const html = `<html>
+<h1>U.S.A.</h1>
+<span class="country">U.S.A.</span>
+<span class="birthdate">N/A</span>
+<span class="tag">U.S.A.</span>
+<span class="tag">Other</span>
+<span class="url">U.S.A.</span>
+</html>`
+
+	doc, err := htmlquery.Parse(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("Error loading document: %s", err.Error())
+	}
+
+	q := &xpathQuery{
+		doc: doc,
+	}
+
+	performer, err := c.XPathScrapers["performerScraper"].scrapePerformer(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping performer: %s", err.Error())
+	}
+
+	if !assert.NotNil(t, performer) {
+		return
+	}
+
+	verifyField(t, "USA", performer.Name, "Name")
+	verifyField(t, "USA", performer.Country, "Country")
+	verifyField(t, "", performer.Birthdate, "Birthdate")
+	assert.Equal(t, []string{"USA"}, performer.URLs)
+
+	var tags []string
+	for _, tag := range performer.Tags {
+		tags = append(tags, tag.Name)
+	}
+	assert.Equal(t, []string{"USA", "Other"}, tags)
+}
+
+func TestXPathScalarFunctions(t *testing.T) {
+	const html = `<html>
+<h1>  The   Title  </h1>
+<div class="gallery"><img src="1.jpg"/><img src="2.jpg"/><img src="3.jpg"/></div>
+</html>`
+
+	doc, err := htmlquery.Parse(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("Error loading document: %s", err.Error())
+	}
+
+	q := &xpathQuery{
+		doc: doc,
+	}
+
+	tests := []struct {
+		selector string
+		want     []string
+	}{
+		{"count(//div[@class='gallery']/img)", []string{"3"}},
+		{"count(//img) div 2", []string{"1.5"}},
+		{"normalize-space(//h1)", []string{"The Title"}},
+		{"concat(//img[1]/@src, ',', //img[2]/@src)", []string{"1.jpg,2.jpg"}},
+		{"boolean(//video)", []string{"false"}},
+		{"string(//video)", nil},
+		{"//img/@src", []string{"1.jpg", "2.jpg", "3.jpg"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			got, err := q.runQuery(tt.selector)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+
+	_, err = q.runQuery("count(//img")
+	assert.Error(t, err)
+}
+
+func TestFromFieldXPath(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Title: //h1
+      Code:
+        selector: //h1
+        postProcess:
+          - replace:
+              - regex: ^.*\[(\w+)\]$
+                with: $1
+      URL:
+        postProcess:
+          - fromField: Code
+          - replace:
+              - regex: ^
+                with: https://example.com/scene/
+      Details:
+        selector: //p
+        postProcess:
+          - fromField: Missing
+  scenesScraper:
+    scene:
+      Title: //li/a
+      Code:
+        selector: //li/a/@href
+        postProcess:
+          - replace:
+              - regex: ^/scene/
+                with:
+      Director:
+        postProcess:
+          - fromField: Code
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	const html = `<html>
+<h1>Title [ABC123]</h1>
+<p>Details</p>
+<ul>
+	<li><a href="/scene/1">First</a></li>
+	<li><a href="/scene/2">Second</a></li>
+</ul>
+</html>`
+
+	doc, err := htmlquery.Parse(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("Error loading document: %s", err.Error())
+	}
+
+	q := &xpathQuery{
+		doc: doc,
+	}
+
+	scene, err := c.XPathScrapers["sceneScraper"].scrapeScene(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping scene: %s", err.Error())
+	}
+
+	assert.Equal(t, "ABC123", *scene.Code)
+	assert.Equal(t, "https://example.com/scene/ABC123", *scene.URL)
+	// referencing a field that is not set results in an empty value
+	assert.Nil(t, scene.Details)
+
+	scenes, err := c.XPathScrapers["scenesScraper"].scrapeScenes(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping scenes: %s", err.Error())
+	}
+
+	if assert.Len(t, scenes, 2) {
+		// each value references the field of the same result
+		assert.Equal(t, "1", *scenes[0].Director)
+		assert.Equal(t, "2", *scenes[1].Director)
+	}
+}
+
+func TestPerformerLinksXPath(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  performerScraper:
+    performer:
+      Name: //h1
+      URLs: //link[@rel="canonical"]/@href
+      Links:
+        Label: //ul[@class="links"]/li/a
+        URL: //ul[@class="links"]/li/a/@href
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	const html = `<html>
+<head><link rel="canonical" href="https://example.com/performer/1"></head>
+<h1>Name</h1>
+<ul class="links">
+	<li><a href="https://twitter.com/name">Twitter</a></li>
+	<li><a href="https://www.instagram.com/name">Instagram</a></li>
+	<li><a href="https://onlyfans.com/name">OnlyFans</a></li>
+	<li><a href="https://x.com/other">X</a></li>
+	<li><a href="https://example.com/performer/1">Homepage</a></li>
+</ul>
+</html>`
+
+	doc, err := htmlquery.Parse(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("Error loading document: %s", err.Error())
+	}
+
+	q := &xpathQuery{
+		doc: doc,
+	}
+
+	performer, err := c.XPathScrapers["performerScraper"].scrapePerformer(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping performer: %s", err.Error())
+	}
+
+	// the first link with a known label sets the field
+	if assert.NotNil(t, performer.Twitter) {
+		assert.Equal(t, "https://twitter.com/name", *performer.Twitter)
+	}
+	if assert.NotNil(t, performer.Instagram) {
+		assert.Equal(t, "https://www.instagram.com/name", *performer.Instagram)
+	}
+
+	// all links are added to the URLs, without duplicates
+	assert.Equal(t, []string{
+		"https://example.com/performer/1",
+		"https://twitter.com/name",
+		"https://www.instagram.com/name",
+		"https://onlyfans.com/name",
+		"https://x.com/other",
+	}, performer.URLs)
+}
+
+func TestGalleryImagesXPath(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  galleryScraper:
+    gallery:
+      Title: //h1
+      Images:
+        selector: //div[@class="gallery"]//img/@src
+        postProcess:
+          - replace:
+              - regex: ^/
+                with: https://example.com/
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	const html = `<html>
+<h1>Gallery Title</h1>
+<div class="gallery">
+	<a href="/image/1"><img src="/images/1.jpg"></a>
+	<a href="/image/2"><img src="/images/2.jpg"></a>
+	<a href="/image/3"><img src="https://cdn.example.com/3.jpg"></a>
+</div>
+</html>`
+
+	doc, err := htmlquery.Parse(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("Error loading document: %s", err.Error())
+	}
+
+	q := &xpathQuery{
+		doc: doc,
+	}
+
+	gallery, err := c.XPathScrapers["galleryScraper"].scrapeGallery(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping gallery: %s", err.Error())
+	}
+
+	if assert.NotNil(t, gallery) {
+		assert.Equal(t, "Gallery Title", *gallery.Title)
+		assert.Equal(t, []string{
+			"https://example.com/images/1.jpg",
+			"https://example.com/images/2.jpg",
+			"https://cdn.example.com/3.jpg",
+		}, gallery.Images)
+	}
+}
+
+func TestPerformerTattoosXPath(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  performerScraper:
+    performer:
+      Name: //h1
+      Tattoos: //ul[@class="tattoos"]/li
+      Piercings: //ul[@class="piercings"]/li
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	const html = `<html>
+<h1>Jane Doe</h1>
+<ul class="tattoos">
+	<li>Rose, left arm</li>
+	<li>Star, ankle</li>
+</ul>
+<ul class="piercings">
+	<li>Navel</li>
+</ul>
+</html>`
+
+	doc, err := htmlquery.Parse(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("Error loading document: %s", err.Error())
+	}
+
+	q := &xpathQuery{
+		doc: doc,
+	}
+
+	performer, err := c.XPathScrapers["performerScraper"].scrapePerformer(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping performer: %s", err.Error())
+	}
+
+	if assert.NotNil(t, performer) {
+		assert.Equal(t, "Jane Doe", *performer.Name)
+		assert.Equal(t, "Rose, left arm; Star, ankle", *performer.Tattoos)
+		assert.Equal(t, "Navel", *performer.Piercings)
+	}
+}
+
+func TestExtractFromURLXPath(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Title: //h1
+      Code:
+        postProcess:
+          - extractFromURL: /videos/([A-Z]+-\d+)/
+      Director:
+        postProcess:
+          - extractFromURL: /videos/[A-Z]+-\d+/[^/]+$
+          - replace:
+              - regex: ^/videos/
+                with:
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	doc, err := htmlquery.Parse(strings.NewReader(`<html><h1>Title</h1></html>`))
+	if err != nil {
+		t.Fatalf("Error loading document: %s", err.Error())
+	}
+
+	q := &xpathQuery{
+		doc: doc,
+		url: "https://example.com/videos/ABC-123/some-title",
+	}
+
+	scene, err := c.XPathScrapers["sceneScraper"].scrapeScene(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping scene: %s", err.Error())
+	}
+
+	if assert.NotNil(t, scene) {
+		assert.Equal(t, "ABC-123", *scene.Code)
+		assert.Equal(t, "ABC-123/some-title", *scene.Director)
+	}
+
+	// the value is not set if the url does not match
+	q.url = "https://example.com/search?q=title"
+	scene, err = c.XPathScrapers["sceneScraper"].scrapeScene(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping scene: %s", err.Error())
+	}
+
+	if assert.NotNil(t, scene) {
+		assert.Nil(t, scene.Code)
+		assert.Nil(t, scene.Director)
+	}
+}
+
+func TestSceneGroupIndexXPath(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Title: //h1
+      Groups:
+        Name: //div[@class="series"]/a
+        SceneIndex:
+          selector: //div[@class="series"]/span
+          postProcess:
+            - replace:
+                - regex: ^Episode\s+
+                  with:
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	const html = `<html>
+<h1>Scene Title</h1>
+<div class="series"><a href="/series/1">Series Name</a><span>Episode 4</span></div>
+</html>`
+
+	doc, err := htmlquery.Parse(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("Error loading document: %s", err.Error())
+	}
+
+	q := &xpathQuery{
+		doc: doc,
+	}
+
+	scene, err := c.XPathScrapers["sceneScraper"].scrapeScene(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping scene: %s", err.Error())
+	}
+
+	if assert.NotNil(t, scene) && assert.Len(t, scene.Groups, 1) {
+		g := scene.Groups[0]
+		assert.Equal(t, "Series Name", *g.Name)
+		if assert.NotNil(t, g.SceneIndex) {
+			assert.Equal(t, 4, *g.SceneIndex)
+		}
+	}
+}
+
+func TestAlsoXPath(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  performerScraper:
+    performer:
+      Name: //h1
+      Measurements:
+        selector: //span[@class="measurements"]
+        postProcess:
+          - replace:
+              - regex: \s+
+                with:
+      CustomFields:
+        measurements:
+          selector: //span[@class="measurements"]
+          also:
+            measurements_cm:
+              postProcess:
+                - measurementsToCm: true
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	const html = `<html>
+<h1>Name</h1>
+<span class="measurements">34D - 24 - 36</span>
+</html>`
+
+	doc, err := htmlquery.Parse(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("Error loading document: %s", err.Error())
+	}
+
+	q := &xpathQuery{
+		doc: doc,
+	}
+
+	performer, err := c.XPathScrapers["performerScraper"].scrapePerformer(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping performer: %s", err.Error())
+	}
+
+	assert.Equal(t, "34D-24-36", *performer.Measurements)
+
+	// both values are produced from the same selector, with their own post-processing
+	assert.Equal(t, map[string]interface{}{
+		"measurements":    "34D - 24 - 36",
+		"measurements_cm": "86D - 61 - 91",
+	}, performer.CustomFields)
+}
+
+func TestImageGalleryDateXPath(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  imageScraper:
+    image:
+      Title: //h1
+      Date:
+        selector: //span[@class="date"]
+        postProcess:
+          - parseDate: January 2, 2006
+      URLs:
+        fixed: "{inputURL}"
+      Studio:
+        Name: //span[@class="studio"]
+        URL:
+          fixed: https://{inputHostname}/
+      Performers:
+        Name: //a[@class="performer"]
+        URLs: //a[@class="performer"]/@href
+  galleryScraper:
+    gallery:
+      Title: //h1
+      Date:
+        selector: //span[@class="date"]
+        postProcess:
+          - parseDate: January 2, 2006
+      URLs:
+        fixed: "{inputURL}"
+      Studio:
+        Name: //span[@class="studio"]
+        URL:
+          fixed: https://{inputHostname}/
+      Performers:
+        Name: //a[@class="performer"]
+        URLs: //a[@class="performer"]/@href
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	const html = `<html>
+<h1>Title</h1>
+<span class="date">March 4, 2021</span>
+<span class="studio">Studio</span>
+<a class="performer" href="https://example.com/performers/1">Performer 1</a>
+<a class="performer" href="https://example.com/performers/2">Performer 2</a>
+</html>`
+
+	doc, err := htmlquery.Parse(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("Error loading document: %s", err.Error())
+	}
+
+	const inputURL = "https://example.com/content/1"
+	q := &xpathQuery{
+		doc: doc,
+		url: inputURL,
+	}
+
+	wantStudioURL := "https://example.com/"
+	// each performer has its own URL
+	wantPerformers := [][]string{{"https://example.com/performers/1"}, {"https://example.com/performers/2"}}
+	performerURLs := func(performers []*models.ScrapedPerformer) [][]string {
+		var ret [][]string
+		for _, p := range performers {
+			ret = append(ret, p.URLs)
+		}
+		return ret
+	}
+
+	image, err := c.XPathScrapers["imageScraper"].scrapeImage(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping image: %s", err.Error())
+	}
+
+	if assert.NotNil(t, image) {
+		verifyField(t, "2021-03-04", image.Date, "Date")
+		assert.Equal(t, []string{inputURL}, image.URLs)
+		if assert.NotNil(t, image.Studio) {
+			verifyField(t, wantStudioURL, image.Studio.URL, "Studio URL")
+		}
+		assert.Equal(t, wantPerformers, performerURLs(image.Performers))
+	}
+
+	gallery, err := c.XPathScrapers["galleryScraper"].scrapeGallery(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping gallery: %s", err.Error())
+	}
+
+	if assert.NotNil(t, gallery) {
+		verifyField(t, "2021-03-04", gallery.Date, "Date")
+		assert.Equal(t, []string{inputURL}, gallery.URLs)
+		if assert.NotNil(t, gallery.Studio) {
+			verifyField(t, wantStudioURL, gallery.Studio.URL, "Studio URL")
+		}
+		assert.Equal(t, wantPerformers, performerURLs(gallery.Performers))
+	}
+}
+
+func TestExtractAllXPath(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Title: //h1
+      URLs:
+        selector: //div[@class="links"]
+        postProcess:
+          - extractAll:
+              regex: (https://\S+)
+      Tags:
+        Name:
+          selector: //div[@class="description"]
+          postProcess:
+            - extractAll:
+                regex: '#(\w+)'
+            - replace:
+                - regex: _
+                  with: " "
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	const html = `<html>
+<h1>Title</h1>
+<div class="description">A description with #tag_one, #tag_two and #tag_one again.</div>
+<div class="links">Mirrors: https://example.com/1 https://example.org/1</div>
+</html>`
+
+	doc, err := htmlquery.Parse(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("Error loading document: %s", err.Error())
+	}
+
+	q := &xpathQuery{
+		doc: doc,
+	}
+
+	scene, err := c.XPathScrapers["sceneScraper"].scrapeScene(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping scene: %s", err.Error())
+	}
+
+	if !assert.NotNil(t, scene) {
+		return
+	}
+
+	assert.Equal(t, []string{"https://example.com/1", "https://example.org/1"}, scene.URLs)
+
+	var tags []string
+	for _, tag := range scene.Tags {
+		tags = append(tags, tag.Name)
+	}
+	// duplicate matches are removed
+	assert.Equal(t, []string{"tag one", "tag two"}, tags)
+}
+
+func TestDedupeIgnoreCaseXPath(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Title: //h1
+      Tags:
+        Name:
+          selector: //span[@class="tag"]
+          dedupeIgnoreCase: true
+      Performers:
+        Name: //span[@class="performer"]
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	const html = `<html>
+<h1>Title</h1>
+<span class="tag">Action</span>
+<span class="tag">action</span>
+<span class="tag">Drama</span>
+<span class="tag">ACTION</span>
+<span class="tag">drama</span>
+<span class="performer">Jane Doe</span>
+<span class="performer">jane doe</span>
+</html>`
+
+	doc, err := htmlquery.Parse(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("Error loading document: %s", err.Error())
+	}
+
+	q := &xpathQuery{
+		doc: doc,
+	}
+
+	scene, err := c.XPathScrapers["sceneScraper"].scrapeScene(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping scene: %s", err.Error())
+	}
+
+	// case variants are collapsed, keeping the casing of the first value found
+	var tags []string
+	for _, tag := range scene.Tags {
+		tags = append(tags, tag.Name)
+	}
+	assert.Equal(t, []string{"Action", "Drama"}, tags)
+
+	// the default dedupe is case-sensitive
+	var performers []string
+	for _, p := range scene.Performers {
+		performers = append(performers, *p.Name)
+	}
+	assert.Equal(t, []string{"Jane Doe", "jane doe"}, performers)
+}
+
+func TestDedupeTagsXPath(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  dedupe:
+    dedupeTags: true
+    scene: &scene
+      Title: //h1
+      Tags:
+        Name: //span[@class="tag"] | //span[@class="category"]
+      Performers:
+        Name: //span[@class="performer"]
+        Tags:
+          Name: //span[@class="performer-tag"]
+  noDedupe:
+    scene: *scene
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	const html = `<html>
+<h1>Title</h1>
+<span class="tag">Outdoor Scene</span>
+<span class="tag">Indoors</span>
+<span class="category">outdoor  scene</span>
+<span class="category">INDOORS</span>
+<span class="performer">Performer</span>
+<span class="performer-tag">Brunette</span>
+<span class="performer-tag">brunette </span>
+</html>`
+
+	doc, err := htmlquery.Parse(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("Error loading document: %s", err.Error())
+	}
+
+	q := &xpathQuery{
+		doc: doc,
+	}
+
+	tagNames := func(tags []*models.ScrapedTag) []string {
+		var ret []string
+		for _, t := range tags {
+			ret = append(ret, t.Name)
+		}
+		return ret
+	}
+
+	scene, err := c.XPathScrapers["dedupe"].scrapeScene(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping scene: %s", err.Error())
+	}
+
+	// the first tag with each name is kept
+	assert.Equal(t, []string{"Outdoor Scene", "Indoors"}, tagNames(scene.Tags))
+	if assert.Len(t, scene.Performers, 1) {
+		assert.Equal(t, []string{"Brunette"}, tagNames(scene.Performers[0].Tags))
+	}
+
+	scene, err = c.XPathScrapers["noDedupe"].scrapeScene(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping scene: %s", err.Error())
+	}
+
+	assert.Len(t, scene.Tags, 4)
+}
+
+func TestPerformerScenesXPath(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  performerScraper:
+    common:
+      $scene: //div[@class="scene"]
+    performer:
+      Name: //h1
+      Scenes:
+        Title: $scene/a
+        URL:
+          selector: $scene/a/@href
+          postProcess:
+            - replace:
+                - regex: ^
+                  with: https://example.com
+        Date: $scene/span[@class="date"]
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	const html = `<html>
+<h1>Performer</h1>
+<div class="scene"><a href="/scenes/1">First Scene</a><span class="date">2021-01-02</span></div>
+<div class="scene"><a href="/scenes/2">Second Scene</a><span class="date">2022-03-04</span></div>
+<div class="scene"><a href="/scenes/3">Third Scene</a><span class="date">2023-05-06</span></div>
+</html>`
+
+	doc, err := htmlquery.Parse(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("Error loading document: %s", err.Error())
+	}
+
+	q := &xpathQuery{
+		doc: doc,
+	}
+
+	performer, err := c.XPathScrapers["performerScraper"].scrapePerformer(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping performer: %s", err.Error())
+	}
+
+	verifyField(t, "Performer", performer.Name, "Name")
+
+	if !assert.Len(t, performer.Scenes, 3) {
+		return
+	}
+
+	for i, want := range []struct {
+		title string
+		url   string
+		date  string
+	}{
+		{"First Scene", "https://example.com/scenes/1", "2021-01-02"},
+		{"Second Scene", "https://example.com/scenes/2", "2022-03-04"},
+		{"Third Scene", "https://example.com/scenes/3", "2023-05-06"},
+	} {
+		scene := performer.Scenes[i]
+		verifyField(t, want.title, scene.Title, "Title")
+		verifyField(t, want.url, scene.URL, "URL")
+		verifyField(t, want.date, scene.Date, "Date")
+	}
+}
+
+func TestValidateXPath(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Title:
+        selector: //h1
+        validate:
+          maxLength: 20
+      Code:
+        selector: //span[@class="code"]
+        validate:
+          match: ^[A-Z]+-\d+$
+      Details:
+        selector: //div[@class="details"]
+        validate:
+          notContains:
+            - Log In
+      Director: //span[@class="director"]
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	scrape := func(html string) *models.ScrapedScene {
+		t.Helper()
+
+		doc, err := htmlquery.Parse(strings.NewReader(html))
+		if err != nil {
+			t.Fatalf("Error loading document: %s", err.Error())
+		}
+
+		q := &xpathQuery{
+			doc: doc,
+		}
+
+		scene, err := c.XPathScrapers["sceneScraper"].scrapeScene(context.Background(), q)
+		if err != nil {
+			t.Fatalf("Error scraping scene: %s", err.Error())
+		}
+
+		return scene
+	}
+
+	scene := scrape(`<html>
+<h1>Valid Title</h1>
+<span class="code">ABC-123</span>
+<div class="details">Some details.</div>
+</html>`)
+
+	verifyField(t, "Valid Title", scene.Title, "Title")
+	verifyField(t, "ABC-123", scene.Code, "Code")
+	verifyField(t, "Some details.", scene.Details, "Details")
+
+	scene = scrape(`<html>
+<h1>Home Videos Models Categories Log In</h1>
+<span class="code">Videos</span>
+<div class="details">Home | Log in | Sign up</div>
+<span class="director">Director</span>
+</html>`)
+
+	assert.Nil(t, scene.Title)
+	assert.Nil(t, scene.Code)
+	assert.Nil(t, scene.Details)
+	verifyField(t, "Director", scene.Director, "Director")
+}
+
+func TestValidateInvalidRegex(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Title:
+        selector: //h1
+        validate:
+          match: "["
+`
+
+	c := &Definition{}
+	assert.Error(t, yaml.Unmarshal([]byte(yamlStr), &c))
+}
+
+func TestAliasesXPath(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  performerScraper:
+    performer:
+      Name: //h1
+      Aliases: //div[@class="aliases"]
+  groupScraper:
+    group:
+      Name: //h1
+      Aliases: //li[@class="alias"]
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	const html = `<html>
+<h1>Name</h1>
+<div class="aliases">Alias One, Alias Two, alias one</div>
+<ul>
+  <li class="alias">Alias One, Alias Two</li>
+  <li class="alias">Alias Three</li>
+</ul>
+</html>`
+
+	doc, err := htmlquery.Parse(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("Error loading document: %s", err.Error())
+	}
+
+	q := &xpathQuery{
+		doc: doc,
+	}
+
+	performer, err := c.XPathScrapers["performerScraper"].scrapePerformer(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping performer: %s", err.Error())
+	}
+
+	verifyField(t, "Alias One, Alias Two", performer.Aliases, "Aliases")
+
+	group, err := c.XPathScrapers["groupScraper"].scrapeGroup(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping group: %s", err.Error())
+	}
+
+	verifyField(t, "Alias One, Alias Two, Alias Three", group.Aliases, "Aliases")
+}
+
+func TestStudioParentXPath(t *testing.T) {
+	const yamlStr = `name: Test
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Title: //h1
+      Studio:
+        Name: //div[@class="studio"]/a
+        URL: //div[@class="studio"]/a/@href
+        Parent:
+          Name: //div[@class="network"]/a
+          URL: //div[@class="network"]/a/@href
+  galleryScraper:
+    gallery:
+      Title: //h1
+      Studio:
+        Name: //div[@class="studio"]/a
+  noParentScraper:
+    scene:
+      Title: //h1
+      Studio:
+        Name: //div[@class="studio"]/a
+        Parent:
+          Name: //div[@class="missing"]
+`
+
+	c := &Definition{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	const html = `<html>
+<h1>Title</h1>
+<div class="studio"><a href="https://example.com/studio">Studio</a></div>
+<div class="network"><a href="https://example.com/network">Network</a></div>
+</html>`
+
+	doc, err := htmlquery.Parse(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("Error loading document: %s", err.Error())
+	}
+
+	q := &xpathQuery{
+		doc: doc,
+	}
+
+	scene, err := c.XPathScrapers["sceneScraper"].scrapeScene(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping scene: %s", err.Error())
+	}
+
+	if assert.NotNil(t, scene.Studio) {
+		assert.Equal(t, "Studio", scene.Studio.Name)
+		verifyField(t, "https://example.com/studio", scene.Studio.URL, "Studio.URL")
+
+		if assert.NotNil(t, scene.Studio.Parent) {
+			assert.Equal(t, "Network", scene.Studio.Parent.Name)
+			verifyField(t, "https://example.com/network", scene.Studio.Parent.URL, "Studio.Parent.URL")
+			assert.Nil(t, scene.Studio.Parent.Parent)
+		}
+	}
+
+	gallery, err := c.XPathScrapers["galleryScraper"].scrapeGallery(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping gallery: %s", err.Error())
+	}
+
+	if assert.NotNil(t, gallery.Studio) {
+		assert.Equal(t, "Studio", gallery.Studio.Name)
+		assert.Nil(t, gallery.Studio.Parent)
+	}
+
+	scene, err = c.XPathScrapers["noParentScraper"].scrapeScene(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping scene: %s", err.Error())
+	}
+
+	if assert.NotNil(t, scene.Studio) {
+		assert.Nil(t, scene.Studio.Parent)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/stashapp/stash/pkg/models"
 	"gopkg.in/guregu/null.v4"
+	"gopkg.in/guregu/null.v4/zero"
 )
 
 const (
@@ -30,14 +31,16 @@ const (
 )
 
 type basicFileRow struct {
-	ID             models.FileID   `db:"id" goqu:"skipinsert"`
-	Basename       string          `db:"basename"`
-	ZipFileID      null.Int        `db:"zip_file_id"`
-	ParentFolderID models.FolderID `db:"parent_folder_id"`
-	Size           int64           `db:"size"`
-	ModTime        Timestamp       `db:"mod_time"`
-	CreatedAt      Timestamp       `db:"created_at"`
-	UpdatedAt      Timestamp       `db:"updated_at"`
+	ID              models.FileID   `db:"id" goqu:"skipinsert"`
+	Basename        string          `db:"basename"`
+	ZipFileID       null.Int        `db:"zip_file_id"`
+	ParentFolderID  models.FolderID `db:"parent_folder_id"`
+	Size            int64           `db:"size"`
+	MimeType        zero.String     `db:"mime_type"`
+	MetadataVersion int             `db:"metadata_version"`
+	ModTime         Timestamp       `db:"mod_time"`
+	CreatedAt       Timestamp       `db:"created_at"`
+	UpdatedAt       Timestamp       `db:"updated_at"`
 }
 
 func (r *basicFileRow) fromBasicFile(o models.BaseFile) {
@@ -46,6 +49,8 @@ func (r *basicFileRow) fromBasicFile(o models.BaseFile) {
 	r.ZipFileID = nullIntFromFileIDPtr(o.ZipFileID)
 	r.ParentFolderID = o.ParentFolderID
 	r.Size = o.Size
+	r.MimeType = zero.StringFrom(o.MimeType)
+	r.MetadataVersion = o.MetadataVersion
 	r.ModTime = Timestamp{Timestamp: o.ModTime}
 	r.CreatedAt = Timestamp{Timestamp: o.CreatedAt}
 	r.UpdatedAt = Timestamp{Timestamp: o.UpdatedAt}
@@ -167,14 +172,16 @@ func (f *imageFileQueryRow) resolve() *models.ImageFile {
 }
 
 type fileQueryRow struct {
-	FileID         null.Int      `db:"file_id"`
-	Basename       null.String   `db:"basename"`
-	ZipFileID      null.Int      `db:"zip_file_id"`
-	ParentFolderID null.Int      `db:"parent_folder_id"`
-	Size           null.Int      `db:"size"`
-	ModTime        NullTimestamp `db:"mod_time"`
-	CreatedAt      NullTimestamp `db:"file_created_at"`
-	UpdatedAt      NullTimestamp `db:"file_updated_at"`
+	FileID          null.Int      `db:"file_id"`
+	Basename        null.String   `db:"basename"`
+	ZipFileID       null.Int      `db:"zip_file_id"`
+	ParentFolderID  null.Int      `db:"parent_folder_id"`
+	Size            null.Int      `db:"size"`
+	MimeType        null.String   `db:"mime_type"`
+	MetadataVersion null.Int      `db:"metadata_version"`
+	ModTime         NullTimestamp `db:"mod_time"`
+	CreatedAt       NullTimestamp `db:"file_created_at"`
+	UpdatedAt       NullTimestamp `db:"file_updated_at"`
 
 	ZipBasename   null.String `db:"zip_basename"`
 	ZipFolderPath null.String `db:"zip_folder_path"`
@@ -193,12 +200,14 @@ func (r *fileQueryRow) resolve() models.File {
 			ZipFileID: nullIntFileIDPtr(r.ZipFileID),
 			ModTime:   r.ModTime.Timestamp,
 		},
-		Path:           filepath.Join(r.FolderPath.String, r.Basename.String),
-		ParentFolderID: models.FolderID(r.ParentFolderID.Int64),
-		Basename:       r.Basename.String,
-		Size:           r.Size.Int64,
-		CreatedAt:      r.CreatedAt.Timestamp,
-		UpdatedAt:      r.UpdatedAt.Timestamp,
+		Path:            filepath.Join(r.FolderPath.String, r.Basename.String),
+		ParentFolderID:  models.FolderID(r.ParentFolderID.Int64),
+		Basename:        r.Basename.String,
+		Size:            r.Size.Int64,
+		MimeType:        r.MimeType.String,
+		MetadataVersion: int(r.MetadataVersion.Int64),
+		CreatedAt:       r.CreatedAt.Timestamp,
+		UpdatedAt:       r.UpdatedAt.Timestamp,
 	}
 
 	if basic.ZipFileID != nil && r.ZipFolderPath.Valid && r.ZipBasename.Valid {
@@ -492,6 +501,8 @@ func (qb *FileStore) selectDataset() *goqu.SelectDataset {
 		table.Col("zip_file_id"),
 		table.Col("parent_folder_id"),
 		table.Col("size"),
+		table.Col("mime_type"),
+		table.Col("metadata_version"),
 		table.Col("mod_time"),
 		table.Col("created_at").As("file_created_at"),
 		table.Col("updated_at").As("file_updated_at"),
@@ -36,6 +36,8 @@ func Test_fileFileStore_Create(t *testing.T) {
 		createdAt              = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
 		updatedAt              = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
 		size             int64 = 1234
+		mimeType               = "video/mp4"
+		metadataVersion        = 1
 
 		duration         = 1.234
 		width            = 640
@@ -60,10 +62,12 @@ func Test_fileFileStore_Create(t *testing.T) {
 					ZipFile:   makeZipFileWithID(fileIdxZip),
 					ModTime:   fileModTime,
 				},
-				Path:           getFilePath(folderIdxWithFiles, basename),
-				ParentFolderID: folderIDs[folderIdxWithFiles],
-				Basename:       basename,
-				Size:           size,
+				Path:            getFilePath(folderIdxWithFiles, basename),
+				ParentFolderID:  folderIDs[folderIdxWithFiles],
+				Basename:        basename,
+				Size:            size,
+				MimeType:        mimeType,
+				MetadataVersion: metadataVersion,
 				Fingerprints: []models.Fingerprint{
 					{
 						Type:        fingerprintType,
@@ -253,6 +257,8 @@ func Test_fileStore_Update(t *testing.T) {
 		createdAt              = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
 		updatedAt              = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
 		size             int64 = 1234
+		mimeType               = "video/mp4"
+		metadataVersion        = 1
 
 		duration         = 1.234
 		width            = 640
@@ -278,10 +284,12 @@ func Test_fileStore_Update(t *testing.T) {
 					ZipFile:   makeZipFileWithID(fileIdxZip),
 					ModTime:   fileModTime,
 				},
-				Path:           getFilePath(folderIdxWithFiles, basename),
-				ParentFolderID: folderIDs[folderIdxWithFiles],
-				Basename:       basename,
-				Size:           size,
+				Path:            getFilePath(folderIdxWithFiles, basename),
+				ParentFolderID:  folderIDs[folderIdxWithFiles],
+				Basename:        basename,
+				Size:            size,
+				MimeType:        mimeType,
+				MetadataVersion: metadataVersion,
 				Fingerprints: []models.Fingerprint{
 					{
 						Type:        fingerprintType,
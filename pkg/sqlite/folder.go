@@ -311,6 +311,30 @@ func (qb *FolderStore) FindByPath(ctx context.Context, p string, caseSensitive b
 	return ret, nil
 }
 
+// FindByPaths returns the folders with the provided paths, matched case-sensitively.
+// Paths without a folder are ignored, and the order of the returned folders is
+// not guaranteed to be the same as the order of the paths.
+func (qb *FolderStore) FindByPaths(ctx context.Context, paths []string) ([]*models.Folder, error) {
+	var ret []*models.Folder
+
+	table := qb.table()
+	if err := batchExec(paths, defaultBatchSize, func(batch []string) error {
+		q := qb.selectDataset().Prepared(true).Where(table.Col("path").In(batch))
+		folders, err := qb.getMany(ctx, q)
+		if err != nil {
+			return err
+		}
+
+		ret = append(ret, folders...)
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("getting folders by paths: %w", err)
+	}
+
+	return ret, nil
+}
+
 func (qb *FolderStore) FindByParentFolderID(ctx context.Context, parentFolderID models.FolderID) ([]*models.Folder, error) {
 	q := qb.selectDataset().Where(qb.table().Col("parent_folder_id").Eq(int(parentFolderID)))
 
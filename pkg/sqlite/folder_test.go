@@ -239,3 +239,26 @@ func Test_FolderStore_FindByPath(t *testing.T) {
 		})
 	}
 }
+
+func Test_FolderStore_FindByPaths(t *testing.T) {
+	qb := db.Folder
+
+	runWithRollbackTxn(t, "find by paths", func(t *testing.T, ctx context.Context) {
+		got, err := qb.FindByPaths(ctx, []string{
+			folderPaths[folderIdxWithFiles],
+			"invalid path",
+			folderPaths[folderIdxWithSubFolder],
+		})
+		if err != nil {
+			t.Errorf("FolderStore.FindByPaths() error = %v", err)
+			return
+		}
+
+		want := []*models.Folder{
+			makeFolderWithID(folderIdxWithFiles),
+			makeFolderWithID(folderIdxWithSubFolder),
+		}
+
+		assert.ElementsMatch(t, want, got)
+	})
+}
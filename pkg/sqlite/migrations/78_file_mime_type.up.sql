@@ -0,0 +1 @@
+ALTER TABLE `files` ADD COLUMN `mime_type` varchar(255);
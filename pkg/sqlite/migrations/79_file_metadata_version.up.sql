@@ -0,0 +1 @@
+ALTER TABLE `files` ADD COLUMN `metadata_version` integer not null default 0;
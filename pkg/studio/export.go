@@ -3,6 +3,9 @@ package studio
 import (
 	"context"
 	"fmt"
+	"io"
+
+	jsoniter "github.com/json-iterator/go"
 
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
@@ -78,3 +81,142 @@ func ToJSON(ctx context.Context, reader FinderImageStashIDGetter, studio *models
 
 	return &newStudioJSON, nil
 }
+
+// ExportFormat is the output format used by ExportAll.
+type ExportFormat string
+
+const (
+	// ExportFormatJSON writes all studios as a single JSON array.
+	ExportFormatJSON ExportFormat = "json"
+	// ExportFormatNDJSON writes one JSON studio object per line.
+	ExportFormatNDJSON ExportFormat = "ndjson"
+)
+
+// exportBatchSize is the number of studios for which custom fields are
+// fetched in a single query.
+const exportBatchSize = 1000
+
+type AllFinderImageStashIDGetter interface {
+	FinderImageStashIDGetter
+	All(ctx context.Context) ([]*models.Studio, error)
+}
+
+// ExportAll writes every studio returned by reader to w in the given format.
+// Custom fields are fetched in batches and parent studios are resolved from
+// the set of exported studios, so that each record only requires its own
+// image to be fetched individually.
+func ExportAll(ctx context.Context, reader AllFinderImageStashIDGetter, w io.Writer, format ExportFormat) error {
+	if format != ExportFormatJSON && format != ExportFormatNDJSON {
+		return fmt.Errorf("unsupported export format: %q", format)
+	}
+
+	studios, err := reader.All(ctx)
+	if err != nil {
+		return fmt.Errorf("getting studios: %w", err)
+	}
+
+	r := &exportReader{
+		FinderImageStashIDGetter: reader,
+		studios:                  make(map[int]*models.Studio, len(studios)),
+		customFields:             make(map[int]models.CustomFieldMap),
+	}
+	for _, s := range studios {
+		r.studios[s.ID] = s
+	}
+
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	if format == ExportFormatJSON {
+		if _, err := io.WriteString(w, "["); err != nil {
+			return err
+		}
+	}
+
+	for i, s := range studios {
+		if i%exportBatchSize == 0 {
+			end := min(i+exportBatchSize, len(studios))
+			if err := r.loadCustomFields(ctx, studios[i:end]); err != nil {
+				return err
+			}
+		}
+
+		studioJSON, err := ToJSON(ctx, r, s)
+		if err != nil {
+			return fmt.Errorf("exporting studio %q: %w", s.Name, err)
+		}
+
+		if format == ExportFormatJSON && i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+
+		if err := encoder.Encode(studioJSON); err != nil {
+			return fmt.Errorf("encoding studio %q: %w", s.Name, err)
+		}
+	}
+
+	if format == ExportFormatJSON {
+		if _, err := io.WriteString(w, "]\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportReader serves parent studio lookups and custom fields from data
+// loaded up front by ExportAll, falling back to the underlying reader for
+// anything not already loaded.
+type exportReader struct {
+	FinderImageStashIDGetter
+	studios      map[int]*models.Studio
+	customFields map[int]models.CustomFieldMap
+}
+
+func (r *exportReader) Find(ctx context.Context, id int) (*models.Studio, error) {
+	if s, found := r.studios[id]; found {
+		return s, nil
+	}
+
+	s, err := r.FinderImageStashIDGetter.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.studios[id] = s
+	return s, nil
+}
+
+func (r *exportReader) GetCustomFields(ctx context.Context, id int) (map[string]interface{}, error) {
+	if m, found := r.customFields[id]; found {
+		return m, nil
+	}
+
+	return r.FinderImageStashIDGetter.GetCustomFields(ctx, id)
+}
+
+func (r *exportReader) loadCustomFields(ctx context.Context, studios []*models.Studio) error {
+	ids := make([]int, len(studios))
+	for i, s := range studios {
+		ids[i] = s.ID
+	}
+
+	fields, err := r.FinderImageStashIDGetter.GetCustomFieldsBulk(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("getting studio custom fields: %w", err)
+	}
+
+	clear(r.customFields)
+	for i, id := range ids {
+		m := fields[i]
+		if m == nil {
+			m = make(models.CustomFieldMap)
+		}
+		r.customFields[id] = m
+	}
+
+	return nil
+}
@@ -1,6 +1,8 @@
 package studio
 
 import (
+	"bytes"
+	stdjson "encoding/json"
 	"errors"
 
 	"github.com/stashapp/stash/pkg/models"
@@ -237,3 +239,107 @@ func TestToJSON(t *testing.T) {
 
 	db.AssertExpectations(t)
 }
+
+func TestExportAll(t *testing.T) {
+	const (
+		sharedParentID = 20
+		childID1       = 21
+		childID2       = 22
+	)
+
+	newStudios := func() []*models.Studio {
+		parent := createEmptyStudio(sharedParentID)
+		parent.Name = parentStudioName
+		child1 := createEmptyStudio(childID1)
+		child1.Name = "child1"
+		child1.ParentID = &parent.ID
+		child2 := createEmptyStudio(childID2)
+		child2.Name = "child2"
+		child2.ParentID = &parent.ID
+		return []*models.Studio{&parent, &child1, &child2}
+	}
+
+	ids := []int{sharedParentID, childID1, childID2}
+
+	tests := []struct {
+		name   string
+		format ExportFormat
+		decode func(data []byte) ([]jsonschema.Studio, error)
+	}{
+		{
+			"json",
+			ExportFormatJSON,
+			func(data []byte) ([]jsonschema.Studio, error) {
+				var ret []jsonschema.Studio
+				err := stdjson.Unmarshal(data, &ret)
+				return ret, err
+			},
+		},
+		{
+			"ndjson",
+			ExportFormatNDJSON,
+			func(data []byte) ([]jsonschema.Studio, error) {
+				var ret []jsonschema.Studio
+				for _, line := range bytes.Split(bytes.TrimSpace(data), []byte("\n")) {
+					var s jsonschema.Studio
+					if err := stdjson.Unmarshal(line, &s); err != nil {
+						return nil, err
+					}
+					ret = append(ret, s)
+				}
+				return ret, nil
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := mocks.NewDatabase()
+
+			db.Studio.On("All", testCtx).Return(newStudios(), nil).Once()
+			db.Studio.On("GetCustomFieldsBulk", testCtx, ids).Return([]models.CustomFieldMap{nil, customFields, nil}, nil).Once()
+			db.Studio.On("GetImage", testCtx, sharedParentID).Return(nil, nil).Once()
+			db.Studio.On("GetImage", testCtx, childID1).Return(imageBytes, nil).Once()
+			db.Studio.On("GetImage", testCtx, childID2).Return(nil, nil).Once()
+
+			var buf bytes.Buffer
+			if err := ExportAll(testCtx, db.Studio, &buf, tt.format); err != nil {
+				t.Fatalf("ExportAll() error = %v", err)
+			}
+
+			got, err := tt.decode(buf.Bytes())
+			if err != nil {
+				t.Fatalf("decoding output: %v", err)
+			}
+
+			if assert.Len(t, got, 3) {
+				assert.Equal(t, parentStudioName, got[0].Name)
+				assert.Empty(t, got[0].ParentStudio)
+				assert.Empty(t, got[0].Image)
+
+				assert.Equal(t, "child1", got[1].Name)
+				assert.Equal(t, parentStudioName, got[1].ParentStudio)
+				assert.Equal(t, image, got[1].Image)
+				assert.Equal(t, customFields, got[1].CustomFields)
+
+				assert.Equal(t, "child2", got[2].Name)
+				assert.Equal(t, parentStudioName, got[2].ParentStudio)
+				assert.Empty(t, got[2].CustomFields)
+			}
+
+			// parent studio and custom fields must not be looked up per record
+			db.Studio.AssertNotCalled(t, "Find", testCtx, sharedParentID)
+			db.Studio.AssertNotCalled(t, "GetCustomFields", testCtx, childID1)
+			db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestExportAllInvalidFormat(t *testing.T) {
+	db := mocks.NewDatabase()
+
+	var buf bytes.Buffer
+	err := ExportAll(testCtx, db.Studio, &buf, ExportFormat("xml"))
+	assert.Error(t, err)
+	assert.Empty(t, buf.String())
+}